@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MemberBalanceView is a member's net balance as returned by the balance tools.
+type MemberBalanceView struct {
+	Name    string `json:"name"`
+	Balance string `json:"balance" jsonschema_description:"net balance in dollars; positive means others owe them, negative means they owe"`
+}
+
+type MembersByBalanceInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to rank"`
+}
+
+type MembersByBalanceOutput struct {
+	Members []MemberBalanceView `json:"members" jsonschema_description:"members from the biggest creditor to the biggest debtor"`
+}
+
+func MembersByBalance(ctx context.Context, req *mcp.CallToolRequest, input *MembersByBalanceInput) (*mcp.CallToolResult, *MembersByBalanceOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to rank its members by balance")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &MembersByBalanceOutput{
+		Members: toMemberBalanceViews(group.MembersByBalance()),
+	}
+	return nil, output, nil
+}
+
+type GetBalancesInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group whose balances to show"`
+	Person    string `json:"person,omitempty" jsonschema_description:"only show this person's balance; leave empty for everyone"`
+}
+
+type GetBalancesOutput struct {
+	Balances map[string]float64 `json:"balances" jsonschema_description:"Map person->net balance in dollars, in name order; positive means others owe them, negative means they owe"`
+}
+
+func GetBalances(ctx context.Context, req *mcp.CallToolRequest, input *GetBalancesInput) (*mcp.CallToolResult, *GetBalancesOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show its balances")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	// encoding/json writes map keys in sorted order, so the balances come out by name.
+	output := &GetBalancesOutput{}
+	if input.Person == "" {
+		output.Balances = group.GetAllBalances()
+		return nil, output, nil
+	}
+	balance, err := group.GetPersonBalance(input.Person)
+	if err != nil {
+		return nil, nil, err
+	}
+	output.Balances = map[string]float64{strings.TrimSpace(input.Person): balance}
+	return nil, output, nil
+}
+
+func toMemberBalanceViews(members []groups.MemberBalance) []MemberBalanceView {
+	views := make([]MemberBalanceView, 0, len(members))
+	for _, m := range members {
+		views = append(views, MemberBalanceView{
+			Name:    m.Name,
+			Balance: formatMicroCents(m.BalanceMicroCents),
+		})
+	}
+	return views
+}
+
+type PaidVsOwedInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to break down"`
+}
+
+// PaidVsOwedView is what a member paid and what their shares came to.
+type PaidVsOwedView struct {
+	Name    string `json:"name"`
+	Paid    string `json:"paid" jsonschema_description:"total paid towards expenses, in dollars"`
+	Owed    string `json:"owed" jsonschema_description:"total of their shares, in dollars"`
+	Summary string `json:"summary"`
+}
+
+type PaidVsOwedOutput struct {
+	Members []PaidVsOwedView `json:"members" jsonschema_description:"members in name order"`
+}
+
+func PaidVsOwed(ctx context.Context, req *mcp.CallToolRequest, input *PaidVsOwedInput) (*mcp.CallToolResult, *PaidVsOwedOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to break down what each member paid and owed")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	totals := group.PaidVsOwed()
+	output := &PaidVsOwedOutput{
+		Members: make([]PaidVsOwedView, 0, len(totals)),
+	}
+	for _, name := range group.GetPeople() {
+		paid, owed := formatMicroCents(totals[name][0]), formatMicroCents(totals[name][1])
+		output.Members = append(output.Members, PaidVsOwedView{
+			Name:    name,
+			Paid:    paid,
+			Owed:    owed,
+			Summary: fmt.Sprintf("%s paid %s and their share was %s", name, paid, owed),
+		})
+	}
+	return nil, output, nil
+}
+
+type LeaderboardInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to rank"`
+}
+
+// LeaderboardView is one place on the leaderboard.
+type LeaderboardView struct {
+	Rank int    `json:"rank"`
+	Name string `json:"name"`
+	Paid string `json:"paid" jsonschema_description:"total fronted for expenses, in dollars"`
+	Owed string `json:"owed" jsonschema_description:"total of their own shares, in dollars"`
+	Net  string `json:"net" jsonschema_description:"paid minus owed, in dollars"`
+}
+
+type LeaderboardOutput struct {
+	Entries []LeaderboardView `json:"entries" jsonschema_description:"members from the most generous down"`
+}
+
+func Leaderboard(ctx context.Context, req *mcp.CallToolRequest, input *LeaderboardInput) (*mcp.CallToolResult, *LeaderboardOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show its leaderboard")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	entries := group.Leaderboard()
+	output := &LeaderboardOutput{
+		Entries: make([]LeaderboardView, 0, len(entries)),
+	}
+	for i, e := range entries {
+		output.Entries = append(output.Entries, LeaderboardView{
+			Rank: i + 1,
+			Name: e.Name,
+			Paid: formatMicroCents(e.PaidMicroCents),
+			Owed: formatMicroCents(e.OwedMicroCents),
+			Net:  formatMicroCents(e.NetMicroCents),
+		})
+	}
+	return nil, output, nil
+}
+
+type NonPayersInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type NonPayersOutput struct {
+	Names   []string `json:"names" jsonschema_description:"members who haven't paid for any expense yet"`
+	Summary string   `json:"summary"`
+}
+
+func NonPayers(ctx context.Context, req *mcp.CallToolRequest, input *NonPayersInput) (*mcp.CallToolResult, *NonPayersOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to find who hasn't paid for anything")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	names := group.NonPayers()
+	summary := "everyone has paid for something"
+	if len(names) > 0 {
+		summary = fmt.Sprintf("haven't covered anything yet: %s", strings.Join(names, ", "))
+	}
+	output := &NonPayersOutput{
+		Names:   names,
+		Summary: summary,
+	}
+	return nil, output, nil
+}
+
+type ReimbursementNeededInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Person    string `json:"person,omitempty" jsonschema_description:"person who fronted costs"`
+}
+
+type ReimbursementNeededOutput struct {
+	Total     string            `json:"total" jsonschema_description:"dollars the person is still owed overall"`
+	Breakdown map[string]string `json:"breakdown,omitempty" jsonschema_description:"Map debtor->dollars they should pay the person"`
+	Summary   string            `json:"summary"`
+}
+
+func ReimbursementNeeded(ctx context.Context, req *mcp.CallToolRequest, input *ReimbursementNeededInput) (*mcp.CallToolResult, *ReimbursementNeededOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to work out a reimbursement")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Person == "" {
+		return nil, nil, errors.New("person is required")
+	}
+
+	total, breakdown, err := group.ReimbursementNeeded(input.Person)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ReimbursementNeededOutput{
+		Total:     formatMicroCents(total),
+		Breakdown: make(map[string]string, len(breakdown)),
+		Summary:   fmt.Sprintf("%s isn't owed anything; they are already whole", input.Person),
+	}
+	for name, amount := range breakdown {
+		output.Breakdown[name] = formatMicroCents(amount)
+	}
+	if total > 0 {
+		output.Summary = fmt.Sprintf("%s is still owed %s", input.Person, formatMicroCents(total))
+	}
+	return nil, output, nil
+}
+
+type ExplainBalanceInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Person    string `json:"person,omitempty" jsonschema_description:"person whose balance to explain"`
+}
+
+type ExplainBalanceOutput struct {
+	Explanation string `json:"explanation" jsonschema_description:"the balance in plain English, addressed to the person, naming the expenses behind each debt"`
+}
+
+func ExplainBalance(ctx context.Context, req *mcp.CallToolRequest, input *ExplainBalanceInput) (*mcp.CallToolResult, *ExplainBalanceOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to explain a balance")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Person == "" {
+		return nil, nil, errors.New("person is required")
+	}
+
+	explanation, err := group.ExplainBalance(input.Person)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExplainBalanceOutput{
+		Explanation: explanation,
+	}
+	return nil, output, nil
+}
+
+type PersonStatementInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Person    string `json:"person,omitempty" jsonschema_description:"person to write the statement for"`
+}
+
+type PersonStatementOutput struct {
+	Statement string `json:"statement" jsonschema_description:"the person's shares, what they paid, their net balance and how to settle up"`
+}
+
+func PersonStatement(ctx context.Context, req *mcp.CallToolRequest, input *PersonStatementInput) (*mcp.CallToolResult, *PersonStatementOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to write a statement")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Person == "" {
+		return nil, nil, errors.New("person is required")
+	}
+
+	statement, err := group.PersonStatement(input.Person)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &PersonStatementOutput{
+		Statement: statement,
+	}
+	return nil, output, nil
+}
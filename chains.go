@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"expense-splitter/groups"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ChainView is a suggested settlement chain as returned by settlement_chains.
+type ChainView struct {
+	From    string `json:"from" jsonschema_description:"person who pays"`
+	Via     string `json:"via" jsonschema_description:"intermediary who must agree; their debt to 'to' shrinks by the amount"`
+	To      string `json:"to" jsonschema_description:"person who is paid"`
+	Amount  string `json:"amount" jsonschema_description:"amount in dollars"`
+	Summary string `json:"summary"`
+}
+
+type SettlementChainsInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type SettlementChainsOutput struct {
+	Chains []ChainView `json:"chains"`
+}
+
+func SettlementChains(ctx context.Context, req *mcp.CallToolRequest, input *SettlementChainsInput) (*mcp.CallToolResult, *SettlementChainsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to look for settlement chains")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &SettlementChainsOutput{
+		Chains: toChainViews(group.SettlementChains()),
+	}
+	return nil, output, nil
+}
+
+func toChainViews(chains []groups.Chain) []ChainView {
+	views := make([]ChainView, 0, len(chains))
+	for _, c := range chains {
+		amount := formatMicroCents(c.AmountMicroCents)
+		views = append(views, ChainView{
+			From:    c.From,
+			Via:     c.Via,
+			To:      c.To,
+			Amount:  amount,
+			Summary: fmt.Sprintf("%s pays %s %s directly instead of through %s", c.From, c.To, amount, c.Via),
+		})
+	}
+	return views
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type SetHomeCurrencyInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to configure"`
+	Currency  string `json:"currency" jsonschema_description:"ISO 4217 code, e.g. USD; expenses without a currency are in it. It can't change once the group has expenses or debts"`
+}
+
+type SetHomeCurrencyOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+type HomeCurrencyReportInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to report on"`
+}
+
+type HomeCurrencyReportOutput struct {
+	Report string `json:"report" jsonschema_description:"every expense and payment in the home currency, with the amount as entered and the rate of converted expenses, and the total spent"`
+}
+
+func SetHomeCurrency(ctx context.Context, req *mcp.CallToolRequest, input *SetHomeCurrencyInput) (*mcp.CallToolResult, *SetHomeCurrencyOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to set its home currency")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Currency == "" {
+		return nil, nil, errors.New("currency is required")
+	}
+
+	if err := group.SetHomeCurrency(input.Currency); err != nil {
+		return nil, nil, err
+	}
+
+	output := &SetHomeCurrencyOutput{
+		Msg: fmt.Sprintf("home currency set to %s", group.HomeCurrency()),
+	}
+	return nil, output, nil
+}
+
+func HomeCurrencyReport(ctx context.Context, req *mcp.CallToolRequest, input *HomeCurrencyReportInput) (*mcp.CallToolResult, *HomeCurrencyReportOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to report in its home currency")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &HomeCurrencyReportOutput{
+		Report: group.ReportInHomeCurrency(),
+	}
+	return nil, output, nil
+}
+
+type SettleUpConvertedInput struct {
+	GroupName string  `json:"group_name,omitempty" jsonschema_description:"group to settle"`
+	Currency  string  `json:"currency,omitempty" jsonschema_description:"ISO 4217 code to settle in, e.g. USD"`
+	Rate      float64 `json:"rate,omitempty" jsonschema_description:"value of one unit of the group's home currency in the settlement currency, e.g. 0.92 to settle a USD group in EUR; not needed to settle in the home currency"`
+}
+
+type SettleUpConvertedOutput struct {
+	Currency  string           `json:"currency" jsonschema_description:"currency of the transfers"`
+	Transfers []SettlementView `json:"transfers" jsonschema_description:"the fewest transfers that settle everything, amounts in the settlement currency"`
+	Note      string           `json:"note"`
+}
+
+func SettleUpConverted(ctx context.Context, req *mcp.CallToolRequest, input *SettleUpConvertedInput) (*mcp.CallToolResult, *SettleUpConvertedOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to settle up in one currency")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Currency == "" {
+		return nil, nil, errors.New("currency is required")
+	}
+
+	settlements, err := group.SettleUpConverted(input.Currency, input.Rate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(input.Currency))
+	output := &SettleUpConvertedOutput{
+		Currency:  currency,
+		Transfers: make([]SettlementView, 0, len(settlements)),
+		Note:      "the home-currency settlement converted at the given rate; each transfer is rounded to the cent, so amounts can be a cent off",
+	}
+	for _, s := range settlements {
+		output.Transfers = append(output.Transfers, SettlementView{
+			From:             s.From,
+			To:               s.To,
+			Amount:           fmt.Sprintf("%.2f %s", float64(s.AmountMicroCents)/100_000, currency),
+			AmountMicroCents: s.AmountMicroCents,
+		})
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"expense-splitter/groups"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// PairDebtView is an outstanding debt between two people as returned by the debt tools.
+type PairDebtView struct {
+	From   string `json:"from" jsonschema_description:"person who owes"`
+	To     string `json:"to" jsonschema_description:"person who is owed"`
+	Amount string `json:"amount" jsonschema_description:"amount in dollars"`
+}
+
+type TopDebtsInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+	N         int    `json:"n,omitempty" jsonschema_description:"how many debts to return, defaults to 5"`
+}
+
+type TopDebtsOutput struct {
+	Debts []PairDebtView `json:"debts"`
+}
+
+func TopDebts(ctx context.Context, req *mcp.CallToolRequest, input *TopDebtsInput) (*mcp.CallToolResult, *TopDebtsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to list its largest debts")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	n := input.N
+	if n <= 0 {
+		n = 5
+	}
+
+	output := &TopDebtsOutput{
+		Debts: toPairDebtViews(group.TopDebts(n)),
+	}
+	return nil, output, nil
+}
+
+func toPairDebtViews(debts []groups.PairDebt) []PairDebtView {
+	views := make([]PairDebtView, 0, len(debts))
+	for _, d := range debts {
+		views = append(views, PairDebtView{
+			From:   d.From,
+			To:     d.To,
+			Amount: formatMicroCents(d.AmountMicroCents),
+		})
+	}
+	return views
+}
+
+type GrossDebtsInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type GrossDebtsOutput struct {
+	GrossDebts map[string]float64 `json:"gross_debts" jsonschema_description:"Map \"A to pay B\"->dollars, with both directions of a pair kept apart instead of netted; payments are left out"`
+}
+
+func GrossDebts(ctx context.Context, req *mcp.CallToolRequest, input *GrossDebtsInput) (*mcp.CallToolResult, *GrossDebtsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show its gross debts")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &GrossDebtsOutput{
+		GrossDebts: group.GrossDebts(),
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxElicitations caps the elicitation round-trips of a single tool call. AddExpense
+// asks for the amount again when an elicited one doesn't parse, so without a cap a
+// client that keeps sending invalid amounts would keep the call going forever. Every
+// handler elicits through an elicitationBudget so a retry loop added later is bounded too.
+const maxElicitations = 8
+
+var errTooManyElicitations = fmt.Errorf("too many invalid attempts: gave up after %d questions; call the tool again with the missing values filled in", maxElicitations)
+
+// elicitationBudget counts the elicitations made during one tool call.
+type elicitationBudget struct {
+	used int
+}
+
+// elicit sends an elicitation request to the client, unless the budget is spent.
+func (b *elicitationBudget) elicit(ctx context.Context, req *mcp.CallToolRequest, params *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+	if b.used >= maxElicitations {
+		return nil, errTooManyElicitations
+	}
+	ss, ok := req.GetSession().(*mcp.ServerSession)
+	if !ok || ss == nil {
+		return nil, fmt.Errorf("expected *mcp.ServerSession, got %T", req.GetSession())
+	}
+	b.used++
+	return ss.Elicit(ctx, params)
+}
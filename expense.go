@@ -8,6 +8,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -15,11 +16,35 @@ import (
 type AddExpenseInput struct {
 	GroupName        *string            `json:"group_name,omitempty" jsonschema:"group where this expense belongs"`
 	Amount           *string            `json:"amount,omitempty" jsonschema:"amount in dollars (e.g. \"208\", \"208.50\")"`
+	Discount         *string            `json:"discount,omitempty" jsonschema:"coupon or discount in dollars taken off the amount"`
+	PayerPersonal    *string            `json:"payer_personal,omitempty" jsonschema:"dollars of the amount that were for the payer alone; only the rest is split"`
+	CoveredPercent   *float64           `json:"covered_percent,omitempty" jsonschema:"percent of the amount borne by someone outside the group, e.g. 80 when the company covers 80%"`
+	CoveredBy        *string            `json:"covered_by,omitempty" jsonschema:"who covers covered_percent, e.g. Company; need not be a member"`
+	Tip              *string            `json:"tip,omitempty" jsonschema:"dollars of the amount that were tip"`
+	TipSplitWeights  map[string]float64 `json:"tip_split_weights,omitempty" jsonschema:"Map person->weight for splitting the tip alone, e.g. by how much service mattered to them; the rest splits by split_method"`
+	Currency         *string            `json:"currency,omitempty" jsonschema:"ISO 4217 code the amount is in, e.g. EUR; defaults to the group's home currency"`
+	ExchangeRate     *float64           `json:"exchange_rate,omitempty" jsonschema:"value of one unit of currency in the group's home currency, e.g. 1.08 for EUR in a USD group; required when currency isn't the home currency, and every amount is converted with it"`
 	PaidBy           *string            `json:"paid_by,omitempty" jsonschema:"the person who paid for this expense"`
+	PaidByMap        map[string]float64 `json:"paid_by_map,omitempty" jsonschema:"Map person->dollars paid, when several people paid; must add up to amount"`
 	Description      *string            `json:"description,omitempty" jsonschema:"description of the expense"`
-	SplitMethod      *string            `json:"split_method,omitempty" jsonschema:"how to split the expense" jsonschema_enum:"equal,percentage,weights" jsonschema_default:"equal"`
+	SplitMethod      *string            `json:"split_method,omitempty" jsonschema:"how to split the expense" jsonschema_enum:"equal,percentage,weights,duration,headcount,income,exact" jsonschema_default:"equal"`
 	SplitPercentages map[string]float64 `json:"split_percentages,omitempty" jsonschema:"percent ownership by person, values 0..100"`
 	SplitWeights     map[string]float64 `json:"split_weights,omitempty" jsonschema:"Map person->weight (relative shares)"`
+	SplitHeadcount   map[string]int     `json:"split_headcount,omitempty" jsonschema:"Map person->number of people they stand for, e.g. 3 for someone with two kids"`
+	SplitExact       map[string]string  `json:"split_exact,omitempty" jsonschema:"Map person->dollars they owe, e.g. {\"Alice\": \"12.50\"}; must add up to amount, less any tip split by tip_split_weights, payer's personal or covered portion"`
+
+	SettledParticipants []string `json:"settled_participants,omitempty" jsonschema:"participants who already paid their share on the spot"`
+
+	Excluded []string `json:"excluded,omitempty" jsonschema:"members who take no part in the expense, for any split method"`
+
+	ParticipantNotes map[string]string `json:"participant_notes,omitempty" jsonschema:"Map participant->short note explaining their share, e.g. \"had the steak\""`
+
+	Labels []string `json:"labels,omitempty" jsonschema:"lowercase tags such as recurring, business or reimbursable"`
+
+	Event *string `json:"event,omitempty" jsonschema:"sub-event of the trip the expense belongs to, e.g. Day 1 dinner or concert"`
+
+	PeriodStart *string `json:"period_start,omitempty" jsonschema:"start date (YYYY-MM-DD) of the period a duration split covers"`
+	PeriodEnd   *string `json:"period_end,omitempty" jsonschema:"last day (YYYY-MM-DD) of the period a duration split covers, inclusive: a stay from the 1st to the 3rd is three days"`
 }
 
 type AddExpenseOutput struct {
@@ -30,10 +55,87 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 	groupName := input.GroupName
 	amountStr := input.Amount
 	paidBy := input.PaidBy
+	paidByMap := input.PaidByMap
 	expenseDescription := input.Description
 	splitMethod := input.SplitMethod
 	percentages := input.SplitPercentages
 	weights := input.SplitWeights
+	settledParticipants := input.SettledParticipants
+	budget := &elicitationBudget{}
+	// an elicited amount that fails validation is asked again on its own
+	amountElicited := false
+
+	// ask for every missing field in one form instead of one round-trip per field
+	needPayer := paidBy == nil && len(paidByMap) == 0
+	missing := 0
+	for _, m := range []bool{groupName == nil, amountStr == nil, needPayer, expenseDescription == nil} {
+		if m {
+			missing++
+		}
+	}
+	if missing >= 2 {
+		var members []string
+		if groupName != nil {
+			// a known group that can't take an expense shouldn't get the whole form first
+			g, exists := groups.Get(*groupName)
+			if !exists {
+				return nil, nil, errors.New("no such group")
+			}
+			if ok, reason := g.CanAddExpense(); !ok {
+				return nil, nil, errors.New(reason)
+			}
+			members = g.GetPeople()
+		}
+		needSplitMap := splitMethod == nil ||
+			(*splitMethod == "percentage" && len(percentages) == 0) ||
+			(*splitMethod == "weights" && len(weights) == 0)
+		schema := combinedExpenseSchema(groupName == nil, amountStr == nil, needPayer, expenseDescription == nil,
+			splitMethod == nil, needSplitMap, members)
+		er, err := sendExpenseElicitRequest(ctx, req, budget, "I need a few details to add the expense", schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch er.Action {
+		case "accept":
+			if v, ok := er.Content["group_name"].(string); ok && groupName == nil {
+				groupName = &v
+			}
+			if v := elicitedAmount(er.Content["amount"]); v != nil && amountStr == nil {
+				amountStr = v
+				amountElicited = true
+			}
+			if v, ok := er.Content["paid_by"].(string); ok && needPayer {
+				paidBy = &v
+			}
+			if v, ok := er.Content["description"].(string); ok && expenseDescription == nil {
+				expenseDescription = &v
+			}
+			if v, ok := er.Content["split_method"].(string); ok && splitMethod == nil {
+				splitMethod = &v
+			}
+			if v, ok := er.Content["split_map"].(string); ok && needSplitMap && splitMethod != nil {
+				m, err := parseSplitMapText(v, *splitMethod == "percentage")
+				if err != nil {
+					return nil, nil, err
+				}
+				switch *splitMethod {
+				case "percentage":
+					percentages = m
+				case "weights":
+					weights = m
+				}
+			}
+		case "decline":
+			// fall back to asking for one field at a time
+		default:
+			// user cancelled
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "No worries — cancelled."},
+				},
+			}, nil, nil
+		}
+	}
 
 	if groupName == nil {
 		msg := "What's the group name?"
@@ -47,7 +149,7 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 			},
 			"required": []any{"group_name"},
 		}
-		er, err := sendExpenseElicitRequest(ctx, req, msg, schema)
+		er, err := sendExpenseElicitRequest(ctx, req, budget, msg, schema)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -65,12 +167,16 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 		if groupName == nil || strings.TrimSpace(*groupName) == "" {
 			return nil, nil, errors.New("group_name is required")
 		}
+	}
 
-		// check if group exists in the app
-		_, exists := groups.Get(*groupName)
-		if !exists {
-			return nil, nil, errors.New("no such group")
-		}
+	// check if group exists in the app and can take an expense before
+	// walking the user through the rest of the elicitation flow
+	group, exists := groups.Get(*groupName)
+	if !exists {
+		return nil, nil, errors.New("no such group")
+	}
+	if ok, reason := group.CanAddExpense(); !ok {
+		return nil, nil, errors.New(reason)
 	}
 	//
 	if amountStr == nil {
@@ -86,7 +192,7 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 			},
 			"required": []any{"amount"},
 		}
-		er, err := sendExpenseElicitRequest(ctx, req, msg, schema)
+		er, err := sendExpenseElicitRequest(ctx, req, budget, msg, schema)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -98,16 +204,14 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 				},
 			}, nil, nil
 		}
-		if v, ok := er.Content["amount"].(string); ok {
-			amountStr = &v
-		}
+		amountStr = elicitedAmount(er.Content["amount"])
 		if amountStr == nil {
 			return nil, nil, errors.New("amount is required")
 		}
+		amountElicited = true
 	}
 	//
-	if paidBy == nil {
-		group, _ := groups.Get(*groupName)
+	if paidBy == nil && len(paidByMap) == 0 {
 		people := group.GetPeople()
 		enumPeople := make([]any, 0, len(people))
 		for _, p := range people {
@@ -126,7 +230,7 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 			},
 			"required": []any{"paid_by"},
 		}
-		er, err := sendExpenseElicitRequest(ctx, req, msg, schema)
+		er, err := sendExpenseElicitRequest(ctx, req, budget, msg, schema)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -160,7 +264,7 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 			},
 			"required": []any{"description"},
 		}
-		er, err := sendExpenseElicitRequest(ctx, req, msg, schema)
+		er, err := sendExpenseElicitRequest(ctx, req, budget, msg, schema)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -186,39 +290,19 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 		splitMethod = &v
 	}
 	if *splitMethod == "percentage" && len(percentages) == 0 {
-		if groupName == nil || strings.TrimSpace(*groupName) == "" {
-			return nil, nil, errors.New("group_name is required")
-		}
-		group, _ := groups.Get(*groupName)
-		people := group.GetPeople()
-		enumPeople := make([]any, 0, len(people))
-		for _, p := range people {
-			enumPeople = append(enumPeople, p)
-		}
-
-		msg := "I need person to percentage map to split the expenses"
+		msg := fmt.Sprintf("I need each person's percentage to split the expense (members: %s)", strings.Join(group.GetPeople(), ", "))
 		schema := map[string]any{
 			"type":                 "object",
 			"additionalProperties": false,
 			"properties": map[string]any{
 				"split_percentages": map[string]any{
-					"type":          "object",
-					"minProperties": 1,
-					"propertyNames": map[string]any{
-						"type": "string",
-						"enum": enumPeople,
-					},
-					"additionalProperties": map[string]any{
-						"type":    "number",
-						"minimum": 0,
-						"maximum": 100,
-					},
-					"description": "Map of person->percentage. Must sum to 100.",
+					"type":        "string",
+					"description": "name=percentage pairs separated by commas that sum to 100, e.g. \"Alice=60, Bob=40\"; fractions of the whole such as \"Alice=1/3\" work too",
 				},
 			},
 			"required": []any{"split_percentages"},
 		}
-		er, err := sendExpenseElicitRequest(ctx, req, msg, schema)
+		er, err := sendExpenseElicitRequest(ctx, req, budget, msg, schema)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -231,54 +315,27 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 			}, nil, nil
 		}
 
-		if m, ok := er.Content["split_percentages"].(map[string]interface{}); ok {
-			percentages = make(map[string]float64)
-			for name, pct := range m {
-				switch x := pct.(type) {
-				case float64:
-					percentages[name] = x
-				case string:
-					if f, err := strconv.ParseFloat(x, 64); err == nil {
-						percentages[name] = f
-					}
-				}
+		if v, ok := er.Content["split_percentages"].(string); ok {
+			if percentages, err = parseSplitMapText(v, true); err != nil {
+				return nil, nil, err
 			}
 		}
 	}
 	//
 	if *splitMethod == "weights" && len(weights) == 0 {
-		if groupName == nil || strings.TrimSpace(*groupName) == "" {
-			return nil, nil, errors.New("group_name is required")
-		}
-		group, _ := groups.Get(*groupName)
-		people := group.GetPeople()
-		enumPeople := make([]any, 0, len(people))
-		for _, p := range people {
-			enumPeople = append(enumPeople, p)
-		}
-
-		msg := "I need person to weights map to split the expenses"
+		msg := fmt.Sprintf("I need each person's weight to split the expense (members: %s)", strings.Join(group.GetPeople(), ", "))
 		schema := map[string]any{
 			"type":                 "object",
 			"additionalProperties": false,
 			"properties": map[string]any{
 				"split_weights": map[string]any{
-					"type":          "object",
-					"minProperties": 1,
-					"propertyNames": map[string]any{
-						"type": "string",
-						"enum": enumPeople, // build from group members
-					},
-					"additionalProperties": map[string]any{
-						"type":    "number",
-						"minimum": 0,
-					},
-					"description": "Map of person->weight. Weight 0 excludes the person from this expense. At least one weight must be > 0.",
+					"type":        "string",
+					"description": "name=weight pairs separated by commas, e.g. \"Alice=2, Bob=1\"; fractions such as \"Alice=1/3\" work too. Weight 0 excludes the person from this expense. At least one weight must be > 0.",
 				},
 			},
 			"required": []any{"split_weights"},
 		}
-		er, err := sendExpenseElicitRequest(ctx, req, msg, schema)
+		er, err := sendExpenseElicitRequest(ctx, req, budget, msg, schema)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -290,33 +347,72 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 				},
 			}, nil, nil
 		}
-		if m, ok := er.Content["split_weights"].(map[string]interface{}); ok {
-			weights = make(map[string]float64)
-			for name, w := range m {
-				switch x := w.(type) {
-				case float64:
-					weights[name] = x
-				case string:
-					if f, err := strconv.ParseFloat(x, 64); err == nil {
-						weights[name] = f
-					}
-				}
+		if v, ok := er.Content["split_weights"].(string); ok {
+			if weights, err = parseSplitMapText(v, false); err != nil {
+				return nil, nil, err
 			}
 		}
 	}
 
-	group, exists := groups.Get(*groupName)
-	if !exists {
-		return nil, nil, errors.New("no such group exists")
-	}
 	people := group.GetPeople()
 
 	// after ensuring group exists and people list known
 	// validate
-	totalMicroCents, err := parseDollarsToMicroCents(*amountStr)
+	totalMicroCents, err := groups.ParseDollars(*amountStr)
+	for err != nil && amountElicited {
+		// re-ask only the amount; the answers so far are offered as defaults so they
+		// don't have to be typed again
+		payer := ""
+		if paidBy != nil && len(paidByMap) == 0 {
+			payer, _, _ = group.ResolveName(*paidBy)
+		}
+		msg := fmt.Sprintf("%v. What is the amount in dollars?", err)
+		er, elicitErr := sendExpenseElicitRequest(ctx, req, budget, msg, retryAmountSchema(payer, *expenseDescription, people))
+		if elicitErr != nil {
+			return nil, nil, elicitErr
+		}
+		if er.Action != "accept" {
+			// user declined/cancelled
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "No worries — cancelled."},
+				},
+			}, nil, nil
+		}
+		if v := elicitedAmount(er.Content["amount"]); v != nil {
+			amountStr = v
+		}
+		if v, ok := er.Content["paid_by"].(string); ok && strings.TrimSpace(v) != "" && len(paidByMap) == 0 {
+			paidBy = &v
+		}
+		if v, ok := er.Content["description"].(string); ok && strings.TrimSpace(v) != "" {
+			expenseDescription = &v
+		}
+		totalMicroCents, err = groups.ParseDollars(*amountStr)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
+	discountMicroCents := int64(0)
+	if input.Discount != nil {
+		if discountMicroCents, err = groups.ParseDollars(*input.Discount); err != nil {
+			return nil, nil, fmt.Errorf("invalid discount: %w", err)
+		}
+	}
+
+	payerPersonalMicroCents := int64(0)
+	if input.PayerPersonal != nil {
+		if payerPersonalMicroCents, err = groups.ParseDollars(*input.PayerPersonal); err != nil {
+			return nil, nil, fmt.Errorf("invalid payer_personal: %w", err)
+		}
+	}
+
+	tipMicroCents := int64(0)
+	if input.Tip != nil {
+		if tipMicroCents, err = groups.ParseDollars(*input.Tip); err != nil {
+			return nil, nil, fmt.Errorf("invalid tip: %w", err)
+		}
+	}
 
 	if splitMethod == nil {
 		return nil, nil, errors.New("split_method is required")
@@ -343,6 +439,35 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 			}
 		}
 	}
+	if *splitMethod == "headcount" && len(input.SplitHeadcount) == 0 {
+		return nil, nil, errors.New("split_headcount required for headcount split")
+	}
+	var exact map[string]int64
+	if len(input.SplitExact) > 0 {
+		exact = make(map[string]int64, len(input.SplitExact))
+		for name, dollars := range input.SplitExact {
+			if exact[name], err = groups.ParseDollars(dollars); err != nil {
+				return nil, nil, fmt.Errorf("invalid split_exact amount for %s: %w", name, err)
+			}
+		}
+	}
+	if *splitMethod == "exact" && len(exact) == 0 {
+		return nil, nil, errors.New("split_exact required for exact split")
+	}
+	var periodStart, periodEnd time.Time
+	if *splitMethod == "duration" {
+		if input.PeriodStart == nil || input.PeriodEnd == nil {
+			return nil, nil, errors.New("period_start and period_end are required for duration split")
+		}
+		if periodStart, err = time.ParseInLocation(time.DateOnly, *input.PeriodStart, time.Local); err != nil {
+			return nil, nil, fmt.Errorf("invalid period_start %q, expected YYYY-MM-DD", *input.PeriodStart)
+		}
+		if periodEnd, err = time.ParseInLocation(time.DateOnly, *input.PeriodEnd, time.Local); err != nil {
+			return nil, nil, fmt.Errorf("invalid period_end %q, expected YYYY-MM-DD", *input.PeriodEnd)
+		}
+		// period_end is the last day; the period runs to the end of it
+		periodEnd = periodEnd.AddDate(0, 0, 1)
+	}
 	if *splitMethod == "weights" {
 		// should I support 0 weights? for example, to exclude a person from an expense
 		sumW := 0.0
@@ -358,14 +483,58 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 	}
 
 	// add an expense to the app
-	group.AddExpense(&groups.Expense{
+	expense := &groups.Expense{
 		TotalMicroCents:  totalMicroCents,
-		PaidBy:           *paidBy,
+		PaidByMap:        paidByMap,
 		Description:      *expenseDescription,
 		SplitMethod:      *splitMethod,
 		SplitPercentages: percentages,
 		SplitWeights:     weights,
-	})
+		SplitHeadcount:   input.SplitHeadcount,
+		PeriodStart:      periodStart,
+		PeriodEnd:        periodEnd,
+
+		SplitExactMicroCents: exact,
+
+		SettledParticipants: settledParticipants,
+		Excluded:            input.Excluded,
+		ParticipantNotes:    input.ParticipantNotes,
+		Labels:              input.Labels,
+		DiscountMicroCents:  discountMicroCents,
+
+		PayerPersonalMicroCents: payerPersonalMicroCents,
+		TipMicroCents:           tipMicroCents,
+		TipSplitWeights:         input.TipSplitWeights,
+
+		EnteredBy: callerIdentity(ctx, req),
+	}
+	if paidBy != nil {
+		expense.PaidBy = *paidBy
+	}
+	if input.Event != nil {
+		expense.Event = *input.Event
+	}
+	if input.CoveredPercent != nil {
+		expense.CoveredPercent = *input.CoveredPercent
+	}
+	if input.CoveredBy != nil {
+		expense.CoveredBy = *input.CoveredBy
+	}
+	if input.Currency != nil {
+		expense.Currency = *input.Currency
+	}
+	if input.ExchangeRate != nil {
+		expense.ExchangeRate = *input.ExchangeRate
+	}
+	if len(expense.PaidByMap) == 0 {
+		// suggest "did you mean Bob?" for a mistyped payer
+		if _, _, err := group.ResolveName(expense.PaidBy); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := group.AddExpense(expense); err != nil {
+		return nil, nil, err
+	}
 
 	output := &AddExpenseOutput{
 		Msg: "success",
@@ -378,52 +547,196 @@ func AddExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddExpense
 	}, output, nil
 }
 
-func sendExpenseElicitRequest(ctx context.Context, req *mcp.CallToolRequest, msg string, schema map[string]any) (*mcp.ElicitResult, error) {
-	ss, ok := req.GetSession().(*mcp.ServerSession)
-	if !ok || ss == nil {
-		return nil, fmt.Errorf("expected *mcp.ServerSession, got %T", ss)
+// combinedExpenseSchema is the elicitation form asking for every missing add_expense field
+// at once. Elicitation forms only take flat primitive fields, so the split map is a
+// "name=value, ..." string required when the chosen split method needs one.
+//
+// The other fields are optional: the SDK validates even declined answers against the
+// schema, and anything left blank is asked for one field at a time afterwards.
+func combinedExpenseSchema(needGroup, needAmount, needPayer, needDescription, needMethod, needSplitMap bool, members []string) map[string]any {
+	properties := map[string]any{}
+	if needGroup {
+		properties["group_name"] = map[string]any{
+			"type":        "string",
+			"description": "group name where this expense belong to",
+		}
 	}
-
-	er, err := ss.Elicit(ctx, &mcp.ElicitParams{
-		Mode:            "form",
-		Message:         msg,
-		RequestedSchema: schema,
-	})
-	return er, err
-}
-
-func parseDollarsToMicroCents(s string) (int64, error) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0, fmt.Errorf("amount is empty")
+	if needAmount {
+		properties["amount"] = map[string]any{
+			"type":             "number",
+			"description":      "total amount of the expense in dollars",
+			"exclusiveMinimum": 0,
+		}
 	}
-
-	parts := strings.SplitN(s, ".", 2)
-
-	// dollars
-	dollars, err := strconv.ParseInt(parts[0], 10, 64)
-	if err != nil || dollars < 0 {
-		return 0, fmt.Errorf("invalid dollar amount: %q", s)
+	if needPayer {
+		payer := map[string]any{
+			"type":        "string",
+			"description": "person who paid for the expense",
+		}
+		if len(members) > 0 {
+			enumPeople := make([]any, 0, len(members))
+			for _, p := range members {
+				enumPeople = append(enumPeople, p)
+			}
+			payer["enum"] = enumPeople
+		}
+		properties["paid_by"] = payer
+	}
+	if needDescription {
+		properties["description"] = map[string]any{
+			"type":        "string",
+			"description": "a short description about the expense",
+			"minLength":   3,
+			"maxLength":   100,
+		}
+	}
+	if needMethod {
+		properties["split_method"] = map[string]any{
+			"type":        "string",
+			"description": "how to split the expense; equal when left blank",
+			"enum":        []any{"equal", "percentage", "weights"},
+		}
 	}
 
-	cents := int64(0)
-
-	if len(parts) == 2 {
-		frac := parts[1]
-		if len(frac) > 2 {
-			return 0, fmt.Errorf("too many decimal places: %q", s)
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if needSplitMap {
+		properties["split_map"] = map[string]any{
+			"type":        "string",
+			"description": "for percentage or weights splits: name=value pairs separated by commas, e.g. \"Alice=60, Bob=40\"; fractions such as \"Alice=1/3\" work too",
+		}
+		if needMethod {
+			schema["allOf"] = []any{
+				map[string]any{
+					"if": map[string]any{
+						"properties": map[string]any{"split_method": map[string]any{"enum": []any{"percentage", "weights"}}},
+						"required":   []any{"split_method"},
+					},
+					"then": map[string]any{"required": []any{"split_map"}},
+				},
+			}
+		} else {
+			schema["required"] = []any{"split_map"}
 		}
+	}
+	return schema
+}
 
-		if len(frac) == 1 {
-			frac += "0"
+// retryAmountSchema is the form for asking again for an amount that failed validation. The
+// payer and description collected so far are included with their answers as defaults, so
+// the user only has to correct the amount. payer is left out when it is empty.
+func retryAmountSchema(payer, description string, members []string) map[string]any {
+	properties := map[string]any{
+		"amount": map[string]any{
+			"type":             "number",
+			"description":      "total amount of the expense in dollars, with at most 2 decimals",
+			"exclusiveMinimum": 0,
+		},
+		"description": map[string]any{
+			"type":        "string",
+			"description": "a short description about the expense",
+			"minLength":   3,
+			"maxLength":   100,
+			"default":     description,
+		},
+	}
+	if payer != "" {
+		enumPeople := make([]any, 0, len(members))
+		for _, p := range members {
+			enumPeople = append(enumPeople, p)
+		}
+		properties["paid_by"] = map[string]any{
+			"type":        "string",
+			"description": "person who paid for the expense",
+			"enum":        enumPeople,
+			"default":     payer,
 		}
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   []any{"amount"},
+	}
+}
 
-		c, err := strconv.ParseInt(frac, 10, 64)
+// elicitedAmount returns the amount from an elicitation answer as a string for ParseDollars.
+func elicitedAmount(v any) *string {
+	switch v := v.(type) {
+	case string:
+		return &v
+	case float64:
+		// the form asks for a number; ParseDollars still validates the format
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		return &s
+	}
+	return nil
+}
+
+// parseSplitMapText parses "Alice=60, Bob=40" into a person->value map. Values may also be
+// fractions such as "Alice=1/3", see parseSplitValue.
+func parseSplitMapText(text string, percent bool) (map[string]float64, error) {
+	m := map[string]float64{}
+	for _, pair := range strings.Split(text, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid split_map entry %q, expected name=value", strings.TrimSpace(pair))
+		}
+		f, err := parseSplitValue(value, percent)
 		if err != nil {
-			return 0, fmt.Errorf("invalid cents: %q", s)
+			return nil, fmt.Errorf("invalid split_map value for %s: %q", strings.TrimSpace(name), strings.TrimSpace(value))
 		}
-		cents = c
+		m[strings.TrimSpace(name)] = f
+	}
+	if len(m) == 0 {
+		return nil, errors.New("split_map is empty")
+	}
+	return m, nil
+}
+
+// parseSplitValue parses a split value given as a decimal ("60") or a fraction ("1/3").
+// A fraction is a share of the whole, so for a percentage split it is scaled to percent:
+// "1/3" becomes 33.33... at full precision, and three of them still add up to 100.
+func parseSplitValue(text string, percent bool) (float64, error) {
+	text = strings.TrimSpace(text)
+	num, den, isFraction := strings.Cut(text, "/")
+	if !isFraction {
+		return strconv.ParseFloat(text, 64)
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid fraction %q", text)
 	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(den), 64)
+	if err != nil || d == 0 {
+		return 0, fmt.Errorf("invalid fraction %q", text)
+	}
+	f := n / d
+	if percent {
+		f *= 100
+	}
+	return f, nil
+}
+
+func sendExpenseElicitRequest(ctx context.Context, req *mcp.CallToolRequest, budget *elicitationBudget, msg string, schema map[string]any) (*mcp.ElicitResult, error) {
+	return budget.elicit(ctx, req, &mcp.ElicitParams{
+		Mode:            "form",
+		Message:         msg,
+		RequestedSchema: schema,
+	})
+}
 
-	return (dollars*100 + cents) * 1000, nil
+// formatMicroCents formats micro cents as dollars rounded to the cent, e.g. "$12.50".
+func formatMicroCents(micro int64) string {
+	sign := ""
+	if micro < 0 {
+		sign = "-"
+		micro = -micro
+	}
+	cents := (micro + 500) / 1000
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
 }
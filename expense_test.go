@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connectElicitingClient connects a client that answers the elicitations in order, repeating
+// the last answer, and returns the server session along with a pointer to the number of
+// elicitations.
+func connectElicitingClient(t *testing.T, answers ...*mcp.ElicitResult) (*mcp.ServerSession, *int) {
+	t.Helper()
+	ctx := context.Background()
+	ct, st := mcp.NewInMemoryTransports()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "expense-splitter", Version: "test"}, nil)
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ss.Close() })
+
+	calls := 0
+	client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "test"}, &mcp.ClientOptions{
+		ElicitationHandler: func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			answer := answers[min(calls, len(answers)-1)]
+			calls++
+			return answer, nil
+		},
+	})
+	cs, err := client.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return ss, &calls
+}
+
+func TestAddExpenseCombinedElicitation(t *testing.T) {
+	group, err := groups.Create("combined-form-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ss, calls := connectElicitingClient(t, &mcp.ElicitResult{
+		Action: "accept",
+		Content: map[string]any{
+			"group_name":   "combined-form-trip",
+			"amount":       30.0,
+			"paid_by":      "Alice",
+			"description":  "dinner",
+			"split_method": "percentage",
+			"split_map":    "Alice=50, Bob=50",
+		},
+	})
+
+	_, out, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, &AddExpenseInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == nil {
+		t.Fatal("expected the expense to be added")
+	}
+	if *calls != 1 {
+		t.Fatalf("expected a single elicitation, got %d", *calls)
+	}
+	_, net, err := group.DebtsBetween("Bob", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net != 15*100*1000 {
+		t.Fatalf("expected Bob to owe Alice $15, got %d", net)
+	}
+}
+
+func TestAddExpenseChecksGroupBeforeCombinedForm(t *testing.T) {
+	group, err := groups.Create("solo-form-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	if err := group.AddPerson("Alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	ss, calls := connectElicitingClient(t, &mcp.ElicitResult{Action: "decline"})
+	_, out, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, &AddExpenseInput{GroupName: &group.Name})
+	if err == nil || out != nil {
+		t.Fatalf("expected a one-person group to be rejected, got %v", err)
+	}
+	if *calls != 0 {
+		t.Fatalf("expected no elicitation for a group that can't take an expense, got %d", *calls)
+	}
+}
+
+func TestAddExpenseDeclinedCombinedFormFallsBack(t *testing.T) {
+	group, err := groups.Create("declined-form-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// every sequential question gets the same full answer
+	ss, calls := connectElicitingClient(t,
+		&mcp.ElicitResult{Action: "decline"},
+		&mcp.ElicitResult{
+			Action: "accept",
+			Content: map[string]any{
+				"group_name":  "declined-form-trip",
+				"amount":      20.0,
+				"paid_by":     "Alice",
+				"description": "taxi",
+			},
+		},
+	)
+
+	_, out, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, &AddExpenseInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == nil {
+		t.Fatal("expected the expense to be added")
+	}
+	// the declined combined form, then group, amount, payer and description one at a time
+	if *calls != 5 {
+		t.Fatalf("expected 5 elicitations, got %d", *calls)
+	}
+}
+
+func TestAddExpenseFractionSplitMap(t *testing.T) {
+	group, err := groups.Create("fraction-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ss, _ := connectElicitingClient(t, &mcp.ElicitResult{
+		Action: "accept",
+		Content: map[string]any{
+			"group_name":   "fraction-trip",
+			"amount":       100.0,
+			"paid_by":      "Alice",
+			"description":  "cabin",
+			"split_method": "percentage",
+			"split_map":    "Alice=1/3, Bob=1/3, Charlie=1/3",
+		},
+	})
+
+	_, out, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, &AddExpenseInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == nil {
+		t.Fatal("expected the expense to be added")
+	}
+	for _, name := range []string{"Bob", "Charlie"} {
+		_, net, err := group.DebtsBetween(name, "Alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if net < 3333*1000 || net > 3334*1000 {
+			t.Fatalf("expected %s to owe Alice a third of $100, got %d", name, net)
+		}
+	}
+
+	if _, err := parseSplitValue("1/0", false); err == nil {
+		t.Fatal("expected a zero denominator to be rejected")
+	}
+}
+
+func TestAddExpenseFractionPercentageForm(t *testing.T) {
+	group, err := groups.Create("fraction-form-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// only the split map is missing, so it is asked for on its own
+	ss, calls := connectElicitingClient(t, &mcp.ElicitResult{
+		Action:  "accept",
+		Content: map[string]any{"split_percentages": "Alice=1/3, Bob=1/3, Charlie=1/3"},
+	})
+	amount, payer, description, method := "30", "Alice", "cabin", "percentage"
+	input := &AddExpenseInput{
+		GroupName:   &group.Name,
+		Amount:      &amount,
+		PaidBy:      &payer,
+		Description: &description,
+		SplitMethod: &method,
+	}
+	if _, _, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, input); err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected a single elicitation, got %d", *calls)
+	}
+	_, net, err := group.DebtsBetween("Bob", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net != 10*100*1000 {
+		t.Fatalf("expected Bob to owe Alice a third of $30, got %d", net)
+	}
+
+	// a value that doesn't parse must not quietly drop a person from the split
+	method = "weights"
+	ss, _ = connectElicitingClient(t, &mcp.ElicitResult{
+		Action:  "accept",
+		Content: map[string]any{"split_weights": "Alice=1, Bob=one"},
+	})
+	if _, _, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, input); err == nil {
+		t.Fatal("expected an invalid weight to be rejected")
+	}
+	if n := len(group.ListExpenses()); n != 1 {
+		t.Fatalf("expected only the first expense, got %d", n)
+	}
+}
+
+func TestAddExpenseDurationEndIsInclusive(t *testing.T) {
+	group, err := groups.Create("inclusive-stay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now().AddDate(0, 0, 1)
+	first, last := start.Format(time.DateOnly), start.AddDate(0, 0, 2).Format(time.DateOnly)
+	amount, payer, description, method := "90", "Alice", "cabin", "duration"
+	input := &AddExpenseInput{
+		GroupName:   &group.Name,
+		Amount:      &amount,
+		PaidBy:      &payer,
+		Description: &description,
+		SplitMethod: &method,
+		PeriodStart: &first,
+		PeriodEnd:   &last,
+	}
+	if _, _, err := AddExpense(context.Background(), &mcp.CallToolRequest{}, input); err != nil {
+		t.Fatal(err)
+	}
+	e, err := group.GetExpense(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days := e.PeriodEnd.Sub(e.PeriodStart).Hours() / 24; days < 2.9 || days > 3.1 {
+		t.Errorf("expected %s to %s to cover three days, got %.1f", first, last, days)
+	}
+}
+
+func TestAddExpenseRecordsCaller(t *testing.T) {
+	group, err := groups.Create("shared-flat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ss, _ := connectElicitingClient(t, &mcp.ElicitResult{
+		Action: "accept",
+		Content: map[string]any{
+			"group_name":   "shared-flat",
+			"amount":       40.0,
+			"paid_by":      "Alice",
+			"description":  "groceries",
+			"split_method": "equal",
+		},
+	})
+
+	req := &mcp.CallToolRequest{Session: ss, Extra: &mcp.RequestExtra{TokenInfo: &auth.TokenInfo{UserID: "alice@example.com"}}}
+	if _, _, err := AddExpense(context.Background(), req, &AddExpenseInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, &AddExpenseInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, want := range map[int]string{1: "alice@example.com", 2: "unknown"} {
+		e, err := group.GetExpense(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.EnteredBy != want {
+			t.Fatalf("expected expense %d to be entered by %q, got %q", id, want, e.EnteredBy)
+		}
+	}
+	for _, entry := range group.Ledger() {
+		if entry.ExpenseID == 1 && entry.EnteredBy != "alice@example.com" {
+			t.Fatalf("expected the ledger to show who entered expense 1, got %q", entry.EnteredBy)
+		}
+	}
+}
+
+func TestAddExpenseRetriesInvalidAmountOnly(t *testing.T) {
+	group, err := groups.Create("retry-amount-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the retry only answers the amount; the payer and description come from the defaults
+	ss, calls := connectElicitingClient(t,
+		&mcp.ElicitResult{
+			Action: "accept",
+			Content: map[string]any{
+				"group_name":  "retry-amount-trip",
+				"amount":      12.345,
+				"paid_by":     "Alice",
+				"description": "dinner",
+			},
+		},
+		&mcp.ElicitResult{
+			Action:  "accept",
+			Content: map[string]any{"amount": 12.34},
+		},
+	)
+
+	_, out, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, &AddExpenseInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == nil {
+		t.Fatal("expected the expense to be added")
+	}
+	if *calls != 2 {
+		t.Fatalf("expected the combined form and one amount retry, got %d elicitations", *calls)
+	}
+	e, err := group.GetExpense(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.TotalMicroCents != 1234*1000 || e.PaidBy != "Alice" || e.Description != "dinner" {
+		t.Fatalf("expected Alice's $12.34 dinner, got %s paid by %s for %s", formatMicroCents(e.TotalMicroCents), e.PaidBy, e.Description)
+	}
+
+	if schema := retryAmountSchema("Alice", "dinner", []string{"Alice", "Bob"}); !slices.Equal(schema["required"].([]any), []any{"amount"}) {
+		t.Fatalf("expected only the amount to be required on retry, got %v", schema["required"])
+	}
+}
+
+func TestElicitationBudgetCapsRoundTrips(t *testing.T) {
+	ss, calls := connectElicitingClient(t, &mcp.ElicitResult{Action: "accept", Content: map[string]any{"name": ""}})
+	req := &mcp.CallToolRequest{Session: ss}
+	params := &mcp.ElicitParams{
+		Mode:    "form",
+		Message: "name?",
+		RequestedSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"name": map[string]any{"type": "string"}},
+		},
+	}
+
+	budget := &elicitationBudget{}
+	for i := 0; i < maxElicitations; i++ {
+		if _, err := budget.elicit(context.Background(), req, params); err != nil {
+			t.Fatalf("elicitation %d: %v", i+1, err)
+		}
+	}
+	if _, err := budget.elicit(context.Background(), req, params); !errors.Is(err, errTooManyElicitations) {
+		t.Fatalf("expected the too many attempts error, got %v", err)
+	}
+	if *calls != maxElicitations {
+		t.Fatalf("expected %d elicitations to reach the client, got %d", maxElicitations, *calls)
+	}
+}
+
+func TestAddExpenseGivesUpAfterTooManyElicitations(t *testing.T) {
+	group, err := groups.Create("elicitation-cap-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a client that keeps answering with an amount that has too many decimals
+	ss, calls := connectElicitingClient(t, &mcp.ElicitResult{
+		Action:  "accept",
+		Content: map[string]any{"amount": 12.345},
+	})
+
+	groupName, paidBy, description, method := group.Name, "Alice", "dinner", "equal"
+	_, out, err := AddExpense(context.Background(), &mcp.CallToolRequest{Session: ss}, &AddExpenseInput{
+		GroupName:   &groupName,
+		PaidBy:      &paidBy,
+		Description: &description,
+		SplitMethod: &method,
+	})
+	if !errors.Is(err, errTooManyElicitations) {
+		t.Fatalf("expected the too many attempts error, got %v", err)
+	}
+	if out != nil {
+		t.Fatal("expected no expense to be added")
+	}
+	if *calls != maxElicitations {
+		t.Fatalf("expected %d elicitations, got %d", maxElicitations, *calls)
+	}
+}
@@ -0,0 +1,610 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type DeleteExpenseInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group the expense belongs to"`
+	ExpenseID int    `json:"expense_id,omitempty" jsonschema_description:"ID of the expense to delete"`
+}
+
+type DeleteExpenseOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+type CompactExpenseIDsInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group whose expense IDs to renumber"`
+}
+
+// ExpenseIDChange is an expense that was renumbered.
+type ExpenseIDChange struct {
+	OldID int `json:"old_id"`
+	NewID int `json:"new_id"`
+}
+
+type CompactExpenseIDsOutput struct {
+	Changes []ExpenseIDChange `json:"changes" jsonschema_description:"expenses whose ID changed; empty when IDs were already contiguous"`
+}
+
+func DeleteExpense(ctx context.Context, req *mcp.CallToolRequest, input *DeleteExpenseInput) (*mcp.CallToolResult, *DeleteExpenseOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to delete an expense from it")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.ExpenseID <= 0 {
+		return nil, nil, errors.New("expense_id is required")
+	}
+
+	if err := group.DeleteExpense(input.ExpenseID); err != nil {
+		return nil, nil, err
+	}
+
+	output := &DeleteExpenseOutput{
+		Msg: fmt.Sprintf("expense #%d deleted", input.ExpenseID),
+	}
+	return nil, output, nil
+}
+
+func CompactExpenseIDs(ctx context.Context, req *mcp.CallToolRequest, input *CompactExpenseIDsInput) (*mcp.CallToolResult, *CompactExpenseIDsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to renumber its expenses")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	mapping := group.CompactExpenseIDs()
+	changes := make([]ExpenseIDChange, 0, len(mapping))
+	for oldID, newID := range mapping {
+		changes = append(changes, ExpenseIDChange{OldID: oldID, NewID: newID})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].OldID < changes[j].OldID
+	})
+
+	output := &CompactExpenseIDsOutput{
+		Changes: changes,
+	}
+	return nil, output, nil
+}
+
+type ListExpensesInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group whose expenses to list"`
+}
+
+type ListExpensesOutput struct {
+	Expenses []groups.ExpenseView `json:"expenses" jsonschema_description:"every expense as entered, by ID, with the amount in dollars and in micro cents (1 dollar = 100000 micro cents), any discount and who entered it"`
+}
+
+func ListExpenses(ctx context.Context, req *mcp.CallToolRequest, input *ListExpensesInput) (*mcp.CallToolResult, *ListExpensesOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to list its expenses")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &ListExpensesOutput{
+		Expenses: group.ListExpenses(),
+	}
+	return nil, output, nil
+}
+
+type ExpensesByMethodInput struct {
+	GroupName   string `json:"group_name,omitempty" jsonschema_description:"group to audit"`
+	SplitMethod string `json:"split_method" jsonschema_description:"equal, percentage, weights, duration, headcount or income"`
+}
+
+type ExpensesByMethodOutput struct {
+	Expenses []groups.ExpenseView `json:"expenses" jsonschema_description:"expenses split with the method, by ID"`
+}
+
+func ExpensesByMethod(ctx context.Context, req *mcp.CallToolRequest, input *ExpensesByMethodInput) (*mcp.CallToolResult, *ExpensesByMethodOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to list its expenses")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	expenses, err := group.ExpensesByMethod(input.SplitMethod)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExpensesByMethodOutput{
+		Expenses: group.ExpenseViews(expenses),
+	}
+	return nil, output, nil
+}
+
+type ExpenseImpactInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the expense"`
+	ExpenseID int    `json:"expense_id" jsonschema_description:"ID of the expense"`
+}
+
+type ExpenseImpactOutput struct {
+	Impact map[string]string `json:"impact" jsonschema_description:"Map person->change in dollars to their net balance from this expense; positive means they are owed more"`
+}
+
+func ExpenseImpact(ctx context.Context, req *mcp.CallToolRequest, input *ExpenseImpactInput) (*mcp.CallToolResult, *ExpenseImpactOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show an expense's impact")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	impact, err := group.ExpenseImpact(input.ExpenseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExpenseImpactOutput{
+		Impact: make(map[string]string, len(impact)),
+	}
+	for name, delta := range impact {
+		output.Impact[name] = formatMicroCents(delta)
+	}
+	return nil, output, nil
+}
+
+type ExpensesOwedByInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Name      string `json:"name,omitempty" jsonschema_description:"person whose owed expenses to list"`
+}
+
+type ExpensesOwedByOutput struct {
+	Expenses []groups.ExpenseView `json:"expenses" jsonschema_description:"expenses the person owes a share of but didn't pay, by ID"`
+}
+
+func ExpensesOwedBy(ctx context.Context, req *mcp.CallToolRequest, input *ExpensesOwedByInput) (*mcp.CallToolResult, *ExpensesOwedByOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to list what a person owes for")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	expenses, err := group.ExpensesOwedBy(input.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExpensesOwedByOutput{
+		Expenses: group.ExpenseViews(expenses),
+	}
+	return nil, output, nil
+}
+
+type SoleBeneficiaryExpensesInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Name      string `json:"name,omitempty" jsonschema_description:"person to look for"`
+}
+
+type SoleBeneficiaryExpensesOutput struct {
+	Expenses []groups.ExpenseView `json:"expenses" jsonschema_description:"expenses where the person was the only one with a share, by ID; often personal charges entered as shared"`
+}
+
+func SoleBeneficiaryExpenses(ctx context.Context, req *mcp.CallToolRequest, input *SoleBeneficiaryExpensesInput) (*mcp.CallToolResult, *SoleBeneficiaryExpensesOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to look for personal charges")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	expenses, err := group.SoleBeneficiaryExpenses(input.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SoleBeneficiaryExpensesOutput{
+		Expenses: group.ExpenseViews(expenses),
+	}
+	return nil, output, nil
+}
+
+type GetExpenseInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the expense"`
+	ExpenseID int    `json:"expense_id" jsonschema_description:"ID of the expense"`
+}
+
+type GetExpenseOutput struct {
+	Expense          groups.ExpenseView `json:"expense"`
+	Shares           map[string]string  `json:"shares" jsonschema_description:"Map person->share in dollars"`
+	ParticipantNotes map[string]string  `json:"participant_notes,omitempty" jsonschema_description:"Map person->note explaining their share"`
+}
+
+func GetExpense(ctx context.Context, req *mcp.CallToolRequest, input *GetExpenseInput) (*mcp.CallToolResult, *GetExpenseOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show an expense")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	e, err := group.GetExpense(input.ExpenseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shares, err := group.ExpenseShares(input.ExpenseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &GetExpenseOutput{
+		Expense:          group.ExpenseViews([]groups.Expense{e})[0],
+		Shares:           make(map[string]string, len(shares)),
+		ParticipantNotes: e.ParticipantNotes,
+	}
+	for name, share := range shares {
+		output.Shares[name] = formatMicroCents(share)
+	}
+	return nil, output, nil
+}
+
+type SetParticipantNoteInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the expense"`
+	ExpenseID int    `json:"expense_id" jsonschema_description:"ID of the expense"`
+	Name      string `json:"name" jsonschema_description:"participant the note is about"`
+	Note      string `json:"note" jsonschema_description:"short note, e.g. \"had the steak\"; empty removes the note"`
+}
+
+type SetParticipantNoteOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func SetParticipantNote(ctx context.Context, req *mcp.CallToolRequest, input *SetParticipantNoteInput) (*mcp.CallToolResult, *SetParticipantNoteOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to note an expense")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	if err := group.SetParticipantNote(input.ExpenseID, input.Name, input.Note); err != nil {
+		return nil, nil, err
+	}
+
+	output := &SetParticipantNoteOutput{
+		Msg: "success",
+	}
+	return nil, output, nil
+}
+
+type ExpenseExtremaInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type ExpenseExtremaOutput struct {
+	Most    *groups.ExpenseView `json:"most,omitempty" jsonschema_description:"most expensive expense, by amount after any discount"`
+	Least   *groups.ExpenseView `json:"least,omitempty" jsonschema_description:"least expensive expense, by amount after any discount"`
+	Summary string              `json:"summary"`
+}
+
+func ExpenseExtrema(ctx context.Context, req *mcp.CallToolRequest, input *ExpenseExtremaInput) (*mcp.CallToolResult, *ExpenseExtremaOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to find its biggest and smallest expenses")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	most, least := group.ExtremaExpenses()
+	if most == nil {
+		output := &ExpenseExtremaOutput{
+			Summary: fmt.Sprintf("no expenses in %s yet", group.Name),
+		}
+		return nil, output, nil
+	}
+
+	views := group.ExpenseViews([]groups.Expense{*most, *least})
+	output := &ExpenseExtremaOutput{
+		Most:  &views[0],
+		Least: &views[1],
+		Summary: fmt.Sprintf("biggest splurge: %s (%s); smallest: %s (%s)", most.Description,
+			formatMicroCents(most.NetMicroCents()), least.Description, formatMicroCents(least.NetMicroCents())),
+	}
+	return nil, output, nil
+}
+
+type FilterExpensesByLabelInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to search"`
+	Label     string `json:"label" jsonschema_description:"label to look for, e.g. business or reimbursable"`
+}
+
+type FilterExpensesByLabelOutput struct {
+	Expenses []groups.ExpenseView `json:"expenses" jsonschema_description:"expenses carrying the label, by ID"`
+}
+
+func FilterExpensesByLabel(ctx context.Context, req *mcp.CallToolRequest, input *FilterExpensesByLabelInput) (*mcp.CallToolResult, *FilterExpensesByLabelOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to list its expenses")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if strings.TrimSpace(input.Label) == "" {
+		return nil, nil, errors.New("label is required")
+	}
+
+	output := &FilterExpensesByLabelOutput{
+		Expenses: group.ExpenseViews(group.ExpensesWithLabel(input.Label)),
+	}
+	return nil, output, nil
+}
+
+type PersonCategoryShareInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to report on"`
+	Name      string `json:"name" jsonschema_description:"person whose share to compute"`
+	Category  string `json:"category" jsonschema_description:"expense label to total, e.g. food or lodging"`
+}
+
+type PersonCategoryShareOutput struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Share    string `json:"share" jsonschema_description:"the person's share of the labelled expenses in dollars, $0.00 if they took part in none"`
+}
+
+func PersonCategoryShare(ctx context.Context, req *mcp.CallToolRequest, input *PersonCategoryShareInput) (*mcp.CallToolResult, *PersonCategoryShareOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to compute a category share")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, nil, errors.New("name is required")
+	}
+	if strings.TrimSpace(input.Category) == "" {
+		return nil, nil, errors.New("category is required")
+	}
+
+	share, err := group.PersonCategoryShare(input.Name, input.Category)
+	if err != nil {
+		return nil, nil, err
+	}
+	output := &PersonCategoryShareOutput{
+		Name:     input.Name,
+		Category: input.Category,
+		Share:    formatMicroCents(share),
+	}
+	return nil, output, nil
+}
+
+type ExpensesByEventInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to report on"`
+}
+
+// EventExpenses is one sub-event of a trip with its expenses.
+type EventExpenses struct {
+	Event    string               `json:"event"`
+	Total    string               `json:"total" jsonschema_description:"total in dollars after discounts"`
+	Expenses []groups.ExpenseView `json:"expenses"`
+}
+
+type ExpensesByEventOutput struct {
+	Events []EventExpenses `json:"events" jsonschema_description:"sub-events by name, each with its expenses by ID; expenses without an event are left out"`
+}
+
+func ExpensesByEvent(ctx context.Context, req *mcp.CallToolRequest, input *ExpensesByEventInput) (*mcp.CallToolResult, *ExpensesByEventOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to list its events")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	byEvent := group.ExpensesByEvent()
+	events := make([]EventExpenses, 0, len(byEvent))
+	for event, expenses := range byEvent {
+		total := int64(0)
+		for _, e := range expenses {
+			total += e.NetMicroCents()
+		}
+		events = append(events, EventExpenses{
+			Event:    event,
+			Total:    formatMicroCents(total),
+			Expenses: group.ExpenseViews(expenses),
+		})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Event < events[j].Event
+	})
+
+	output := &ExpensesByEventOutput{
+		Events: events,
+	}
+	return nil, output, nil
+}
+
+type PreviewEditAmountInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the expense"`
+	ExpenseID int    `json:"expense_id" jsonschema_description:"ID of the expense"`
+	Amount    string `json:"amount,omitempty" jsonschema_description:"corrected amount in dollars (e.g. \"48\" or \"48.50\")"`
+}
+
+type PreviewEditAmountOutput struct {
+	Before map[string]float64 `json:"before" jsonschema_description:"pairwise debts in dollars now, like get_group_info's expense_details"`
+	After  map[string]float64 `json:"after" jsonschema_description:"pairwise debts in dollars if the amount were changed"`
+}
+
+func PreviewEditAmount(ctx context.Context, req *mcp.CallToolRequest, input *PreviewEditAmountInput) (*mcp.CallToolResult, *PreviewEditAmountOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to preview an amount change")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Amount == "" {
+		return nil, nil, errors.New("amount is required")
+	}
+	amount, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before, after, err := group.PreviewEditAmount(input.ExpenseID, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &PreviewEditAmountOutput{
+		Before: before,
+		After:  after,
+	}
+	return nil, output, nil
+}
+
+type BulkResplitInput struct {
+	GroupName   string             `json:"group_name,omitempty" jsonschema_description:"group of the expenses"`
+	ExpenseIDs  []int              `json:"expense_ids" jsonschema_description:"IDs of the expenses to split again"`
+	SplitMethod string             `json:"split_method" jsonschema_description:"split to apply to every expense" jsonschema_enum:"equal,percentage,weights"`
+	Params      map[string]float64 `json:"params,omitempty" jsonschema_description:"percentages or weights by person, e.g. {\"Alice\": 2, \"Bob\": 1}; leave out for equal"`
+}
+
+type BulkResplitOutput struct {
+	Updated []int             `json:"updated" jsonschema_description:"IDs of the expenses that were split again"`
+	Errors  map[string]string `json:"errors,omitempty" jsonschema_description:"why an expense was left alone, by expense ID; \"template\" when the split itself is invalid"`
+}
+
+func BulkResplit(ctx context.Context, req *mcp.CallToolRequest, input *BulkResplitInput) (*mcp.CallToolResult, *BulkResplitOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to re-split its expenses")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if len(input.ExpenseIDs) == 0 {
+		return nil, nil, errors.New("at least one expense ID is required")
+	}
+
+	updated, errs := group.ApplySplitTemplate(input.ExpenseIDs, input.SplitMethod, input.Params)
+	output := &BulkResplitOutput{Updated: updated}
+	if output.Updated == nil {
+		output.Updated = []int{}
+	}
+	if len(errs) > 0 {
+		output.Errors = make(map[string]string, len(errs))
+		for id, err := range errs {
+			output.Errors[id] = err.Error()
+		}
+	}
+	return nil, output, nil
+}
+
+type MergeDuplicateExpensesInput struct {
+	GroupName  string `json:"group_name,omitempty" jsonschema_description:"group of the expenses"`
+	ExpenseIDs []int  `json:"expense_ids" jsonschema_description:"IDs of the same expense entered more than once"`
+}
+
+type MergeDuplicateExpensesOutput struct {
+	KeptID int    `json:"kept_id" jsonschema_description:"ID of the expense that was kept, the lowest given"`
+	Msg    string `json:"msg"`
+}
+
+func MergeDuplicateExpenses(ctx context.Context, req *mcp.CallToolRequest, input *MergeDuplicateExpensesInput) (*mcp.CallToolResult, *MergeDuplicateExpensesOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to merge duplicate expenses")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	kept, err := group.MergeDuplicates(input.ExpenseIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &MergeDuplicateExpensesOutput{
+		KeptID: kept,
+		Msg:    fmt.Sprintf("kept expense(%d) and deleted %d duplicates", kept, len(input.ExpenseIDs)-1),
+	}
+	return nil, output, nil
+}
+
+type BackChargePersonInput struct {
+	GroupName  string `json:"group_name,omitempty" jsonschema_description:"group of the expenses"`
+	Person     string `json:"person,omitempty" jsonschema_description:"person who was actually part of the expenses"`
+	ExpenseIDs []int  `json:"expense_ids" jsonschema_description:"IDs of the equal-split expenses to add the person to"`
+	Commit     bool   `json:"commit,omitempty" jsonschema_description:"apply the change; by default it is only previewed"`
+}
+
+type BackChargePersonOutput struct {
+	Adjustments map[string]string `json:"adjustments" jsonschema_description:"change of each person's share, e.g. \"+$25.00\" for the back-charged person"`
+	Committed   bool              `json:"committed" jsonschema_description:"whether the expenses were changed"`
+}
+
+func BackChargePerson(ctx context.Context, req *mcp.CallToolRequest, input *BackChargePersonInput) (*mcp.CallToolResult, *BackChargePersonOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to back-charge a person")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Person == "" {
+		return nil, nil, errors.New("person is required")
+	}
+
+	adjustments, err := group.BackChargePerson(input.Person, input.ExpenseIDs, input.Commit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &BackChargePersonOutput{
+		Adjustments: make(map[string]string, len(adjustments)),
+		Committed:   input.Commit,
+	}
+	for name, delta := range adjustments {
+		sign := "+"
+		if delta < 0 {
+			sign, delta = "-", -delta
+		}
+		output.Adjustments[name] = sign + formatMicroCents(delta)
+	}
+	return nil, output, nil
+}
+
+// ItemInput is one line of an itemized expense.
+type ItemInput struct {
+	Description  string   `json:"description" jsonschema_description:"what the item was, e.g. drinks"`
+	Amount       string   `json:"amount" jsonschema_description:"amount in dollars (e.g. \"40\" or \"40.50\")"`
+	Participants []string `json:"participants,omitempty" jsonschema_description:"who shares the item equally; defaults to everyone in the original expense"`
+	Excluded     []string `json:"excluded,omitempty" jsonschema_description:"members who take no part in the item; required for everyone left out when the group requires all members to participate"`
+}
+
+type ItemizeExistingExpenseInput struct {
+	GroupName string      `json:"group_name,omitempty" jsonschema_description:"group of the expense"`
+	ExpenseID int         `json:"expense_id" jsonschema_description:"ID of the expense to break up"`
+	Items     []ItemInput `json:"items" jsonschema_description:"the items, adding up to the expense's amount"`
+}
+
+type ItemizeExistingExpenseOutput struct {
+	Expenses []groups.ExpenseView `json:"expenses" jsonschema_description:"the new expenses that replace the original, one per item"`
+}
+
+func ItemizeExistingExpense(ctx context.Context, req *mcp.CallToolRequest, input *ItemizeExistingExpenseInput) (*mcp.CallToolResult, *ItemizeExistingExpenseOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to itemize an expense")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	items := make([]groups.ItemSpec, 0, len(input.Items))
+	for i, item := range input.Items {
+		amount, err := groups.ParseDollars(item.Amount)
+		if err != nil {
+			return nil, nil, fmt.Errorf("item %d: %w", i+1, err)
+		}
+		items = append(items, groups.ItemSpec{
+			Description:      item.Description,
+			AmountMicroCents: amount,
+			Participants:     item.Participants,
+			Excluded:         item.Excluded,
+		})
+	}
+
+	ids, err := group.SplitExpenseIntoItems(input.ExpenseID, items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expenses := make([]groups.Expense, 0, len(ids))
+	for _, id := range ids {
+		e, err := group.GetExpense(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		expenses = append(expenses, e)
+	}
+	output := &ItemizeExistingExpenseOutput{
+		Expenses: group.ExpenseViews(expenses),
+	}
+	return nil, output, nil
+}
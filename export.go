@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type ExportSplitwiseInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to export"`
+}
+
+type ExportSplitwiseOutput struct {
+	SplitwiseJSON string `json:"splitwise_json" jsonschema_description:"Splitwise import JSON with users and per-user paid/owed shares for each expense"`
+}
+
+func ExportSplitwise(ctx context.Context, req *mcp.CallToolRequest, input *ExportSplitwiseInput) (*mcp.CallToolResult, *ExportSplitwiseOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to export it for Splitwise")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	data, err := group.ExportSplitwise()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExportSplitwiseOutput{
+		SplitwiseJSON: string(data),
+	}
+	return nil, output, nil
+}
+
+type ExportGraphMLInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to export"`
+}
+
+type ExportGraphMLOutput struct {
+	GraphML string `json:"graphml" jsonschema_description:"GraphML document with people as nodes and debts as weighted directed edges (weights in dollars)"`
+}
+
+func ExportGraphML(ctx context.Context, req *mcp.CallToolRequest, input *ExportGraphMLInput) (*mcp.CallToolResult, *ExportGraphMLOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to export its debt graph")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	graphML, err := group.ExportGraphML()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExportGraphMLOutput{
+		GraphML: graphML,
+	}
+	return nil, output, nil
+}
+
+type ExportReadOnlyTokenInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to share"`
+}
+
+type ExportReadOnlyTokenOutput struct {
+	Token string `json:"token" jsonschema_description:"signed token holding the group; pass it to view_read_only_token on any instance with the same key"`
+}
+
+func ExportReadOnlyToken(ctx context.Context, req *mcp.CallToolRequest, input *ExportReadOnlyTokenInput) (*mcp.CallToolResult, *ExportReadOnlyTokenOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to create a read-only token for it")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	token, err := group.ExportReadOnlyToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExportReadOnlyTokenOutput{
+		Token: token,
+	}
+	return nil, output, nil
+}
+
+type ViewReadOnlyTokenInput struct {
+	Token string `json:"token" jsonschema_description:"token returned by export_read_only_token"`
+}
+
+type ViewReadOnlyTokenOutput struct {
+	GroupName      string               `json:"group_name"`
+	Names          []string             `json:"names"`
+	Expenses       []groups.ExpenseView `json:"expenses" jsonschema_description:"the group's expenses, by ID"`
+	ExpenseDetails map[string]float64   `json:"expense_details" jsonschema_description:"who pays whom how much, in dollars"`
+}
+
+func ViewReadOnlyToken(ctx context.Context, req *mcp.CallToolRequest, input *ViewReadOnlyTokenInput) (*mcp.CallToolResult, *ViewReadOnlyTokenOutput, error) {
+	if input.Token == "" {
+		return nil, nil, errors.New("token is required")
+	}
+
+	group, err := groups.ImportReadOnlyToken(input.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ViewReadOnlyTokenOutput{
+		GroupName:      group.Name,
+		Names:          group.GetPeople(),
+		Expenses:       group.ExpenseViews(group.Snapshot().Expenses),
+		ExpenseDetails: group.GetExpenseDetails(),
+	}
+	return nil, output, nil
+}
+
+type ExportAllInput struct{}
+
+type ExportAllOutput struct {
+	BackupJSON string `json:"backup_json" jsonschema_description:"every group with its people, expenses and payments; pass it to import_all to restore"`
+}
+
+type ImportAllInput struct {
+	BackupJSON string `json:"backup_json" jsonschema_description:"backup_json returned by export_all"`
+	Force      bool   `json:"force,omitempty" jsonschema_description:"import even if validation finds problems such as invalid expenses or a graph that doesn't net to zero"`
+}
+
+type ImportAllOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func ExportAll(ctx context.Context, req *mcp.CallToolRequest, input *ExportAllInput) (*mcp.CallToolResult, *ExportAllOutput, error) {
+	data, err := groups.ExportAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExportAllOutput{
+		BackupJSON: string(data),
+	}
+	return nil, output, nil
+}
+
+func ImportAll(ctx context.Context, req *mcp.CallToolRequest, input *ImportAllInput) (*mcp.CallToolResult, *ImportAllOutput, error) {
+	if input.BackupJSON == "" {
+		return nil, nil, errors.New("backup_json is required")
+	}
+
+	n, err := groups.ImportAll([]byte(input.BackupJSON), input.Force)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ImportAllOutput{
+		Msg: fmt.Sprintf("imported %d groups; existing groups were replaced", n),
+	}
+	return nil, output, nil
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"expense-splitter/groups"
 	"fmt"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -18,7 +20,8 @@ type CreateGroupOutput struct {
 }
 
 type GetGroupInfoInput struct {
-	Name string `json:"name,omitempty" jsonschema_description:"get group info or details"`
+	Name        string `json:"name,omitempty" jsonschema_description:"get group info or details"`
+	DisplayMode string `json:"display_mode,omitempty" jsonschema_description:"how expense_details shows debts: net (default) nets each pair into one amount, so A owing B $30 and B owing A $10 shows as A owes B $20; gross shows both directions un-netted, $30 and $10, and ignores payments, so a debt paid off with settle_up still shows in full" jsonschema_enum:"net,gross"`
 }
 
 type GetGroupInfoOutput struct {
@@ -35,13 +38,21 @@ type ListGroupsOutput struct {
 
 type ListGroupsInput struct{}
 
+type DeleteGroupInput struct {
+	Name string `json:"name,omitempty" jsonschema_description:"delete the group with the given name"`
+}
+
+type DeleteGroupOutput struct {
+	GroupName string `json:"group_name"`
+	Deleted   bool   `json:"deleted" jsonschema_description:"whether a group was actually removed"`
+	Message   string `json:"message"`
+}
+
 func CreateGroup(ctx context.Context, req *mcp.CallToolRequest, input *CreateGroupInput) (*mcp.CallToolResult, *CreateGroupOutput, error) {
 	name := input.Name
+	budget := &elicitationBudget{}
 	if name == "" {
-		// Get the session so we can talk back to the client.
-		ss, _ := req.GetSession().(*mcp.ServerSession)
-
-		er, err := ss.Elicit(ctx, &mcp.ElicitParams{
+		er, err := budget.elicit(ctx, req, &mcp.ElicitParams{
 			Mode:    "form",
 			Message: "I need group name to create one",
 			RequestedSchema: map[string]any{
@@ -92,13 +103,100 @@ func ListGroups(ctx context.Context, req *mcp.CallToolRequest, input *ListGroups
 	return nil, output, nil
 }
 
-func GetGroupInfo(ctx context.Context, req *mcp.CallToolRequest, input *GetGroupInfoInput) (*mcp.CallToolResult, *GetGroupInfoOutput, error) {
+func DeleteGroup(ctx context.Context, req *mcp.CallToolRequest, input *DeleteGroupInput) (*mcp.CallToolResult, *DeleteGroupOutput, error) {
 	name := input.Name
+	budget := &elicitationBudget{}
 	if name == "" {
-		// Get the session so we can talk back to the client.
-		ss, _ := req.GetSession().(*mcp.ServerSession)
+		er, err := budget.elicit(ctx, req, &mcp.ElicitParams{
+			Mode:    "form",
+			Message: "I need group name to delete one",
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{
+						"type":        "string",
+						"description": "Group name",
+					},
+				},
+				"required": []any{"name"},
+			},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if er.Action != "accept" {
+			// user declined/cancelled
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "No worries — cancelled."},
+				},
+			}, nil, nil
+		}
+
+		if v, ok := er.Content["name"].(string); ok {
+			name = v
+		}
+	}
+
+	output := &DeleteGroupOutput{GroupName: name}
+	if !groups.Delete(name) {
+		output.Message = fmt.Sprintf("group(%s) not found, nothing to delete", name)
+		return nil, output, nil
+	}
+	output.Deleted = true
+	output.Message = fmt.Sprintf("group(%s) deleted", name)
+	return nil, output, nil
+}
+
+type PeopleInDeletedGroupsInput struct{}
+
+type PeopleInDeletedGroupsOutput struct {
+	Names []string `json:"names" jsonschema_description:"everyone who was in a deleted group, listed once each"`
+}
+
+func PeopleInDeletedGroups(ctx context.Context, req *mcp.CallToolRequest, input *PeopleInDeletedGroupsInput) (*mcp.CallToolResult, *PeopleInDeletedGroupsOutput, error) {
+	output := &PeopleInDeletedGroupsOutput{
+		Names: groups.PeopleInDeletedGroups(),
+	}
+	return nil, output, nil
+}
+
+type PersonTotalExposureInput struct {
+	Name string `json:"name" jsonschema_description:"person to look up across every group"`
+}
+
+type PersonTotalExposureOutput struct {
+	Balances map[string]float64 `json:"balances" jsonschema_description:"net balance in dollars per group the person is in; positive means they are owed"`
+	Total    float64            `json:"total" jsonschema_description:"sum of the balances: overall up (positive) or down (negative)"`
+}
+
+func PersonTotalExposure(ctx context.Context, req *mcp.CallToolRequest, input *PersonTotalExposureInput) (*mcp.CallToolResult, *PersonTotalExposureOutput, error) {
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, nil, errors.New("name is required")
+	}
 
-		er, err := ss.Elicit(ctx, &mcp.ElicitParams{
+	exposure := groups.PersonTotalExposure(input.Name)
+	if len(exposure) == 0 {
+		return nil, nil, fmt.Errorf("person(%s) is not in any group", input.Name)
+	}
+	output := &PersonTotalExposureOutput{
+		Balances: make(map[string]float64, len(exposure)-1),
+		Total:    exposure[groups.ExposureTotalKey],
+	}
+	for name, balance := range exposure {
+		if name != groups.ExposureTotalKey {
+			output.Balances[name] = balance
+		}
+	}
+	return nil, output, nil
+}
+
+func GetGroupInfo(ctx context.Context, req *mcp.CallToolRequest, input *GetGroupInfoInput) (*mcp.CallToolResult, *GetGroupInfoOutput, error) {
+	name := input.Name
+	budget := &elicitationBudget{}
+	if name == "" {
+		er, err := budget.elicit(ctx, req, &mcp.ElicitParams{
 			Mode:    "form",
 			Message: "I need group name to get the details of the group",
 			RequestedSchema: map[string]any{
@@ -130,6 +228,16 @@ func GetGroupInfo(ctx context.Context, req *mcp.CallToolRequest, input *GetGroup
 		}
 	}
 
+	var details func(g *groups.Group) map[string]float64
+	switch input.DisplayMode {
+	case "", "net":
+		details = (*groups.Group).GetExpenseDetails
+	case "gross":
+		details = (*groups.Group).GrossDebts
+	default:
+		return nil, nil, fmt.Errorf("display_mode must be net or gross, got %q", input.DisplayMode)
+	}
+
 	group, exists := groups.Get(name)
 	if !exists {
 		return nil, nil, fmt.Errorf("group(%s) not found; create it with CreateGroup", name)
@@ -139,9 +247,54 @@ func GetGroupInfo(ctx context.Context, req *mcp.CallToolRequest, input *GetGroup
 		GroupName:      group.Name,
 		CreatedAt:      fmt.Sprint(group.CreatedAt),
 		Names:          group.GetPeople(),
-		ExpenseDetails: group.GetExpenseDetails(),
+		ExpenseDetails: details(group),
 		GraphDOT:       group.GetGraphDOT(),
 	}
 
 	return nil, output, nil
 }
+
+// lookupGroup returns the named group. When name is empty, it asks the user for it
+// via elicitation using msg. A non-nil *mcp.CallToolResult means the user cancelled
+// and should be returned to the client as is.
+func lookupGroup(ctx context.Context, req *mcp.CallToolRequest, name, msg string) (*groups.Group, *mcp.CallToolResult, error) {
+	budget := &elicitationBudget{}
+	if name == "" {
+		er, err := budget.elicit(ctx, req, &mcp.ElicitParams{
+			Mode:    "form",
+			Message: msg,
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{
+						"type":        "string",
+						"description": "Group name",
+					},
+				},
+				"required": []any{"name"},
+			},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if er.Action != "accept" {
+			// user declined/cancelled
+			return nil, &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "No worries — cancelled."},
+				},
+			}, nil
+		}
+
+		if v, ok := er.Content["name"].(string); ok {
+			name = v
+		}
+	}
+
+	group, exists := groups.Get(name)
+	if !exists {
+		return nil, nil, fmt.Errorf("group(%s) not found; create it with CreateGroup", name)
+	}
+	return group, nil, nil
+}
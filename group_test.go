@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"expense-splitter/groups"
+	"maps"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestGetGroupInfoDisplayMode(t *testing.T) {
+	group, err := groups.Create("display-mode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Bob owes Alice $30 and Alice owes Bob $10.
+	for _, e := range []*groups.Expense{
+		{PaidBy: "Alice", TotalMicroCents: 60 * 100 * 1000, Description: "hotel", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "taxi", SplitMethod: "equal"},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	req := &mcp.CallToolRequest{}
+	tests := []struct {
+		mode string
+		want map[string]float64
+	}{
+		{"", map[string]float64{"Bob to pay Alice": 20}},
+		{"net", map[string]float64{"Bob to pay Alice": 20}},
+		{"gross", map[string]float64{"Bob to pay Alice": 30, "Alice to pay Bob": 10}},
+	}
+	for _, tt := range tests {
+		_, out, err := GetGroupInfo(ctx, req, &GetGroupInfoInput{Name: group.Name, DisplayMode: tt.mode})
+		if err != nil {
+			t.Fatalf("mode %q: %v", tt.mode, err)
+		}
+		if !maps.Equal(out.ExpenseDetails, tt.want) {
+			t.Errorf("mode %q: expected %v, got %v", tt.mode, tt.want, out.ExpenseDetails)
+		}
+	}
+
+	if _, _, err := GetGroupInfo(ctx, req, &GetGroupInfoInput{Name: group.Name, DisplayMode: "sideways"}); err == nil {
+		t.Error("expected an error for an unknown display_mode")
+	}
+}
+
+func TestDeleteGroup(t *testing.T) {
+	if _, err := groups.Create("delete-me"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete("delete-me") })
+
+	ctx := context.Background()
+	req := &mcp.CallToolRequest{}
+	_, out, err := DeleteGroup(ctx, req, &DeleteGroupInput{Name: "delete-me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.Deleted {
+		t.Errorf("expected delete-me to be deleted, got %+v", out)
+	}
+	if _, ok := groups.Get("delete-me"); ok {
+		t.Error("expected delete-me to be gone from the store")
+	}
+
+	_, out, err = DeleteGroup(ctx, req, &DeleteGroupInput{Name: "delete-me"})
+	if err != nil {
+		t.Fatalf("expected a missing group to be reported without an error, got %v", err)
+	}
+	if out.Deleted {
+		t.Errorf("expected nothing to be deleted the second time, got %+v", out)
+	}
+}
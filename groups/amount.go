@@ -0,0 +1,55 @@
+package groups
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AmountPattern is the accepted format for dollar amounts, e.g. "208" or "208.50".
+// The add_expense input schema uses the same pattern.
+const AmountPattern = `^\d+(\.\d{1,2})?$`
+
+var amountPattern = regexp.MustCompile(AmountPattern)
+
+// tooPreciseAmountPattern matches otherwise valid amounts with more than 2 decimals.
+var tooPreciseAmountPattern = regexp.MustCompile(`^\d+\.\d{3,}$`)
+
+// ParseDollars parses a dollar amount matching AmountPattern into micro cents.
+// Surrounding whitespace is ignored; anything else the pattern rejects is an error.
+func ParseDollars(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("amount is empty")
+	}
+	if strings.HasPrefix(s, "-") {
+		return 0, fmt.Errorf("amount cannot be negative: %q", s)
+	}
+	if !amountPattern.MatchString(s) {
+		if tooPreciseAmountPattern.MatchString(s) {
+			return 0, fmt.Errorf("too many decimal places in amount %q; at most 2 are allowed", s)
+		}
+		return 0, fmt.Errorf("invalid amount %q; must match %q (e.g. \"208\" or \"208.50\")", s, AmountPattern)
+	}
+
+	dollarsStr, centsStr, _ := strings.Cut(s, ".")
+	dollars, err := strconv.ParseInt(dollarsStr, 10, 64)
+	if err != nil || dollars > maxDollars {
+		return 0, fmt.Errorf("amount %q is too large", s)
+	}
+
+	cents := int64(0)
+	if centsStr != "" {
+		if len(centsStr) == 1 {
+			centsStr += "0"
+		}
+		// the pattern guarantees 1 or 2 digits
+		cents, _ = strconv.ParseInt(centsStr, 10, 64)
+	}
+
+	return (dollars*100 + cents) * 1000, nil
+}
+
+// maxDollars keeps amounts well clear of int64 overflow once converted to micro cents.
+const maxDollars = 1_000_000_000_000
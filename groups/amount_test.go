@@ -0,0 +1,56 @@
+package groups
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDollars(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr string
+	}{
+		{input: "208", want: 208 * 100 * 1000},
+		{input: "208.5", want: 20850 * 1000},
+		{input: "208.50", want: 20850 * 1000},
+		{input: "0.01", want: 1 * 1000},
+		{input: "0", want: 0},
+		{input: "007", want: 7 * 100 * 1000},
+		{input: "00.5", want: 50 * 1000},
+		{input: " 12.30 ", want: 1230 * 1000},
+		{input: "", wantErr: "amount is empty"},
+		{input: "-5", wantErr: "cannot be negative"},
+		{input: "-0.50", wantErr: "cannot be negative"},
+		{input: "1.234", wantErr: "too many decimal places"},
+		{input: "abc", wantErr: "invalid amount"},
+		{input: "+5", wantErr: "invalid amount"},
+		{input: ".5", wantErr: "invalid amount"},
+		{input: "5.", wantErr: "invalid amount"},
+		{input: "1,000", wantErr: "invalid amount"},
+		{input: "1e3", wantErr: "invalid amount"},
+		{input: "$5", wantErr: "invalid amount"},
+		{input: "1.2.3", wantErr: "invalid amount"},
+		{input: "99999999999999999999", wantErr: "too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDollars(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDollars(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+				}
+				if amountPattern.MatchString(strings.TrimSpace(tt.input)) && !strings.Contains(tt.wantErr, "too large") {
+					t.Fatalf("pattern accepts %q but ParseDollars rejects it", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDollars(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseDollars(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// closeOutMemo is the memo of the payments SettleAllAndArchive records.
+const closeOutMemo = "close out"
+
+// SettleAllAndArchive settles the whole group in one go: it records the minimal set of
+// payments that brings everyone to zero, then moves every expense, payment and debt
+// transfer to the archive and clears the debt graph, so the group starts over with the same members.
+// It returns the payments it recorded and the expenses it archived, oldest first.
+// Sub-cent residuals left by rounding are dropped.
+func (g *Group) SettleAllAndArchive() ([]Payment, []Expense, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	made := []Payment{}
+	for _, s := range g.minimalSettlement(g.netBalances(nil)) {
+		if err := g.addPayment(normalizeName(s.From), normalizeName(s.To), s.AmountMicroCents, closeOutMemo, now); err != nil {
+			slog.Error("close out failed", "group", g.Name, "error", err.Error())
+			return nil, nil, err
+		}
+		made = append(made, Payment{From: s.From, To: s.To, AmountMicroCents: s.AmountMicroCents, Memo: closeOutMemo, CreatedAt: now})
+	}
+	for key, balance := range g.netBalances(nil) {
+		if balance <= -settleThresholdMicroCents || balance >= settleThresholdMicroCents {
+			return nil, nil, fmt.Errorf("group(%s) is not settled after close out: person(%s) has balance %d", g.Name, g.displayName(key), balance)
+		}
+	}
+
+	payments := g.payments()
+	transfers := g.transfers()
+	archived := make([]Expense, 0, len(g.expenses))
+	for _, e := range g.sortedExpenses() {
+		archived = append(archived, copyExpense(e))
+	}
+
+	gr := newGraph(g.Name)
+	for key := range g.people {
+		if err := gr.addNode(key); err != nil {
+			return nil, nil, err
+		}
+	}
+	g.graph = gr
+	g.expenses = make(map[int]*Expense)
+	g.archived = append(g.archived, archived...)
+	g.archivedPayments = append(g.archivedPayments, payments...)
+	g.archivedTransfers = append(g.archivedTransfers, transfers...)
+	slog.Debug("SettleAllAndArchive", "group", g.Name, "payments", len(made), "expenses", len(archived), "transfers", len(transfers))
+	return made, archived, nil
+}
+
+// ArchivedExpenses returns copies of the expenses archived by SettleAllAndArchive, oldest first.
+func (g *Group) ArchivedExpenses() []Expense {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	list := make([]Expense, 0, len(g.archived))
+	for i := range g.archived {
+		list = append(list, copyExpense(&g.archived[i]))
+	}
+	return list
+}
+
+// ArchivedTransfers returns the debt transfers and forgiveness entries archived by
+// SettleAllAndArchive, oldest first.
+func (g *Group) ArchivedTransfers() []LedgerEntry {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.Clone(g.archivedTransfers)
+}
@@ -0,0 +1,87 @@
+package groups
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// BackChargePerson works out what changes if name is added retroactively as a participant
+// of the equal-split expenses expenseIDs ("Dave was actually at that dinner"). Each expense
+// is split again among its original participants plus name, the way AddExpense would.
+// It returns how much each person's share changed in micro cents, keyed by display name:
+// name's share goes up and everyone else's goes down by as much. Nothing is changed unless
+// commit is set, in which case the expenses are replaced and the debt graph is rebuilt.
+func (g *Group) BackChargePerson(name string, expenseIDs []int, commit bool) (map[string]int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		slog.Error("person not found", "group", g.Name)
+		return nil, fmt.Errorf("person(%s) not found in group(%s)", name, g.Name)
+	}
+	if len(expenseIDs) == 0 {
+		return nil, errors.New("at least one expense ID is required")
+	}
+
+	edits := map[int]Expense{}
+	adjustments := map[string]int64{}
+	for _, id := range expenseIDs {
+		if _, seen := edits[id]; seen {
+			return nil, fmt.Errorf("expense(%d) is listed more than once", id)
+		}
+		e, exists := g.expenses[id]
+		if !exists {
+			return nil, fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+		}
+		if e.SplitMethod != "equal" {
+			return nil, fmt.Errorf("expense(%d) is a %s split; only equal splits can be back-charged", id, e.SplitMethod)
+		}
+		if _, ok := e.ResolvedShares[key]; ok {
+			return nil, fmt.Errorf("%s already has a share of expense(%d)", g.displayName(key), id)
+		}
+
+		edited := copyExpense(e)
+		edited.Excluded = nil
+		for k, p := range g.people {
+			if _, ok := e.ResolvedShares[k]; !ok && k != key {
+				edited.Excluded = append(edited.Excluded, p.Name)
+			}
+		}
+		slices.Sort(edited.Excluded)
+		if err := g.resolveExpense(&edited); err != nil {
+			return nil, err
+		}
+		for k, share := range edited.ResolvedShares {
+			adjustments[g.displayName(k)] += share - e.ResolvedShares[k]
+		}
+		edits[id] = edited
+	}
+	for k, v := range adjustments {
+		if v == 0 {
+			delete(adjustments, k)
+		}
+	}
+	if !commit {
+		return adjustments, nil
+	}
+
+	s := g.snapshot()
+	for i := range s.Expenses {
+		if edited, ok := edits[s.Expenses[i].ID]; ok {
+			s.Expenses[i] = edited
+		}
+	}
+	people, expenses, gr, err := buildState(g.Name, s)
+	if err != nil {
+		slog.Error("back-charge failed", "group", g.Name, "error", err.Error())
+		return nil, err
+	}
+	g.people = people
+	g.expenses = expenses
+	g.graph = gr
+	slog.Debug("BackChargePerson", "group", g.Name, "person", key, "expenses", len(edits))
+	return adjustments, nil
+}
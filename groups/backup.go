@@ -0,0 +1,235 @@
+package groups
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Backup is every group in the store, as written by ExportAll and read by ImportAll.
+type Backup struct {
+	Groups []GroupSnapshot `json:"groups"`
+
+	// Deleted is the recycle bin, oldest first; see PeopleInDeletedGroups.
+	Deleted []GroupSnapshot `json:"deleted,omitempty"`
+}
+
+// ExportAll returns every group in the store as a single JSON document, in name order,
+// followed by the recycle bin.
+func ExportAll() ([]byte, error) {
+	backup := Backup{Groups: []GroupSnapshot{}}
+	for _, group := range ListGroups() {
+		backup.Groups = append(backup.Groups, group.Snapshot())
+	}
+	groupMgr.mu.Lock()
+	deleted := slices.Clone(groupMgr.deleted)
+	groupMgr.mu.Unlock()
+	for _, group := range deleted {
+		backup.Deleted = append(backup.Deleted, group.Snapshot())
+	}
+	return json.MarshalIndent(backup, "", "  ")
+}
+
+// ImportAll replaces the whole store and the recycle bin with the groups in data, as
+// returned by ExportAll.
+// Every group's debt graph is rebuilt from its expenses and payments and checked with
+// ValidateImport. Nothing changes if any group fails to rebuild, or fails validation
+// unless force is set; the error lists the problems of every group at once.
+func ImportAll(data []byte, force bool) (int, error) {
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return 0, fmt.Errorf("invalid backup: %w", err)
+	}
+
+	store := make(map[string]*Group, len(backup.Groups))
+	var problems []error
+	for _, s := range backup.Groups {
+		group, err := NewGroup(strings.TrimSpace(s.Name))
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		key := normalizeName(group.Name)
+		if _, exists := store[key]; exists {
+			problems = append(problems, fmt.Errorf("backup has duplicate group(%s)", group.Name))
+			continue
+		}
+		if err := group.RestoreSnapshot(s); err != nil {
+			problems = append(problems, fmt.Errorf("group(%s): %w", group.Name, err))
+			continue
+		}
+		if !s.CreatedAt.IsZero() {
+			group.CreatedAt = s.CreatedAt
+		}
+		if errs := ValidateImport(group); len(errs) > 0 && !force {
+			problems = append(problems, errs...)
+		}
+		store[key] = group
+	}
+	// deleted groups only serve as records, so they aren't validated, and one that doesn't
+	// rebuild keeps just its members, which is what the recycle bin is consulted for
+	deleted := make([]*Group, 0, len(backup.Deleted))
+	for _, s := range backup.Deleted {
+		group, err := NewGroup(strings.TrimSpace(s.Name))
+		if err != nil {
+			problems = append(problems, fmt.Errorf("deleted %w", err))
+			continue
+		}
+		if err := group.RestoreSnapshot(s); err != nil {
+			slog.Warn("deleted group restored with its members only", "group", group.Name, "error", err.Error())
+			if err := group.RestoreSnapshot(GroupSnapshot{Name: s.Name, People: s.People}); err != nil {
+				problems = append(problems, fmt.Errorf("deleted group(%s): %w", group.Name, err))
+				continue
+			}
+		}
+		if !s.CreatedAt.IsZero() {
+			group.CreatedAt = s.CreatedAt
+		}
+		deleted = append(deleted, group)
+	}
+	deleted = deleted[max(len(deleted)-maxDeletedGroups, 0):]
+	if len(problems) > 0 {
+		slog.Error("ImportAll failed", "problems", len(problems))
+		return 0, errors.Join(problems...)
+	}
+
+	groupMgr.mu.Lock()
+	defer groupMgr.mu.Unlock()
+
+	groupMgr.store = store
+	groupMgr.deleted = deleted
+	slog.Debug("ImportAll", "groups", len(store), "deleted", len(deleted))
+	return len(store), nil
+}
+
+// SaveToFile writes every group in the store to path in the format of ExportAll, so the
+// state survives a restart. The file is replaced atomically: a crash mid-write leaves the
+// previous state in place.
+func SaveToFile(path string) error {
+	data, err := ExportAll()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	slog.Debug("SaveToFile", "path", path, "bytes", len(data))
+	return nil
+}
+
+// LoadFromFile replaces the store with the groups saved to path by SaveToFile, rebuilding
+// every debt graph from the expenses and payments, as ImportAll does. Groups that fail
+// validation, e.g. against a check added since they were saved, are loaded anyway with a
+// warning rather than lost. A missing file is reported with an error wrapping
+// fs.ErrNotExist.
+func LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("state file(%s) is not valid JSON", path)
+	}
+	n, err := ImportAll(data, false)
+	if err != nil {
+		slog.Warn("state file has problems, loading it anyway", "path", path, "error", err.Error())
+		n, err = ImportAll(data, true)
+	}
+	if err != nil {
+		return fmt.Errorf("state file(%s): %w", path, err)
+	}
+	slog.Debug("LoadFromFile", "path", path, "groups", n)
+	return nil
+}
+
+// ValidateImport checks a group rebuilt from imported data before it is registered.
+// It runs the checks of Group.Verify.
+func ValidateImport(g *Group) []error {
+	return g.Verify()
+}
+
+// Verify checks the group's consistency: names must match the name patterns, every
+// expense must pass the checks AddExpense makes, and the debt graph must net to zero.
+// It returns every problem found, nil for a consistent group.
+func (g *Group) Verify() []error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var errs []error
+	if !groupNamePattern.MatchString(g.Name) {
+		errs = append(errs, fmt.Errorf("group(%s) name must match %q", g.Name, groupNamePattern.String()))
+	}
+	for _, key := range slices.Sorted(maps.Keys(g.people)) {
+		if name := g.people[key].Name; !validPersonName(name) {
+			errs = append(errs, fmt.Errorf("group(%s) person name(%s) must match %q", g.Name, name, personNamePattern.String()))
+		}
+	}
+	for _, e := range g.sortedExpenses() {
+		for _, err := range validateImportedExpense(e, g.people) {
+			errs = append(errs, fmt.Errorf("group(%s) expense(%d): %w", g.Name, e.ID, err))
+		}
+	}
+	net := int64(0)
+	for _, balance := range g.netBalances(nil) {
+		net += balance
+	}
+	if net != 0 {
+		errs = append(errs, fmt.Errorf("group(%s) debt graph nets to %d, not zero", g.Name, net))
+	}
+	return errs
+}
+
+// validateImportedExpense returns the problems with a stored expense e.
+func validateImportedExpense(e *Expense, people map[string]*Person) []error {
+	var errs []error
+	c := copyExpense(e)
+	if err := validateExpenseFields(&c); err != nil {
+		errs = append(errs, err)
+	}
+	paid := int64(0)
+	for _, amount := range e.paidShares() {
+		if amount < 0 {
+			errs = append(errs, fmt.Errorf("payer amounts must be >= 0, got %d", amount))
+		}
+		paid += amount
+	}
+	if paid != e.NetMicroCents() {
+		errs = append(errs, fmt.Errorf("payers paid %d, not the total %d", paid, e.NetMicroCents()))
+	}
+	for _, key := range slices.Sorted(maps.Keys(e.ResolvedShares)) {
+		if share := e.ResolvedShares[key]; share < 0 {
+			errs = append(errs, fmt.Errorf("participant(%s) has a negative share %d", key, share))
+		}
+	}
+	for _, split := range []map[string]float64{e.SplitPercentages, e.SplitWeights, e.TipSplitWeights} {
+		for _, name := range slices.Sorted(maps.Keys(split)) {
+			if _, exists := people[normalizeName(name)]; !exists {
+				errs = append(errs, fmt.Errorf("split map person(%s) is not a member", name))
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(e.SplitExactMicroCents)) {
+		if _, exists := people[normalizeName(name)]; !exists {
+			errs = append(errs, fmt.Errorf("split_exact person(%s) is not a member", name))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,201 @@
+package groups
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MemberBalance is a member's net balance. Positive means others owe them.
+type MemberBalance struct {
+	Name              string  `json:"name"`
+	Balance           float64 `json:"balance"` // dollars
+	BalanceMicroCents int64   `json:"balance_micro_cents"`
+}
+
+// MembersByBalance returns every member with their net balance, from the biggest
+// creditor to the biggest debtor. Settled members sit in the middle.
+func (g *Group) MembersByBalance() []MemberBalance {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	balances := g.netBalances(nil)
+	members := make([]MemberBalance, 0, len(g.people))
+	for key, p := range g.people {
+		micro := balances[key]
+		members = append(members, MemberBalance{
+			Name:              p.Name,
+			Balance:           microCentsToDollars(micro),
+			BalanceMicroCents: micro,
+		})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].BalanceMicroCents != members[j].BalanceMicroCents {
+			return members[i].BalanceMicroCents > members[j].BalanceMicroCents
+		}
+		return strings.ToLower(members[i].Name) < strings.ToLower(members[j].Name)
+	})
+	return members
+}
+
+// PaidVsOwed returns, for every member keyed by display name, [total paid, total owed] in
+// micro cents across all expenses: what they paid towards expenses and the sum of their
+// shares. A settled participant's share counts as paid by them rather than by the payer,
+// since they handed it over on the spot. Group credits aren't spending and are left out.
+// Without payments, transfers or credits, paid minus owed is the member's net balance.
+func (g *Group) PaidVsOwed() map[string][2]int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	paid := make(map[string]int64, len(g.people))
+	owed := make(map[string]int64, len(g.people))
+	for _, e := range g.expenses {
+		if e.isGroupCredit() {
+			continue
+		}
+		for key, amount := range e.paidShares() {
+			paid[key] += amount
+		}
+		for key, share := range e.ResolvedShares {
+			owed[key] += share
+		}
+		payerKey := normalizeName(e.PaidBy)
+		for _, name := range e.SettledParticipants {
+			key := normalizeName(name)
+			paid[key] += e.ResolvedShares[key]
+			paid[payerKey] -= e.ResolvedShares[key]
+		}
+	}
+	totals := make(map[string][2]int64, len(g.people))
+	for key, p := range g.people {
+		totals[p.Name] = [2]int64{paid[key], owed[key]}
+	}
+	return totals
+}
+
+// LeaderboardEntry is a member's place on the Leaderboard, amounts in micro cents.
+type LeaderboardEntry struct {
+	Name           string `json:"name"`
+	PaidMicroCents int64  `json:"paid_micro_cents"`
+	OwedMicroCents int64  `json:"owed_micro_cents"`
+	NetMicroCents  int64  `json:"net_micro_cents"`
+}
+
+// Leaderboard ranks members by how much they fronted net of their own shares, the most
+// generous first; ties go to the name that sorts first. Paid and owed are as in
+// PaidVsOwed, so payments between members don't move anyone on the board.
+func (g *Group) Leaderboard() []LeaderboardEntry {
+	entries := []LeaderboardEntry{}
+	for name, totals := range g.PaidVsOwed() {
+		entries = append(entries, LeaderboardEntry{
+			Name:           name,
+			PaidMicroCents: totals[0],
+			OwedMicroCents: totals[1],
+			NetMicroCents:  totals[0] - totals[1],
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].NetMicroCents != entries[j].NetMicroCents {
+			return entries[i].NetMicroCents > entries[j].NetMicroCents
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	return entries
+}
+
+// NonPayers returns the members, in name order, who haven't paid for any expense yet,
+// whether alone or as one of several payers. They may still owe shares.
+func (g *Group) NonPayers() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payers := map[string]bool{}
+	for _, e := range g.expenses {
+		if e.isGroupCredit() {
+			continue
+		}
+		for key := range e.paidShares() {
+			payers[key] = true
+		}
+	}
+	names := []string{}
+	for key, p := range g.people {
+		if !payers[key] {
+			names = append(names, p.Name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
+
+// ReimbursementNeeded returns how much person is still owed overall, their positive net
+// balance in micro cents, and who should pay it, keyed by display name. The breakdown
+// follows the settlement plan, so it adds up to the total; it is 0 and empty when person
+// isn't owed anything.
+func (g *Group) ReimbursementNeeded(person string) (int64, map[string]int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(person)
+	if _, exists := g.people[key]; !exists {
+		return 0, nil, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(person), g.Name)
+	}
+
+	balances := g.netBalances(nil)
+	breakdown := map[string]int64{}
+	if balances[key] <= 0 {
+		return 0, breakdown, nil
+	}
+	for _, d := range matchDebts(balances) {
+		if d.to == key {
+			breakdown[g.displayName(d.from)] += d.amount
+		}
+	}
+	return balances[key], breakdown, nil
+}
+
+// GetPersonBalance returns a member's net balance in dollars: positive means others owe
+// them, negative means they owe.
+func (g *Group) GetPersonBalance(name string) (float64, error) {
+	micro, ok := g.memberBalance(normalizeName(name))
+	if !ok {
+		return 0, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(name), g.Name)
+	}
+	return microCentsToDollars(micro), nil
+}
+
+// GetAllBalances returns every member's net balance in dollars, keyed by display name.
+// Settled members are included with a zero balance.
+func (g *Group) GetAllBalances() map[string]float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	balances := g.netBalances(nil)
+	result := make(map[string]float64, len(g.people))
+	for key, p := range g.people {
+		result[p.Name] = microCentsToDollars(balances[key])
+	}
+	return result
+}
+
+// memberBalance returns the net balance in micro cents of the member with key, and
+// whether they are a member at all.
+func (g *Group) memberBalance(key string) (int64, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, exists := g.people[key]; !exists {
+		return 0, false
+	}
+	return g.netBalances(nil)[key], true
+}
+
+// microCentsToDollars converts micro cents to dollars rounded to the cent.
+func microCentsToDollars(micro int64) float64 {
+	if micro < 0 {
+		return -float64(microCentsToCents(-micro)) / 100
+	}
+	return float64(microCentsToCents(micro)) / 100
+}
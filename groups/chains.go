@@ -0,0 +1,131 @@
+package groups
+
+import "sort"
+
+// maxSettlementChains bounds the chains SettlementChains suggests.
+const maxSettlementChains = 100
+
+// Chain is a suggested shortcut through an intermediary: From owes Via and Via owes To,
+// so From can pay To AmountMicroCents directly if Via agrees, replacing two transfers of
+// that amount with one. From, Via and To are display names.
+type Chain struct {
+	From             string `json:"from"`
+	Via              string `json:"via"`
+	To               string `json:"to"`
+	AmountMicroCents int64  `json:"amount_micro_cents"`
+}
+
+// SettlementChains suggests chains over the pairwise net debts, biggest first. Each
+// chain is applied before looking for the next, so later chains build on earlier ones.
+//
+// Unlike the minimal settlement of SettleSubset, which only looks at net balances and may
+// pair any debtor with any creditor, chains only reroute debts people actually have:
+// every suggestion removes the intermediary from one existing debt.
+func (g *Group) SettlementChains() []Chain {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	owes := map[string]map[string]int64{}
+	sums := g.directedSums()
+	for p, amount := range sums {
+		net := amount - sums[debtPair{from: p.to, to: p.from}]
+		if net < settleThresholdMicroCents {
+			continue
+		}
+		if owes[p.from] == nil {
+			owes[p.from] = map[string]int64{}
+		}
+		owes[p.from][p.to] = net
+	}
+
+	chains := []Chain{}
+	for len(chains) < maxSettlementChains {
+		best, ok := bestChain(owes)
+		if !ok {
+			break
+		}
+		a, b, c, amount := best.from, best.via, best.to, best.amount
+		reduceDebt(owes, a, b, amount)
+		reduceDebt(owes, b, c, amount)
+		// a now owes c directly, netted against anything c already owed a
+		if back := owes[c][a]; back > 0 {
+			reduceDebt(owes, c, a, min(back, amount))
+			if amount > back {
+				addDebt(owes, a, c, amount-back)
+			}
+		} else {
+			addDebt(owes, a, c, amount)
+		}
+		chains = append(chains, Chain{
+			From:             g.displayName(a),
+			Via:              g.displayName(b),
+			To:               g.displayName(c),
+			AmountMicroCents: amount,
+		})
+	}
+	return chains
+}
+
+type chainCandidate struct {
+	from, via, to string
+	amount        int64
+}
+
+// bestChain finds the chain a->b->c (a != c) that moves the most money, ties broken by
+// names so the result is deterministic.
+func bestChain(owes map[string]map[string]int64) (chainCandidate, bool) {
+	froms := make([]string, 0, len(owes))
+	for a := range owes {
+		froms = append(froms, a)
+	}
+	sort.Strings(froms)
+
+	var best chainCandidate
+	found := false
+	for _, a := range froms {
+		vias := make([]string, 0, len(owes[a]))
+		for b := range owes[a] {
+			vias = append(vias, b)
+		}
+		sort.Strings(vias)
+		for _, b := range vias {
+			tos := make([]string, 0, len(owes[b]))
+			for c := range owes[b] {
+				tos = append(tos, c)
+			}
+			sort.Strings(tos)
+			for _, c := range tos {
+				if c == a {
+					continue
+				}
+				amount := min(owes[a][b], owes[b][c])
+				if !found || amount > best.amount {
+					best = chainCandidate{from: a, via: b, to: c, amount: amount}
+					found = true
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+// reduceDebt lowers what from owes to, dropping the debt once it is under a cent.
+func reduceDebt(owes map[string]map[string]int64, from, to string, amount int64) {
+	owes[from][to] -= amount
+	if owes[from][to] < settleThresholdMicroCents {
+		delete(owes[from], to)
+	}
+	if len(owes[from]) == 0 {
+		delete(owes, from)
+	}
+}
+
+func addDebt(owes map[string]map[string]int64, from, to string, amount int64) {
+	if amount < settleThresholdMicroCents {
+		return
+	}
+	if owes[from] == nil {
+		owes[from] = map[string]int64{}
+	}
+	owes[from][to] += amount
+}
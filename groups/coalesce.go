@@ -0,0 +1,76 @@
+package groups
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// SetEdgeCoalescing turns edge coalescing on or off for expenses added from now on.
+//
+// Without coalescing, AddExpense appends one edge per participant per expense, so the
+// graph grows with every expense. With coalescing, all expense debts from one person to
+// another are merged into a single edge carrying the summed amount and the list of
+// contributing expense IDs. Payment edges are never merged.
+func (g *Group) SetEdgeCoalescing(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.coalesceEdges = on
+}
+
+// coalesceEdge adds d to the coalesced from->to edge, creating it if needed.
+// Caller must hold the group lock.
+func (g *graph) coalesceEdge(d debt, expenseID int, createdAt time.Time) error {
+	for _, e := range g.nodes[d.from] {
+		edgeInfo, ok := edgeMetadata(e)
+		if !ok || e.To != d.to || len(edgeInfo.ExpenseIDs) == 0 {
+			continue
+		}
+		edgeInfo.AmountInMicroCents += d.amount
+		edgeInfo.ExpenseIDs = append(edgeInfo.ExpenseIDs, expenseID)
+		e.Metadata = edgeInfo
+		return nil
+	}
+
+	metadata := EdgeMetadata{
+		AmountInMicroCents: d.amount,
+		ExpenseIDs:         []int{expenseID},
+	}
+	return g.addEdgeAt(d.from, d.to, metadata, createdAt)
+}
+
+// removeExpenseEdges removes the contribution of an expense from the graph: its own edges
+// are dropped, and its debts are subtracted from coalesced edges. Coalesced edges left
+// without any contributing expense are dropped too.
+// Caller must hold the group lock.
+func (g *Group) removeExpenseEdges(e *Expense) {
+	type pair struct {
+		from string
+		to   string
+	}
+
+	contributions := map[pair]int64{}
+	for _, d := range expenseDebts(e) {
+		contributions[pair{from: d.from, to: d.to}] += d.amount
+	}
+
+	removed := g.graph.removeEdges(func(from string, edge *edge) bool {
+		edgeInfo, ok := edgeMetadata(edge)
+		if !ok {
+			return false
+		}
+		if edgeInfo.ExpenseID == e.ID && len(edgeInfo.ExpenseIDs) == 0 {
+			return true
+		}
+		i := slices.Index(edgeInfo.ExpenseIDs, e.ID)
+		if i < 0 {
+			return false
+		}
+		edgeInfo.ExpenseIDs = slices.Delete(slices.Clone(edgeInfo.ExpenseIDs), i, i+1)
+		edgeInfo.AmountInMicroCents -= contributions[pair{from: from, to: edge.To}]
+		edge.Metadata = edgeInfo
+		return len(edgeInfo.ExpenseIDs) == 0
+	})
+	slog.Debug("removeExpenseEdges", "group", g.Name, "expense_id", e.ID, "edges_removed", removed)
+}
@@ -0,0 +1,36 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCoveredByLength is the longest CoveredBy label, in characters.
+const maxCoveredByLength = 32
+
+// validateCoverage checks CoveredPercent and CoveredBy and trims the label.
+func validateCoverage(e *Expense) error {
+	e.CoveredBy = strings.TrimSpace(e.CoveredBy)
+	if math.IsNaN(e.CoveredPercent) || e.CoveredPercent < 0 || e.CoveredPercent >= 100 {
+		slog.Error("expense covered percent out of range", "covered_percent", e.CoveredPercent)
+		return fmt.Errorf("covered percent(%v) must be at least 0 and less than 100", e.CoveredPercent)
+	}
+	if e.CoveredPercent > 0 && e.CoveredBy == "" {
+		return fmt.Errorf("covered percent needs covered_by, e.g. Company")
+	}
+	if e.CoveredPercent == 0 && e.CoveredBy != "" {
+		return fmt.Errorf("covered_by(%s) needs a covered percent", e.CoveredBy)
+	}
+	if utf8.RuneCountInString(e.CoveredBy) > maxCoveredByLength {
+		return fmt.Errorf("covered_by must be at most %d characters", maxCoveredByLength)
+	}
+	return nil
+}
+
+// CoveredMicroCents is the part of the total borne by CoveredBy, rounded to the micro cent.
+func (e *Expense) CoveredMicroCents() int64 {
+	return int64(math.Round(float64(e.TotalMicroCents) * e.CoveredPercent / 100))
+}
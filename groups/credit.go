@@ -0,0 +1,93 @@
+package groups
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// groupCreditLabel marks the synthetic expenses recorded by ApplyGroupCredit.
+const groupCreditLabel = "group-credit"
+
+// ApplyGroupCredit spreads a refund not tied to one expense over the members by their
+// shares so far and takes it off the payers by what they paid. It is recorded as an
+// expense labelled group-credit, which spending totals leave out. The credit must be a
+// positive whole number of cents, at most the group's total spending.
+func (g *Group) ApplyGroupCredit(amountMicroCents int64) error {
+	if amountMicroCents <= 0 {
+		return fmt.Errorf("group credit(%d) must be positive", amountMicroCents)
+	}
+	if amountMicroCents%1000 != 0 {
+		return fmt.Errorf("group credit(%d) must be a whole number of cents", amountMicroCents)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	borne := map[string]float64{}
+	paid := map[string]float64{}
+	total := int64(0)
+	for _, e := range g.expenses {
+		if e.isGroupCredit() {
+			continue
+		}
+		for key, share := range e.ResolvedShares {
+			borne[key] += float64(share)
+			total += share
+		}
+		for key, amount := range e.paidShares() {
+			paid[key] += float64(amount)
+		}
+	}
+	if total == 0 {
+		return errors.New("a group credit needs expenses to spread it over")
+	}
+	if amountMicroCents > total {
+		return fmt.Errorf("group credit %s is more than the total spending %s", formatMicroCentsAsDollars(amountMicroCents), formatMicroCentsAsDollars(total))
+	}
+
+	credits, err := splitByWeights(amountMicroCents, borne)
+	if err != nil {
+		return err
+	}
+	refunds, err := splitByWeights(amountMicroCents, paid)
+	if err != nil {
+		return err
+	}
+	e := &Expense{
+		TotalMicroCents:      amountMicroCents,
+		PaidByMap:            map[string]float64{},
+		Description:          "group credit",
+		SplitMethod:          "exact",
+		SplitExactMicroCents: map[string]int64{},
+		Labels:               []string{groupCreditLabel},
+	}
+	// payer amounts are in dollars, so the credits are rounded to cents
+	for key, cents := range distributeCents(credits, amountMicroCents/1000) {
+		if cents > 0 {
+			e.PaidByMap[g.displayName(key)] = float64(cents) / 100
+		}
+	}
+	for key, refund := range refunds {
+		e.SplitExactMicroCents[g.displayName(key)] = refund
+	}
+	if err := validateExpenseFields(e); err != nil {
+		return err
+	}
+	if err := g.resolveExpense(e); err != nil {
+		slog.Error("group credit failed", "group", g.Name, "error", err.Error())
+		return err
+	}
+	if err := g.storeExpense(e); err != nil {
+		return err
+	}
+	slog.Debug("ApplyGroupCredit", "group", g.Name, "amount_micro_cents", amountMicroCents, "expense_id", e.ID)
+	return nil
+}
+
+// isGroupCredit reports whether e was recorded by ApplyGroupCredit. Such expenses move
+// balances but aren't spending.
+func (e *Expense) isGroupCredit() bool {
+	return slices.Contains(e.Labels, groupCreditLabel)
+}
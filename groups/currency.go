@@ -0,0 +1,211 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// DefaultHomeCurrency is the home currency of a group that never set one.
+const DefaultHomeCurrency = "USD"
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// normalizeCurrency upper-cases an ISO 4217 code such as "eur" and checks its shape.
+func normalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyPattern.MatchString(code) {
+		return "", fmt.Errorf("currency(%s) must be a 3-letter ISO 4217 code such as USD", code)
+	}
+	return code, nil
+}
+
+// SetHomeCurrency sets the currency the group keeps its books in. Expenses without a
+// currency are taken to be in the home currency, and so are payments. It can't change once
+// the group has expenses or debts, since their amounts are already in the old currency.
+func (g *Group) SetHomeCurrency(code string) error {
+	code, err := normalizeCurrency(code)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if code == g.home() {
+		g.homeCurrency = code
+		return nil
+	}
+	if len(g.expenses) > 0 || g.graph.hasEdges() {
+		slog.Error("home currency change refused, group has amounts", "group", g.Name)
+		return fmt.Errorf("home currency of group(%s) can't change from %s: its expenses and debts are already in %s", g.Name, g.home(), g.home())
+	}
+	g.homeCurrency = code
+	return nil
+}
+
+// convertToHome converts a new expense in a foreign currency into the home currency with
+// its ExchangeRate, rounding the total, discount, tip and payer's personal portion to the
+// cent. Exact split amounts and several payers' amounts are spread over the converted
+// totals in proportion to what was entered, so they still add up. Expenses already in the
+// home currency are left alone, apart from rejecting a rate.
+// Caller must hold the group lock.
+func (g *Group) convertToHome(e *Expense) error {
+	if e.Currency == "" || e.Currency == g.home() {
+		if e.ExchangeRate != 0 {
+			return fmt.Errorf("exchange rate is only for expenses in a currency other than %s", g.home())
+		}
+		return nil
+	}
+	rate := e.ExchangeRate
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		slog.Error("expense exchange rate missing", "group", g.Name, "currency", e.Currency)
+		return fmt.Errorf("expense in %s needs a positive exchange rate: the value of one %s in %s", e.Currency, e.Currency, g.home())
+	}
+	// check what must add up while the amounts are still as entered
+	if e.SplitMethod == "exact" {
+		if _, err := splitExact(e.sharedMicroCents(), e.SplitExactMicroCents); err != nil {
+			return err
+		}
+	}
+	if len(e.PaidByMap) > 0 {
+		sum := int64(0)
+		for _, dollars := range e.PaidByMap {
+			sum += dollarsToMicroCents(dollars)
+		}
+		if sum != e.NetMicroCents() {
+			return fmt.Errorf("expense paid_by_map amounts must add up to the total %s, got %s",
+				formatMicroCentsAsDollars(e.NetMicroCents()), formatMicroCentsAsDollars(sum))
+		}
+	}
+
+	convert := func(micro int64) int64 {
+		return int64(math.Round(float64(micro)*rate/1000)) * 1000
+	}
+	e.OriginalMicroCents = e.TotalMicroCents
+	e.TotalMicroCents = convert(e.TotalMicroCents)
+	e.DiscountMicroCents = convert(e.DiscountMicroCents)
+	e.PayerPersonalMicroCents = convert(e.PayerPersonalMicroCents)
+	e.TipMicroCents = convert(e.TipMicroCents)
+	if err := validateExpenseFields(e); err != nil {
+		return err
+	}
+	if e.SplitMethod == "exact" {
+		weights := make(map[string]float64, len(e.SplitExactMicroCents))
+		for name, amount := range e.SplitExactMicroCents {
+			weights[name] = float64(amount)
+		}
+		converted, err := splitByWeights(e.sharedMicroCents(), weights)
+		if err != nil {
+			return err
+		}
+		for name := range e.SplitExactMicroCents {
+			e.SplitExactMicroCents[name] = converted[name]
+		}
+	}
+	if len(e.PaidByMap) > 0 {
+		weights := make(map[string]float64, len(e.PaidByMap))
+		for name, dollars := range e.PaidByMap {
+			weights[name] = max(dollars, 0)
+		}
+		shares, err := splitByWeights(e.NetMicroCents(), weights)
+		if err != nil {
+			return err
+		}
+		cents := distributeCents(shares, e.NetMicroCents()/1000)
+		for name := range e.PaidByMap {
+			e.PaidByMap[name] = float64(cents[name]) / 100
+		}
+	}
+	slog.Debug("convertToHome", "group", g.Name, "currency", e.Currency, "rate", rate)
+	return nil
+}
+
+// HomeCurrency returns the group's home currency, DefaultHomeCurrency if it was never set.
+func (g *Group) HomeCurrency() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.home()
+}
+
+// home returns the group's home currency.
+// Caller must hold the group lock.
+func (g *Group) home() string {
+	if g.homeCurrency == "" {
+		return DefaultHomeCurrency
+	}
+	return g.homeCurrency
+}
+
+// ReportInHomeCurrency lists every expense and payment in the home currency, noting the
+// amount as entered and the rate it was converted at for expenses in another currency, and
+// ends with the total spent.
+func (g *Group) ReportInHomeCurrency() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	home := g.home()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Report for %s in %s\n", g.Name, home)
+	total := int64(0)
+	for _, e := range g.sortedExpenses() {
+		net := e.NetMicroCents()
+		total += net
+		if e.Currency == "" || e.Currency == home {
+			fmt.Fprintf(&b, "#%d %s, paid by %s: %s %s\n", e.ID, e.Description, e.PaidBy, formatAmount(net), home)
+			continue
+		}
+		fmt.Fprintf(&b, "#%d %s, paid by %s: %s %s (%s %s at %g)\n",
+			e.ID, e.Description, e.PaidBy, formatAmount(net), home, formatAmount(e.OriginalMicroCents), e.Currency, e.ExchangeRate)
+	}
+	for _, p := range g.payments() {
+		fmt.Fprintf(&b, "payment %s -> %s: %s %s\n", p.From, p.To, formatAmount(p.AmountMicroCents), home)
+	}
+	fmt.Fprintf(&b, "Total spent: %s %s", formatAmount(total), home)
+	return b.String()
+}
+
+// SettleUpConverted returns the same minimal settlement as DissolutionPlan, with each
+// transfer converted into the currency target. Balances are already all in the home
+// currency, since expenses are converted when added, so only one rate is needed: rate is
+// what one unit of the home currency is worth in target, e.g. 0.92 to settle a USD group
+// in EUR. It is ignored when target is the home currency.
+//
+// Each transfer is rounded to the cent after conversion, so the converted amounts can be a
+// cent off the home-currency ones. The result is only as good as the rate given.
+func (g *Group) SettleUpConverted(target string, rate float64) ([]Settlement, error) {
+	target, err := normalizeCurrency(target)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	home := g.home()
+	if target == home {
+		rate = 1
+	}
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		slog.Error("missing exchange rate", "group", g.Name, "from", home, "to", target)
+		return nil, fmt.Errorf("settling in %s needs a positive rate: the value of one %s in %s", target, home, target)
+	}
+
+	settlements := g.minimalSettlement(g.netBalances(nil))
+	converted := make([]Settlement, 0, len(settlements))
+	for _, s := range settlements {
+		s.AmountMicroCents = int64(math.Round(float64(s.AmountMicroCents)*rate/1000)) * 1000
+		if s.AmountMicroCents > 0 {
+			converted = append(converted, s)
+		}
+	}
+	return converted, nil
+}
+
+// formatAmount renders micro cents as a plain decimal amount, e.g. "12.50".
+func formatAmount(micro int64) string {
+	return fmt.Sprintf("%.2f", float64(micro)/100_000)
+}
@@ -0,0 +1,138 @@
+package groups
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PairDebt is the outstanding net debt between two people: From owes To AmountMicroCents.
+// From and To are display names.
+type PairDebt struct {
+	From             string `json:"from"`
+	To               string `json:"to"`
+	AmountMicroCents int64  `json:"amount_micro_cents"`
+}
+
+// TopDebts returns the n largest outstanding pairwise net debts, largest first.
+// Settled pairs are excluded; fewer than n debts are returned when there aren't enough.
+func (g *Group) TopDebts(n int) []PairDebt {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	debts := g.pairwiseNetDebts()
+	sort.Slice(debts, func(i, j int) bool {
+		if debts[i].AmountMicroCents != debts[j].AmountMicroCents {
+			return debts[i].AmountMicroCents > debts[j].AmountMicroCents
+		}
+		if debts[i].From != debts[j].From {
+			return debts[i].From < debts[j].From
+		}
+		return debts[i].To < debts[j].To
+	})
+	if n < 0 {
+		n = 0
+	}
+	if n < len(debts) {
+		debts = debts[:n]
+	}
+	return debts
+}
+
+// pairwiseNetDebts nets the edges of every pair of people and returns the pairs that
+// still owe at least a cent, in no particular order.
+// Caller must hold the group lock.
+func (g *Group) pairwiseNetDebts() []PairDebt {
+	sums := g.directedSums()
+
+	debts := []PairDebt{}
+	for p, amount := range sums {
+		net := amount - sums[debtPair{from: p.to, to: p.from}]
+		if net < settleThresholdMicroCents {
+			continue
+		}
+		debts = append(debts, PairDebt{
+			From:             g.displayName(p.from),
+			To:               g.displayName(p.to),
+			AmountMicroCents: net,
+		})
+	}
+	return debts
+}
+
+// GrossDebts returns what each person owes each other person without netting the two
+// directions, in the format of GetExpenseDetails ("A to pay B" -> dollars). Where
+// GetExpenseDetails shows A owing B $20, this shows A owing B $30 and B owing A $10.
+// Payments are left out, as they are money that moved rather than debts.
+func (g *Group) GrossDebts() map[string]float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sums := map[debtPair]int64{}
+	for from, edges := range g.graph.nodes {
+		for _, edge := range edges {
+			edgeInfo, ok := edgeMetadata(edge)
+			if !ok || edgeInfo.ExpenseID == PaymentExpenseID {
+				continue
+			}
+			sums[debtPair{from: from, to: edge.To}] += edgeInfo.AmountInMicroCents
+		}
+	}
+	result := map[string]float64{}
+	for p, amount := range sums {
+		if dollars := microCentsToPayable(amount); dollars > 0 {
+			result[fmt.Sprintf("%s to pay %s", g.displayName(p.from), g.displayName(p.to))] = dollars
+		}
+	}
+	return result
+}
+
+// netDebtDetails nets the directed sums of every pair and returns what is left in the
+// format of GetExpenseDetails ("A to pay B" -> dollars).
+// Caller must hold the group lock.
+func (g *Group) netDebtDetails(sums map[debtPair]int64) map[string]float64 {
+	result := map[string]float64{}
+	for p, amount := range sums {
+		net := amount - sums[debtPair{from: p.to, to: p.from}]
+		if dollars := microCentsToPayable(net); dollars > 0 {
+			result[fmt.Sprintf("%s to pay %s", g.displayName(p.from), g.displayName(p.to))] = dollars
+		}
+	}
+	return result
+}
+
+// debtPair is a directed pair of people, keyed by normalized names.
+type debtPair struct {
+	from string
+	to   string
+}
+
+// directedSums sums the edges of every directed pair without netting.
+// Caller must hold the group lock.
+func (g *Group) directedSums() map[debtPair]int64 {
+	sums := map[debtPair]int64{}
+	for from, edges := range g.graph.nodes {
+		for _, edge := range edges {
+			edgeInfo, ok := edgeMetadata(edge)
+			if !ok {
+				continue
+			}
+			sums[debtPair{from: from, to: edge.To}] += edgeInfo.AmountInMicroCents
+		}
+	}
+	return sums
+}
+
+// sortedDebtPairs returns the keys of sums ordered by from, then to.
+func sortedDebtPairs(sums map[debtPair]int64) []debtPair {
+	pairs := make([]debtPair, 0, len(sums))
+	for p := range sums {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].from == pairs[j].from {
+			return pairs[i].to < pairs[j].to
+		}
+		return pairs[i].from < pairs[j].from
+	})
+	return pairs
+}
@@ -0,0 +1,64 @@
+package groups
+
+import (
+	"log/slog"
+	"maps"
+	"math"
+)
+
+// percentagePrecision is how many decimal places NormalizePercentageMaps keeps.
+const percentagePrecision = 4
+
+// NormalizePercentageMaps rounds the stored percentages of every percentage split to
+// percentagePrecision decimal places, so float drift such as 33.33333333 from repeated
+// edits or imports doesn't build up. Affected expenses are split again with the rounded
+// percentages and the debt graph is rebuilt. An expense whose rounded percentages no longer
+// add up to 100 is left as it is. It returns how many expenses were adjusted.
+func (g *Group) NormalizePercentageMaps() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	scale := math.Pow10(percentagePrecision)
+	s := g.snapshot()
+	adjusted := 0
+	for i := range s.Expenses {
+		e := &s.Expenses[i]
+		if e.SplitMethod != "percentage" {
+			continue
+		}
+		rounded := make(map[string]float64, len(e.SplitPercentages))
+		for name, p := range e.SplitPercentages {
+			rounded[name] = math.Round(p*scale) / scale
+		}
+		if maps.Equal(rounded, e.SplitPercentages) {
+			continue
+		}
+
+		edited := copyExpense(e)
+		edited.SplitPercentages = rounded
+		if err := validateSplitMap("percentage", rounded, func(string) bool { return true }); err != nil {
+			slog.Error("normalize percentages skipped expense", "group", g.Name, "expense_id", e.ID, "error", err.Error())
+			continue
+		}
+		if err := g.resolveExpense(&edited); err != nil {
+			slog.Error("normalize percentages skipped expense", "group", g.Name, "expense_id", e.ID, "error", err.Error())
+			continue
+		}
+		*e = edited
+		adjusted++
+	}
+	if adjusted == 0 {
+		return 0
+	}
+
+	people, expenses, gr, err := buildState(g.Name, s)
+	if err != nil {
+		slog.Error("normalize percentages failed", "group", g.Name, "error", err.Error())
+		return 0
+	}
+	g.people = people
+	g.expenses = expenses
+	g.graph = gr
+	slog.Debug("NormalizePercentageMaps", "group", g.Name, "adjusted", adjusted)
+	return adjusted
+}
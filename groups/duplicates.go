@@ -0,0 +1,66 @@
+package groups
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MergeDuplicates keeps the expense with the lowest of ids and deletes the others, with
+// every debt edge they created, for an expense that was entered more than once. The
+// expenses must be true duplicates: same payers, amounts, description (ignoring case) and
+// shares. Nothing changes if any of them differs.
+func (g *Group) MergeDuplicates(ids []int) (keptID int, err error) {
+	if len(ids) < 2 {
+		return 0, errors.New("at least 2 expense IDs are required to merge")
+	}
+	ids = slices.Sorted(slices.Values(ids))
+	if len(slices.Compact(slices.Clone(ids))) != len(ids) {
+		return 0, errors.New("expense IDs must be distinct")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	list := make([]*Expense, 0, len(ids))
+	for _, id := range ids {
+		e, exists := g.expenses[id]
+		if !exists {
+			return 0, fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+		}
+		list = append(list, e)
+	}
+	kept := list[0]
+	for _, e := range list[1:] {
+		if err := sameExpense(kept, e); err != nil {
+			return 0, fmt.Errorf("expense(%d) isn't a duplicate of expense(%d): %w", e.ID, kept.ID, err)
+		}
+	}
+
+	for _, e := range list[1:] {
+		delete(g.expenses, e.ID)
+		g.removeExpenseEdges(e)
+	}
+	slog.Debug("MergeDuplicates", "group", g.Name, "kept", kept.ID, "deleted", len(list)-1)
+	return kept.ID, nil
+}
+
+// sameExpense returns why b differs from a, or nil when it records the same spending.
+func sameExpense(a, b *Expense) error {
+	switch {
+	case normalizeName(a.PaidBy) != normalizeName(b.PaidBy) || !maps.Equal(a.paidShares(), b.paidShares()):
+		return errors.New("payers differ")
+	case a.TotalMicroCents != b.TotalMicroCents || a.DiscountMicroCents != b.DiscountMicroCents:
+		return errors.New("amounts differ")
+	case !strings.EqualFold(a.Description, b.Description):
+		return errors.New("descriptions differ")
+	case a.Currency != b.Currency:
+		return errors.New("currencies differ")
+	case !maps.Equal(a.ResolvedShares, b.ResolvedShares):
+		return errors.New("shares differ")
+	}
+	return nil
+}
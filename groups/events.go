@@ -0,0 +1,28 @@
+package groups
+
+import "strings"
+
+// maxEventLength is the longest event name of an expense, in characters.
+const maxEventLength = 50
+
+// ExpensesByEvent returns copies of the expenses that belong to an event, grouped by event
+// name and in ID order within each event. Event names are matched case-insensitively; the
+// key is the name as first recorded. Expenses without an event are left out.
+func (g *Group) ExpensesByEvent() map[string][]Expense {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	names := map[string]string{}
+	byEvent := map[string][]Expense{}
+	for _, e := range g.sortedExpenses() {
+		if e.Event == "" {
+			continue
+		}
+		key := strings.ToLower(e.Event)
+		if _, ok := names[key]; !ok {
+			names[key] = e.Event
+		}
+		byEvent[names[key]] = append(byEvent[names[key]], copyExpense(e))
+	}
+	return byEvent
+}
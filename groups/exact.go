@@ -0,0 +1,53 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// normalizeExactAmounts validates a person->amount map for an "exact" split against the
+// group's members and returns it keyed by normalized name. Amounts must not be negative.
+// Caller must hold the group lock.
+func (g *Group) normalizeExactAmounts(amounts map[string]int64) (map[string]int64, error) {
+	if len(amounts) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]int64, len(amounts))
+	for name, amount := range amounts {
+		key := normalizeName(name)
+		if _, exists := g.people[key]; !exists {
+			slog.Error("expense split_exact validation failed, name not in the group", "name", name, "group", g.Name)
+			return nil, fmt.Errorf("expense split_exact validation failed, name(%s) not in the group(%s)", name, g.Name)
+		}
+		if _, exists := out[key]; exists {
+			return nil, fmt.Errorf("duplicate name in split_exact after normalization: %q", name)
+		}
+		if amount < 0 {
+			return nil, fmt.Errorf("split_exact amount for %s must be >= 0, got %d", name, amount)
+		}
+		out[key] = amount
+	}
+	return out, nil
+}
+
+// splitExact returns the given per-person amounts as the shares, leaving out people with
+// nothing to pay. The amounts must add up exactly to sharedMicroCents, the part of the
+// total left once the tip, payer's personal and covered portions are taken off.
+func splitExact(sharedMicroCents int64, amounts map[string]int64) (map[string]int64, error) {
+	if len(amounts) == 0 {
+		return nil, fmt.Errorf("split_exact is required for exact split")
+	}
+	sum := int64(0)
+	shares := make(map[string]int64, len(amounts))
+	for key, amount := range amounts {
+		sum += amount
+		if amount > 0 {
+			shares[key] = amount
+		}
+	}
+	if sum != sharedMicroCents {
+		return nil, fmt.Errorf("split_exact amounts must add up to the shared amount %s (the total less any tip split by tip_split_weights, payer's personal or covered portion), got %s",
+			formatMicroCentsAsDollars(sharedMicroCents), formatMicroCentsAsDollars(sum))
+	}
+	return shares, nil
+}
@@ -0,0 +1,373 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// ExpenseView is an expense as entered, for listing: the amount both in dollars and in
+// micro cents, names for display, and the split the method used.
+type ExpenseView struct {
+	ID               int    `json:"id"`
+	Amount           string `json:"amount"` // before any discount
+	AmountMicroCents int64  `json:"amount_micro_cents"`
+	PaidBy           string `json:"paid_by"`
+	Description      string `json:"description"`
+	SplitMethod      string `json:"split_method"`
+
+	// Discount is what was taken off Amount, and Net what was actually paid, which the
+	// shares add up to. Both are empty without a discount, when Net is Amount.
+	Discount string `json:"discount,omitempty"`
+	Net      string `json:"net,omitempty"`
+
+	// PaidByMap is what each payer paid, in dollars, when several people paid; PaidBy is
+	// then the biggest of them.
+	PaidByMap map[string]float64 `json:"paid_by_map,omitempty"`
+
+	// Split is the per-person input of the split method: percentages, weights, heads, or
+	// dollars for an exact split. It is nil for methods without one (equal, duration, income).
+	Split map[string]float64 `json:"split,omitempty"`
+
+	// Shares is what each participant ended up owing, in dollars, payer included.
+	Shares map[string]float64 `json:"shares"`
+
+	Labels    []string  `json:"labels,omitempty"`
+	Event     string    `json:"event,omitempty"`
+	EnteredBy string    `json:"entered_by,omitempty"` // who added the expense
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListExpenses returns every expense of the group, sorted by ID.
+func (g *Group) ListExpenses() []ExpenseView {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	list := make([]ExpenseView, 0, len(g.expenses))
+	for _, e := range g.sortedExpenses() {
+		list = append(list, g.expenseView(e))
+	}
+	return list
+}
+
+// ExpenseViews returns the views of expenses, e.g. ones returned by GetExpense or a
+// filter, in the order given.
+func (g *Group) ExpenseViews(expenses []Expense) []ExpenseView {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	views := make([]ExpenseView, 0, len(expenses))
+	for i := range expenses {
+		views = append(views, g.expenseView(&expenses[i]))
+	}
+	return views
+}
+
+// expenseView returns the view of e. Caller must hold the group lock.
+func (g *Group) expenseView(e *Expense) ExpenseView {
+	view := ExpenseView{
+		ID:               e.ID,
+		Amount:           formatMicroCentsAsDollars(e.TotalMicroCents),
+		AmountMicroCents: e.TotalMicroCents,
+		PaidBy:           g.displayName(normalizeName(e.PaidBy)),
+		Description:      e.Description,
+		SplitMethod:      e.SplitMethod,
+		Shares:           make(map[string]float64, len(e.ResolvedShares)),
+		Labels:           slices.Clone(e.Labels),
+		Event:            e.Event,
+		EnteredBy:        e.EnteredBy,
+		CreatedAt:        e.CreatedAt,
+	}
+	if e.DiscountMicroCents > 0 {
+		view.Discount = formatMicroCentsAsDollars(e.DiscountMicroCents)
+		view.Net = formatMicroCentsAsDollars(e.NetMicroCents())
+	}
+	switch e.SplitMethod {
+	case "percentage":
+		view.Split = displayKeyed(g, e.SplitPercentages, func(pct float64) float64 { return pct })
+	case "weights":
+		view.Split = displayKeyed(g, e.SplitWeights, func(w float64) float64 { return w })
+	case "headcount":
+		view.Split = displayKeyed(g, e.SplitHeadcount, func(heads int) float64 { return float64(heads) })
+	case "exact":
+		view.Split = displayKeyed(g, e.SplitExactMicroCents, microCentsToDollars)
+	}
+	if len(e.PaidByMap) > 0 {
+		view.PaidByMap = displayKeyed(g, e.paidShares(), microCentsToDollars)
+	}
+	for key, micro := range e.ResolvedShares {
+		view.Shares[g.displayName(key)] = microCentsToDollars(micro)
+	}
+	return view
+}
+
+// displayKeyed converts a per-person split map keyed by normalized name into one keyed by
+// display name. Caller must hold the group lock.
+func displayKeyed[V any](g *Group, m map[string]V, conv func(V) float64) map[string]float64 {
+	out := make(map[string]float64, len(m))
+	for key, v := range m {
+		out[g.displayName(key)] = conv(v)
+	}
+	return out
+}
+
+// DeleteExpense removes an expense and every debt edge it created.
+func (g *Group) DeleteExpense(id int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e, exists := g.expenses[id]
+	if !exists {
+		return fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+	}
+	delete(g.expenses, id)
+	g.removeExpenseEdges(e)
+	slog.Debug("DeleteExpense", "group", g.Name, "expense_id", id)
+	return nil
+}
+
+// CompactExpenseIDs renumbers the remaining expenses to 1..N in their current order,
+// rewrites the ExpenseID of their edges and resets the ID counter. Archived expenses keep
+// their IDs, so after a close out the numbering starts above the highest archived ID
+// instead. It returns the old->new mapping for the expenses whose ID changed.
+func (g *Group) CompactExpenseIDs() map[int]int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	base := 0
+	for _, e := range g.archived {
+		base = max(base, e.ID)
+	}
+	mapping := map[int]int{}
+	compacted := make(map[int]*Expense, len(g.expenses))
+	for i, e := range g.sortedExpenses() {
+		newID := base + i + 1
+		if e.ID != newID {
+			mapping[e.ID] = newID
+		}
+		e.ID = newID
+		compacted[newID] = e
+	}
+	g.expenses = compacted
+	g.expenseIdCounter = base + len(compacted)
+
+	if len(mapping) == 0 {
+		return mapping
+	}
+	for _, edges := range g.graph.nodes {
+		for _, e := range edges {
+			edgeInfo, ok := edgeMetadata(e)
+			if !ok {
+				continue
+			}
+			if newID, renumbered := mapping[edgeInfo.ExpenseID]; renumbered {
+				edgeInfo.ExpenseID = newID
+			}
+			if len(edgeInfo.ExpenseIDs) > 0 {
+				ids := make([]int, len(edgeInfo.ExpenseIDs))
+				for i, id := range edgeInfo.ExpenseIDs {
+					ids[i] = id
+					if newID, renumbered := mapping[id]; renumbered {
+						ids[i] = newID
+					}
+				}
+				edgeInfo.ExpenseIDs = ids
+			}
+			e.Metadata = edgeInfo
+		}
+	}
+	slog.Debug("CompactExpenseIDs", "group", g.Name, "renumbered", len(mapping))
+	return mapping
+}
+
+// GetExpense returns a copy of the expense with the given ID.
+func (g *Group) GetExpense(id int) (Expense, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e, exists := g.expenses[id]
+	if !exists {
+		return Expense{}, fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+	}
+	return copyExpense(e), nil
+}
+
+// ExpenseShares returns each participant's share of the expense in micro cents, keyed by
+// display name.
+func (g *Group) ExpenseShares(id int) (map[string]int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e, exists := g.expenses[id]
+	if !exists {
+		return nil, fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+	}
+	shares := make(map[string]int64, len(e.ResolvedShares))
+	for key, share := range e.ResolvedShares {
+		shares[g.displayName(key)] = share
+	}
+	return shares, nil
+}
+
+// ExpensesByMethod returns copies of the expenses split with method, in ID order.
+func (g *Group) ExpensesByMethod(method string) ([]Expense, error) {
+	if err := validateSplitMethod(method); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	list := []Expense{}
+	for _, e := range g.sortedExpenses() {
+		if e.SplitMethod == method {
+			list = append(list, copyExpense(e))
+		}
+	}
+	return list, nil
+}
+
+// ExpenseImpact returns how one expense moved each affected person's net balance, in micro
+// cents keyed by display name: positive for those who are owed more because of it, negative
+// for those who owe more. The deltas come from the debts the expense put on the graph, so
+// they add up to zero and settled participants don't appear.
+func (g *Group) ExpenseImpact(id int) (map[string]int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e, exists := g.expenses[id]
+	if !exists {
+		return nil, fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+	}
+	impact := map[string]int64{}
+	for _, d := range expenseDebts(e) {
+		impact[g.displayName(d.from)] -= d.amount
+		impact[g.displayName(d.to)] += d.amount
+	}
+	return impact, nil
+}
+
+// ExpensesOwedBy returns copies of the expenses name owes a share of, in ID order: those
+// with a share for them that they didn't pay towards. Expenses they paid for, settled on
+// the spot or weren't part of are left out.
+func (g *Group) ExpensesOwedBy(name string) ([]Expense, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		return nil, fmt.Errorf("person(%s) not found in group(%s)", name, g.Name)
+	}
+	list := []Expense{}
+	for _, e := range g.sortedExpenses() {
+		if e.ResolvedShares[key] <= 0 {
+			continue
+		}
+		if _, paid := e.paidShares()[key]; paid {
+			continue
+		}
+		if slices.ContainsFunc(e.SettledParticipants, func(p string) bool { return normalizeName(p) == key }) {
+			continue
+		}
+		list = append(list, copyExpense(e))
+	}
+	return list, nil
+}
+
+// SoleBeneficiaryExpenses returns copies of the expenses, in ID order, where name was the
+// only one with a share after the split, e.g. someone's own ticket paid by a friend.
+// Such expenses are often personal charges that were entered as shared.
+func (g *Group) SoleBeneficiaryExpenses(name string) ([]Expense, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		return nil, fmt.Errorf("person(%s) not found in group(%s)", name, g.Name)
+	}
+	list := []Expense{}
+	for _, e := range g.sortedExpenses() {
+		if e.ResolvedShares[key] <= 0 {
+			continue
+		}
+		sole := true
+		for other, share := range e.ResolvedShares {
+			if other != key && share > 0 {
+				sole = false
+				break
+			}
+		}
+		if sole {
+			list = append(list, copyExpense(e))
+		}
+	}
+	return list, nil
+}
+
+// ExtremaExpenses returns copies of the most and the least expensive expenses by net
+// amount (after any discount), group credits aside. Ties go to the lowest ID. Both are
+// nil if the group has no expenses.
+func (g *Group) ExtremaExpenses() (max, min *Expense) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, e := range g.sortedExpenses() {
+		if e.isGroupCredit() {
+			continue
+		}
+		if max == nil || e.NetMicroCents() > max.NetMicroCents() {
+			c := copyExpense(e)
+			max = &c
+		}
+		if min == nil || e.NetMicroCents() < min.NetMicroCents() {
+			c := copyExpense(e)
+			min = &c
+		}
+	}
+	return max, min
+}
+
+// PreviewEditAmount shows the pairwise debts, in the format of GetExpenseDetails, before
+// and after changing the amount of expense id to newAmountMicroCents. The expense is split
+// again the way AddExpense would, among the same participants; nothing is changed.
+// Expenses with several payers can't be previewed, as their paid amounts would change too.
+func (g *Group) PreviewEditAmount(id int, newAmountMicroCents int64) (before, after map[string]float64, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e, exists := g.expenses[id]
+	if !exists {
+		return nil, nil, fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+	}
+	if len(e.PaidByMap) > 0 {
+		return nil, nil, fmt.Errorf("expense(%d) has several payers; change what each paid instead", id)
+	}
+
+	edited := copyExpense(e)
+	edited.TotalMicroCents = newAmountMicroCents
+	if err := validateExpenseFields(&edited); err != nil {
+		return nil, nil, err
+	}
+	if edited.SplitMethod == "equal" {
+		// keep members who joined after the expense out of it
+		for key, p := range g.people {
+			if _, ok := e.ResolvedShares[key]; !ok && !slices.Contains(edited.Excluded, p.Name) {
+				edited.Excluded = append(edited.Excluded, p.Name)
+			}
+		}
+	}
+	if err := g.resolveExpense(&edited); err != nil {
+		return nil, nil, err
+	}
+
+	sums := g.directedSums()
+	before = g.netDebtDetails(sums)
+	for _, d := range expenseDebts(e) {
+		sums[debtPair{from: d.from, to: d.to}] -= d.amount
+	}
+	for _, d := range expenseDebts(&edited) {
+		sums[debtPair{from: d.from, to: d.to}] += d.amount
+	}
+	return before, g.netDebtDetails(sums), nil
+}
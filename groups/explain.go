@@ -0,0 +1,106 @@
+package groups
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ExplainBalance describes a person's balance in plain English, addressed to them:
+// "You owe $45.00 total: $30.00 to Alice for hotel (#3) and $15.00 to Bob for gas (#7).
+// You're owed $10.00 by Carol for snacks (#5). Net: you owe $35.00."
+// Amounts are pairwise net debts, as in PersonLedger; each is followed by the expenses
+// that created debts in that direction.
+func (g *Group) ExplainBalance(name string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		return "", fmt.Errorf("person(%s) not found in group(%s)", name, g.Name)
+	}
+
+	others := make([]string, 0, len(g.people))
+	for other := range g.people {
+		if other != key {
+			others = append(others, other)
+		}
+	}
+	sort.Strings(others)
+
+	var owes, owedBy []string
+	var owesTotal, owedTotal int64
+	for _, other := range others {
+		net := g.netOwed(key, other)
+		switch {
+		case net >= settleThresholdMicroCents:
+			owesTotal += net
+			owes = append(owes, fmt.Sprintf("%s to %s%s", formatMicroCentsAsDollars(net), g.displayName(other),
+				g.debtReasons(key, other)))
+		case net <= -settleThresholdMicroCents:
+			owedTotal -= net
+			owedBy = append(owedBy, fmt.Sprintf("%s by %s%s", formatMicroCentsAsDollars(-net), g.displayName(other),
+				g.debtReasons(other, key)))
+		}
+	}
+
+	if len(owes) == 0 && len(owedBy) == 0 {
+		return "You're all settled up.", nil
+	}
+	var b strings.Builder
+	if len(owes) > 0 {
+		fmt.Fprintf(&b, "You owe %s total: %s. ", formatMicroCentsAsDollars(owesTotal), joinClauses(owes))
+	}
+	if len(owedBy) > 0 {
+		fmt.Fprintf(&b, "You're owed %s total: %s. ", formatMicroCentsAsDollars(owedTotal), joinClauses(owedBy))
+	}
+	switch net := owesTotal - owedTotal; {
+	case net > 0:
+		fmt.Fprintf(&b, "Net: you owe %s.", formatMicroCentsAsDollars(net))
+	case net < 0:
+		fmt.Fprintf(&b, "Net: you're owed %s.", formatMicroCentsAsDollars(-net))
+	default:
+		b.WriteString("Net: you're even.")
+	}
+	return b.String(), nil
+}
+
+// debtReasons lists the expenses behind from's debts to to as " for hotel (#3), gas (#7)",
+// or returns "" when there are none, e.g. for debts that were only transferred.
+// Caller must hold the group lock.
+func (g *Group) debtReasons(from, to string) string {
+	ids := []int{}
+	for _, edge := range g.graph.nodes[from] {
+		edgeInfo, ok := edgeMetadata(edge)
+		if !ok || edge.To != to || edgeInfo.ExpenseID < 0 {
+			continue
+		}
+		if len(edgeInfo.ExpenseIDs) > 0 {
+			ids = append(ids, edgeInfo.ExpenseIDs...)
+		} else {
+			ids = append(ids, edgeInfo.ExpenseID)
+		}
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	reasons := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if e, exists := g.expenses[id]; exists {
+			reasons = append(reasons, fmt.Sprintf("%s (#%d)", e.Description, id))
+		}
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return " for " + strings.Join(reasons, ", ")
+}
+
+// joinClauses joins clauses as "a", "a and b" or "a, b and c".
+func joinClauses(clauses []string) string {
+	if len(clauses) < 2 {
+		return strings.Join(clauses, "")
+	}
+	return strings.Join(clauses[:len(clauses)-1], ", ") + " and " + clauses[len(clauses)-1]
+}
@@ -0,0 +1,236 @@
+package groups
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SplitwiseExport mirrors the shape of Splitwise's expense import: a list of users and a
+// list of expenses, where every expense carries per-user paid and owed shares.
+type SplitwiseExport struct {
+	Group    string             `json:"group"`
+	Users    []SplitwiseUser    `json:"users"`
+	Expenses []SplitwiseExpense `json:"expenses"`
+}
+
+// SplitwiseUser is a group member. ID is only meaningful within the export.
+type SplitwiseUser struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+}
+
+// SplitwiseExpense is one expense. Cost is the total in dollars, formatted as "12.34".
+type SplitwiseExpense struct {
+	Cost         string               `json:"cost"`
+	Description  string               `json:"description"`
+	CurrencyCode string               `json:"currency_code"`
+	Details      string               `json:"details,omitempty"`
+	Labels       []string             `json:"labels,omitempty"`
+	Event        string               `json:"event,omitempty"`
+	EnteredBy    string               `json:"entered_by,omitempty"`
+	Users        []SplitwiseUserShare `json:"users"`
+}
+
+// SplitwiseUserShare is a user's part of an expense, both formatted as "12.34".
+// PaidShare is what the user paid towards the expense; OwedShare is what the
+// user consumed. Across an expense, each column sums to Cost.
+type SplitwiseUserShare struct {
+	UserID    int    `json:"user_id"`
+	PaidShare string `json:"paid_share"`
+	OwedShare string `json:"owed_share"`
+}
+
+// ExportSplitwise returns the group as Splitwise-compatible JSON.
+// Shares are rounded to whole cents using largest remainder so paid and owed shares
+// add up to the expense cost exactly.
+func (g *Group) ExportSplitwise() ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys := make([]string, 0, len(g.people))
+	for key := range g.people {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	export := SplitwiseExport{
+		Group:    g.Name,
+		Users:    make([]SplitwiseUser, 0, len(keys)),
+		Expenses: make([]SplitwiseExpense, 0, len(g.expenses)),
+	}
+	userIDs := make(map[string]int, len(keys))
+	for i, key := range keys {
+		userIDs[key] = i + 1
+		export.Users = append(export.Users, SplitwiseUser{ID: i + 1, FirstName: g.displayName(key)})
+	}
+
+	for _, e := range g.sortedExpenses() {
+		totalCents := microCentsToCents(e.NetMicroCents())
+		owed := distributeCents(e.ResolvedShares, totalCents)
+
+		// settled participants handed their share to the payer, so it counts as paid by them
+		paid := distributeCents(e.paidShares(), totalCents)
+		payerKey := normalizeName(e.PaidBy)
+		for _, name := range e.SettledParticipants {
+			key := normalizeName(name)
+			paid[key] += owed[key]
+			paid[payerKey] -= owed[key]
+		}
+
+		// a share the export has no user for would silently unbalance the expense
+		for _, shares := range []map[string]int64{paid, owed} {
+			for _, key := range slices.Sorted(maps.Keys(shares)) {
+				if _, ok := userIDs[key]; !ok && shares[key] != 0 {
+					return nil, fmt.Errorf("expense(%d) references unknown person(%s) in group(%s)", e.ID, key, g.Name)
+				}
+			}
+		}
+
+		users := []SplitwiseUserShare{}
+		for _, key := range keys {
+			if paid[key] == 0 && owed[key] == 0 {
+				continue
+			}
+			users = append(users, SplitwiseUserShare{
+				UserID:    userIDs[key],
+				PaidShare: formatCents(paid[key]),
+				OwedShare: formatCents(owed[key]),
+			})
+		}
+		export.Expenses = append(export.Expenses, SplitwiseExpense{
+			Cost:         formatCents(totalCents),
+			Description:  e.Description,
+			CurrencyCode: g.home(),
+			Details:      participantNotesText(e.ParticipantNotes),
+			Labels:       e.Labels,
+			Event:        e.Event,
+			EnteredBy:    e.EnteredBy,
+			Users:        users,
+		})
+	}
+
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// ExportGraphML returns the debt graph as GraphML, for tools such as Gephi.
+// People are nodes labelled with their display names, and every directed pair with a debt
+// is an edge weighted by the summed amount in dollars (not netted, like the DOT graph).
+func (g *Group) ExportGraphML() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys := make([]string, 0, len(g.people))
+	for key := range g.people {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escape := func(s string) (string, error) {
+		var b strings.Builder
+		if err := xml.EscapeText(&b, []byte(s)); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="weight" for="edge" attr.name="weight" attr.type="double"/>` + "\n")
+	graphID, err := escape(g.Name)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&b, "  <graph id=\"%s\" edgedefault=\"directed\">\n", graphID)
+	for _, key := range keys {
+		id, err := escape(key)
+		if err != nil {
+			return "", err
+		}
+		label, err := escape(g.displayName(key))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "    <node id=\"%s\"><data key=\"label\">%s</data></node>\n", id, label)
+	}
+
+	sums := g.directedSums()
+	for i, p := range sortedDebtPairs(sums) {
+		if sums[p] <= 0 {
+			continue
+		}
+		source, err := escape(p.from)
+		if err != nil {
+			return "", err
+		}
+		target, err := escape(p.to)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\"><data key=\"weight\">%s</data></edge>\n",
+			i, source, target, formatCents(microCentsToCents(sums[p])))
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+	return b.String(), nil
+}
+
+// sortedExpenses returns the group's expenses in ID order.
+// Caller must hold the group lock.
+func (g *Group) sortedExpenses() []*Expense {
+	list := make([]*Expense, 0, len(g.expenses))
+	for _, e := range g.expenses {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// distributeCents rounds micro-cent shares to whole cents so they sum to totalCents.
+// Each share is floored, and the leftover cents go to the largest remainders (ties by name).
+func distributeCents(shares map[string]int64, totalCents int64) map[string]int64 {
+	type item struct {
+		name string
+		rem  int64
+	}
+
+	out := make(map[string]int64, len(shares))
+	items := make([]item, 0, len(shares))
+	used := int64(0)
+	for name, micro := range shares {
+		cents := micro / 1000
+		out[name] = cents
+		items = append(items, item{name: name, rem: micro % 1000})
+		used += cents
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].rem == items[j].rem {
+			return items[i].name < items[j].name
+		}
+		return items[i].rem > items[j].rem
+	})
+	for i := int64(0); i < totalCents-used && len(items) > 0; i++ {
+		out[items[i%int64(len(items))].name]++
+	}
+	return out
+}
+
+func microCentsToCents(micro int64) int64 {
+	return (micro + 500) / 1000
+}
+
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
@@ -0,0 +1,76 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PreviewForgiveAll returns the pairwise debts, in the format of GetExpenseDetails, that
+// would remain if everything person owes were written off. Nothing is recorded; see
+// ForgiveAllDebts.
+func (g *Group) PreviewForgiveAll(person string) (map[string]float64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(person)
+	if _, exists := g.people[key]; !exists {
+		return nil, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(person), g.Name)
+	}
+
+	sums := g.directedSums()
+	for _, d := range g.forgivenDebts(key) {
+		sums[debtPair{from: d.from, to: d.to}] += d.amount
+	}
+	return g.netDebtDetails(sums), nil
+}
+
+// ForgiveAllDebts writes off everything person owes, e.g. when the group is covering a
+// kid's expenses. Each creditor's claim is cancelled by a transfer edge from the creditor
+// to person, so the write-off shows in the ledger and survives snapshots. Unlike
+// TransferDebt, the creditors' net balances drop by what they forgave.
+// It returns the total written off in micro cents.
+func (g *Group) ForgiveAllDebts(person string) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(person)
+	if _, exists := g.people[key]; !exists {
+		return 0, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(person), g.Name)
+	}
+
+	memo := fmt.Sprintf("debts of %s forgiven", g.displayName(key))
+	total := int64(0)
+	for _, d := range g.forgivenDebts(key) {
+		metadata := EdgeMetadata{
+			AmountInMicroCents: d.amount,
+			ExpenseID:          TransferExpenseID,
+			Memo:               memo,
+		}
+		if err := g.graph.addEdge(d.from, d.to, metadata); err != nil {
+			slog.Error("forgive debts failed", "group", g.Name, "error", err.Error())
+			return 0, err
+		}
+		total += d.amount
+	}
+	slog.Debug("ForgiveAllDebts", "group", g.Name, "person", person, "amount_micro_cents", total)
+	return total, nil
+}
+
+// forgivenDebts returns the edges that cancel what key owes each other member net,
+// pointing from the creditor to key, in creditor order.
+// Caller must hold the group lock.
+func (g *Group) forgivenDebts(key string) []debt {
+	debts := []debt{}
+	for _, other := range slices.Sorted(maps.Keys(g.people)) {
+		if other == key {
+			continue
+		}
+		if owed := g.netOwed(key, other); owed > 0 {
+			debts = append(debts, debt{from: other, to: key, amount: owed})
+		}
+	}
+	return debts
+}
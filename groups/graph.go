@@ -50,6 +50,12 @@ func (g *graph) addNode(node string) error {
 // addEdge adds a directed edge between two nodes with metadata.
 // Caller must hold the group lock.
 func (g *graph) addEdge(from, to string, metadata any) error {
+	return g.addEdgeAt(from, to, metadata, time.Now())
+}
+
+// addEdgeAt is like addEdge but with an explicit creation time, used when rebuilding a graph.
+// Caller must hold the group lock.
+func (g *graph) addEdgeAt(from, to string, metadata any, createdAt time.Time) error {
 	edgeSlice, exists := g.nodes[from]
 	if !exists {
 		slog.Error("From node does not exist in the Graph", "graph", g.Name, "from", from, "to", to)
@@ -62,7 +68,7 @@ func (g *graph) addEdge(from, to string, metadata any) error {
 
 	newEdge := &edge{
 		To:        to,
-		CreatedAt: time.Now(),
+		CreatedAt: createdAt,
 		Metadata:  metadata,
 	}
 	edgeSlice = append(edgeSlice, newEdge)
@@ -75,3 +81,32 @@ func (g *graph) addEdge(from, to string, metadata any) error {
 func (g *graph) size() int {
 	return len(g.nodes)
 }
+
+// hasEdges reports whether the graph has any edge at all.
+// Caller must hold the group lock.
+func (g *graph) hasEdges() bool {
+	for _, edges := range g.nodes {
+		if len(edges) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEdges removes every edge for which match returns true and returns how many were removed.
+// Caller must hold the group lock.
+func (g *graph) removeEdges(match func(from string, e *edge) bool) int {
+	removed := 0
+	for from, edges := range g.nodes {
+		kept := edges[:0]
+		for _, e := range edges {
+			if match(from, e) {
+				removed++
+				continue
+			}
+			kept = append(kept, e)
+		}
+		g.nodes[from] = kept
+	}
+	return removed
+}
@@ -1,14 +1,17 @@
 package groups
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // Concurrency: Group's mutex is the single lock that protects both Group state and the internal graph.
@@ -18,7 +21,8 @@ var personNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z_ -]{0,31}$`)
 // Person represents a node in the graph
 // It has to be a unique name within the group
 type Person struct {
-	Name string
+	Name    string
+	AddedAt time.Time // when the person joined the group
 	// Email, phone
 }
 
@@ -26,28 +30,163 @@ type Group struct {
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
 
-	graph            *graph `json:"-"`
-	people           map[string]*Person
-	expenses         map[int]*Expense
-	expenseIdCounter int
-	mu               sync.Mutex
+	graph              *graph `json:"-"`
+	people             map[string]*Person
+	expenses           map[int]*Expense
+	expenseIdCounter   int
+	coalesceEdges      bool
+	recurring          map[int]*RecurringExpense
+	recurringIdCounter int
+	// requireAllParticipate makes AddExpense reject splits that leave a member out
+	// without listing them in Excluded. See SetRequireAllParticipate.
+	requireAllParticipate bool
+	// nameCollisionPolicy decides what AddPerson does with a name that is already taken.
+	// The zero value behaves like NameCollisionReject.
+	nameCollisionPolicy NameCollisionPolicy
+	// homeCurrency is the currency expenses without one are in; empty means DefaultHomeCurrency.
+	homeCurrency string
+	// archived, archivedPayments and archivedTransfers are what SettleAllAndArchive closed
+	// out, oldest first.
+	archived          []Expense
+	archivedPayments  []Payment
+	archivedTransfers []LedgerEntry
+	// brackets are the income brackets for "income" splits, bracket -> weight; nil means
+	// defaultIncomeBrackets. memberBrackets is each member's bracket, by normalized name.
+	brackets       map[string]float64
+	memberBrackets map[string]string
+	mu             sync.Mutex
 }
 
 // ID is unique only within the graph
 // they take on values such as 1, 2, 3 etc.
 type Expense struct {
-	ID               int                `json:"id"`
-	TotalMicroCents  int64              `json:"total_micro_cents" binding:"required"`
-	PaidBy           string             `json:"paid_by" binding:"required"`
+	ID              int    `json:"id"`
+	TotalMicroCents int64  `json:"total_micro_cents" binding:"required"`
+	PaidBy          string `json:"paid_by" binding:"required"`
+
+	// DiscountMicroCents is a coupon or discount taken off the total. TotalMicroCents stays the
+	// pre-discount amount; the net actually paid is NetMicroCents. The discount is spread over
+	// the participants in proportion to their shares, so ResolvedShares are post-discount.
+	DiscountMicroCents int64 `json:"discount_micro_cents,omitempty"`
+
+	// PayerPersonalMicroCents is part of the total that was for the payer alone, e.g. their
+	// own item on a shared bill. Only the rest is split by SplitMethod; the personal portion
+	// goes onto the payer's share, so it creates no debt.
+	PayerPersonalMicroCents int64 `json:"payer_personal_micro_cents,omitempty"`
+
+	// CoveredPercent is the percentage of the total borne by CoveredBy, someone outside the
+	// group such as "Company" ("the company covers 80%"). Like the payer's personal portion,
+	// the covered part goes onto the payer's share and creates no debt; only the rest is
+	// split among the members.
+	CoveredPercent float64 `json:"covered_percent,omitempty"`
+	CoveredBy      string  `json:"covered_by,omitempty"`
+
+	// TipMicroCents is the part of the total that was tip. With TipSplitWeights, the tip is
+	// split by those weights (e.g. by how much each person felt the service mattered) and
+	// only the rest of the bill by SplitMethod; without them it is split with the bill.
+	TipMicroCents   int64              `json:"tip_micro_cents,omitempty"`
+	TipSplitWeights map[string]float64 `json:"tip_split_weights,omitempty"`
+
+	// Currency is the ISO 4217 code the expense was paid in. Empty means the group's home
+	// currency. An expense in another currency needs ExchangeRate, the value of one unit of
+	// it in the home currency: AddExpense converts every amount into the home currency with
+	// it, so balances never mix currencies. OriginalMicroCents keeps the total as entered.
+	Currency           string  `json:"currency,omitempty"`
+	ExchangeRate       float64 `json:"exchange_rate,omitempty"`
+	OriginalMicroCents int64   `json:"original_micro_cents,omitempty"`
+
+	// PaidByMap lets several people pay for one expense (person -> dollars paid), as an
+	// alternative to PaidBy. The amounts must add up to the net (post-discount) total.
+	// When set, AddExpense fills PaidBy with the biggest payer for display purposes.
+	PaidByMap map[string]float64 `json:"paid_by_map,omitempty"`
+
 	Description      string             `json:"description" binding:"required"`
 	SplitMethod      string             `json:"split_type" binding:"required"`
 	SplitPercentages map[string]float64 `json:"split_percentages"`
 	SplitWeights     map[string]float64 `json:"split_weights"`
+
+	// SplitHeadcount is how many people each person stands for in a "headcount" split,
+	// e.g. 3 for someone who brought two kids. Shares are per head.
+	SplitHeadcount map[string]int `json:"split_headcount,omitempty"`
+
+	// SplitExactMicroCents is what each person owes in an "exact" split, e.g. from an
+	// itemized receipt. The amounts must add up to the shared part of the total: the total
+	// less any tip split by TipSplitWeights, payer's personal portion or covered portion.
+	SplitExactMicroCents map[string]int64 `json:"split_exact_micro_cents,omitempty"`
+
+	// PeriodStart and PeriodEnd bound the period a "duration" split covers, e.g. a monthly
+	// subscription. Each member's share is proportional to how long they were in the group
+	// during the period. PeriodEnd is exclusive: a stay through the 3rd ends at midnight
+	// on the 4th.
+	PeriodStart time.Time `json:"period_start,omitzero"`
+	PeriodEnd   time.Time `json:"period_end,omitzero"`
+
+	// SettledParticipants lists participants who paid their share to the payer on the spot.
+	// The expense still records their share, but no debt edge is created for them.
+	SettledParticipants []string `json:"settled_participants,omitempty"`
+
+	// Excluded lists members who take no part in the expense, whatever the split method:
+	// equal and duration splits leave them out, and percentage and weights splits must
+	// not give them a positive share.
+	Excluded []string `json:"excluded,omitempty"`
+
+	// ParticipantNotes holds a short note per participant, keyed by display name, e.g.
+	// "Bob": "had the steak". They explain uneven shares and never affect the math.
+	ParticipantNotes map[string]string `json:"participant_notes,omitempty"`
+
+	// Labels are free-form lowercase tags such as "business" or "reimbursable"; an expense
+	// can carry several. See ExpensesWithLabel.
+	Labels []string `json:"labels,omitempty"`
+
+	// Event is the sub-event of a trip the expense belongs to, e.g. "Day 1 dinner" or
+	// "concert". It only organizes reports. See ExpensesByEvent.
+	Event string `json:"event,omitempty"`
+
+	// EnteredBy is who added the expense, e.g. the user ID of the MCP caller, or "unknown"
+	// when the caller didn't identify themselves. It is for accountability only.
+	EnteredBy string `json:"entered_by,omitempty"`
+
+	// RecurringID is the recurring expense this expense was materialized from, if any.
+	RecurringID int `json:"recurring_id,omitempty"`
+
+	// CreatedAt is when the expense was added; AddExpense sets it unless provided.
+	CreatedAt time.Time `json:"created_at"`
+
+	// RemainderMicroCents is how many micro cents each participant's share was rounded up
+	// by when the total didn't divide evenly. See RoundingFairnessReport.
+	RemainderMicroCents map[string]int64 `json:"remainder_micro_cents,omitempty"`
+
+	// ResolvedShares is each participant's share in micro cents (payer included), keyed by
+	// normalized name. It is computed by AddExpense from the split method.
+	ResolvedShares map[string]int64 `json:"resolved_shares"`
 }
 
+// EdgeMetadata is the payload of every graph edge.
+// ExpenseID is PaymentExpenseID for edges recorded by RecordPayment and
+// TransferExpenseID for edges recorded by TransferDebt and ForgiveAllDebts.
+//
+// A coalesced edge (see SetEdgeCoalescing) carries the summed amount of several expenses:
+// its ExpenseID is 0 and ExpenseIDs lists the contributing expenses.
 type EdgeMetadata struct {
 	AmountInMicroCents int64
 	ExpenseID          int
+	ExpenseIDs         []int
+	Memo               string // optional free text, e.g. "Venmo on 3/5"
+}
+
+// edgeMetadata returns the edge's EdgeMetadata. Edges carrying anything else are
+// logged and reported as not ok so callers can skip them instead of panicking.
+func edgeMetadata(e *edge) (EdgeMetadata, bool) {
+	switch m := e.Metadata.(type) {
+	case EdgeMetadata:
+		return m, true
+	case *EdgeMetadata:
+		if m != nil {
+			return *m, true
+		}
+	}
+	slog.Error("unexpected edge metadata type", "to", e.To, "type", fmt.Sprintf("%T", e.Metadata))
+	return EdgeMetadata{}, false
 }
 
 // NewGroup creates a new group and returns it
@@ -71,31 +210,48 @@ func NewGroup(name string) (*Group, error) {
 
 // AddPerson adds a person to the group
 func (g *Group) AddPerson(name string) error {
+	_, err := g.AddMember(name)
+	return err
+}
+
+// AddMember adds a person to the group and returns them as added. The display name may
+// differ from name when the group's NameCollisionPolicy auto-suffixes duplicates.
+func (g *Group) AddMember(name string) (Person, error) {
 	// validate name
 	displayName := strings.TrimSpace(name)
-	if !personNamePattern.MatchString(displayName) {
-		return fmt.Errorf("person name must start with a letter, match %q, and be [1, 32] chars long", personNamePattern.String())
-	}
-	key := normalizeName(displayName)
-
-	p := &Person{
-		Name: displayName,
+	base, disambiguator := splitDisambiguator(displayName)
+	if !personNamePattern.MatchString(base) {
+		return Person{}, fmt.Errorf("person name must start with a letter, match %q, and be [1, 32] chars long", personNamePattern.String())
 	}
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	// validate if person already exists
-	if existing, exists := g.people[key]; exists {
-		slog.Error("person already in the group", "person", existing.Name, "group", g.Name)
-		return fmt.Errorf("person(%s) already exists in group(%s)", existing.Name, g.Name)
+	if disambiguator != "" {
+		if g.nameCollisionPolicy != NameCollisionDisambiguate {
+			return Person{}, fmt.Errorf("person name(%s) can only carry a disambiguator when the name collision policy is %s", displayName, NameCollisionDisambiguate)
+		}
+		if !disambiguatorPattern.MatchString(disambiguator) {
+			return Person{}, fmt.Errorf("disambiguator must start with a letter or digit, match %q, and be [1, 16] chars long", disambiguatorPattern.String())
+		}
+	}
+
+	displayName, err := g.resolveNameCollision(displayName, base, disambiguator)
+	if err != nil {
+		slog.Error("person already in the group", "person", displayName, "group", g.Name, "error", err.Error())
+		return Person{}, err
 	}
+	key := normalizeName(displayName)
 
+	p := &Person{
+		Name:    displayName,
+		AddedAt: time.Now(),
+	}
 	if err := g.graph.addNode(key); err != nil {
-		return err
+		return Person{}, err
 	}
 	g.people[key] = p
-	return nil
+	return *p, nil
 }
 
 // Size returns the number of people in the group
@@ -106,14 +262,104 @@ func (g *Group) Size() int {
 	return len(g.people)
 }
 
+// ExpenseCount returns the number of expenses recorded in the group.
+func (g *Group) ExpenseCount() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.expenses)
+}
+
+// WouldAffectExistingExpenses reports whether newPerson joining now would leave them out
+// of expenses already recorded. Splits are fixed when an expense is added, so a new member
+// never takes a share of earlier expenses, even equal ones.
+func (g *Group) WouldAffectExistingExpenses(newPerson string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, exists := g.people[normalizeName(newPerson)]; exists {
+		return false
+	}
+	return len(g.expenses) > 0
+}
+
+// CanAddExpense reports whether an expense can be added to the group.
+// When it can't, the returned reason tells the user what to do first.
+func (g *Group) CanAddExpense() (bool, string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.canAddExpense()
+}
+
+// canAddExpense is the lock-free variant of CanAddExpense.
+// Caller must hold the group lock.
+func (g *Group) canAddExpense() (bool, string) {
+	switch len(g.people) {
+	case 0:
+		return false, fmt.Sprintf("group(%s) has no people yet; add at least two people before adding an expense", g.Name)
+	case 1:
+		return false, fmt.Sprintf("group(%s) has only one person; add at least one more person first", g.Name)
+	}
+	return true, ""
+}
+
 // AddExpense adds an expense to the group.
 // It may result in creating several edges between the nodes of an internal graph
 func (g *Group) AddExpense(e *Expense) error {
-	// validate fields that dont' require lock
+	if err := validateExpenseFields(e); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.prepareNewExpense(e); err != nil {
+		return err
+	}
+	return g.storeExpense(e)
+}
+
+// prepareNewExpense makes the checks a new expense goes through, whether entered or
+// materialized from a recurring expense, and resolves its shares: one payer or several,
+// conversion into the home currency and the participation policy. Edits re-run
+// resolveExpense only. Caller must hold the group lock.
+func (g *Group) prepareNewExpense(e *Expense) error {
+	if strings.TrimSpace(e.PaidBy) != "" && len(e.PaidByMap) > 0 {
+		// PaidBy is filled from PaidByMap once resolved, so only new expenses are checked
+		return fmt.Errorf("paid_by and paid_by_map are mutually exclusive: give one payer or several")
+	}
+	if err := g.convertToHome(e); err != nil {
+		return err
+	}
+	if err := g.resolveExpense(e); err != nil {
+		return err
+	}
+	return g.checkAllParticipate(e)
+}
+
+// validateExpenseFields checks the fields of e that don't depend on the group.
+func validateExpenseFields(e *Expense) error {
 	if e.TotalMicroCents <= 0 {
 		slog.Error("expense TotalMicroCents cannot be negative", "total_micro_cents", e.TotalMicroCents)
 		return fmt.Errorf("expense TotalMicroCents(%d) cannot be 0 or negative", e.TotalMicroCents)
 	}
+	if e.PayerPersonalMicroCents < 0 || e.PayerPersonalMicroCents >= e.TotalMicroCents {
+		slog.Error("expense payer personal portion out of range", "payer_personal_micro_cents", e.PayerPersonalMicroCents, "total_micro_cents", e.TotalMicroCents)
+		return fmt.Errorf("payer's personal portion(%d) must be at least 0 and less than the total(%d)", e.PayerPersonalMicroCents, e.TotalMicroCents)
+	}
+	if e.DiscountMicroCents < 0 || e.DiscountMicroCents > e.TotalMicroCents {
+		slog.Error("expense discount out of range", "discount_micro_cents", e.DiscountMicroCents, "total_micro_cents", e.TotalMicroCents)
+		return fmt.Errorf("expense discount(%d) must be between 0 and the total(%d)", e.DiscountMicroCents, e.TotalMicroCents)
+	}
+	if e.Currency != "" {
+		code, err := normalizeCurrency(e.Currency)
+		if err != nil {
+			slog.Error("expense currency validation failed", "currency", e.Currency)
+			return err
+		}
+		e.Currency = code
+	}
 	e.Description = strings.TrimSpace(e.Description)
 	if e.Description == "" {
 		slog.Error("expense description cannot be empty")
@@ -123,20 +369,36 @@ func (g *Group) AddExpense(e *Expense) error {
 		slog.Error("split method validation failed", "split_method", e.SplitMethod)
 		return err
 	}
+	if err := validateCoverage(e); err != nil {
+		return err
+	}
+	if err := validateTip(e); err != nil {
+		return err
+	}
+	labels, err := normalizeLabels(e.Labels)
+	if err != nil {
+		return err
+	}
+	e.Labels = labels
+	e.Event = strings.TrimSpace(e.Event)
+	if utf8.RuneCountInString(e.Event) > maxEventLength {
+		slog.Error("expense event validation failed", "length", utf8.RuneCountInString(e.Event))
+		return fmt.Errorf("expense event must be at most %d characters", maxEventLength)
+	}
+	e.EnteredBy = strings.TrimSpace(e.EnteredBy)
+	return nil
+}
 
-	g.mu.Lock()
-	defer g.mu.Unlock()
-
-	// validate fields that require lock
-	if len(g.people) <= 1 {
+// resolveExpense validates e against the group's members and fills in the payers and
+// ResolvedShares. Nothing is stored.
+// Caller must hold the group lock.
+func (g *Group) resolveExpense(e *Expense) error {
+	if ok, reason := g.canAddExpense(); !ok {
 		slog.Error("group must contain atleast 2 people to add an expense", "group", g.Name, "size", len(g.people))
-		return fmt.Errorf("group(%s) must contain atleast 2 people to add an expense, current size=%d", g.Name, len(g.people))
+		return errors.New(reason)
 	}
-	paidByKey := normalizeName(e.PaidBy)
-	to, exists := g.people[paidByKey]
-	if !exists {
-		slog.Error("expense PaidBy person not in the group", "paid_by", e.PaidBy, "group", g.Name)
-		return fmt.Errorf("expense PaidBy person(%s) must be in the group(%s)", e.PaidBy, g.Name)
+	if err := g.resolvePayers(e); err != nil {
+		return err
 	}
 
 	normalizedPercentages, err := normalizeSplitMap(e.SplitPercentages)
@@ -161,28 +423,100 @@ func (g *Group) AddExpense(e *Expense) error {
 		}
 	}
 
+	normalizedHeadcount, err := g.normalizeHeadcount(e.SplitHeadcount)
+	if err != nil {
+		return err
+	}
+	if e.SplitMethod != "headcount" && len(normalizedHeadcount) > 0 {
+		return fmt.Errorf("split method %s does not take a split_headcount", e.SplitMethod)
+	}
+
+	normalizedExact, err := g.normalizeExactAmounts(e.SplitExactMicroCents)
+	if err != nil {
+		return err
+	}
+	if e.SplitMethod != "exact" && len(normalizedExact) > 0 {
+		return fmt.Errorf("split method %s does not take a split_exact", e.SplitMethod)
+	}
+
+	splitMap := map[string]float64{}
+	switch e.SplitMethod {
+	case "percentage":
+		splitMap = normalizedPercentages
+	case "weights":
+		splitMap = normalizedWeights
+	}
+	isMember := func(key string) bool {
+		_, exists := g.people[key]
+		return exists
+	}
+	if err := validateSplitMap(e.SplitMethod, splitMap, isMember); err != nil {
+		slog.Error("expense split map validation failed", "group", g.Name, "split_method", e.SplitMethod, "error", err.Error())
+		return err
+	}
+
+	excluded := make(map[string]bool, len(e.Excluded))
+	for i, name := range e.Excluded {
+		key := normalizeName(name)
+		if _, exists := g.people[key]; !exists {
+			slog.Error("expense excluded validation failed, name not in the group", "name", name, "group", g.Name)
+			return fmt.Errorf("excluded person(%s) not found in group(%s)", strings.TrimSpace(name), g.Name)
+		}
+		if splitMap[key] > 0 || normalizedHeadcount[key] > 0 || normalizedExact[key] > 0 {
+			return fmt.Errorf("person(%s) is excluded but has a positive share in the split map", g.displayName(key))
+		}
+		excluded[key] = true
+		e.Excluded[i] = g.displayName(key)
+	}
+
 	// names can be formed using graph or g.people
 	names := []string{}
 	for key := range g.people {
-		names = append(names, key)
+		if !excluded[key] {
+			names = append(names, key)
+		}
 	}
 
-	e.PaidBy = to.Name
 	e.SplitPercentages = normalizedPercentages
 	e.SplitWeights = normalizedWeights
+	e.SplitHeadcount = normalizedHeadcount
+	e.SplitExactMicroCents = normalizedExact
+	if err := g.resolveTipWeights(e, excluded); err != nil {
+		return err
+	}
+
+	if e.PayerPersonalMicroCents > 0 && len(e.PaidByMap) > 0 {
+		return fmt.Errorf("a payer's personal portion is not supported for expenses with several payers")
+	}
+	if e.CoveredPercent > 0 && len(e.PaidByMap) > 0 {
+		return fmt.Errorf("a covered portion is not supported for expenses with several payers")
+	}
+	// the payer's personal portion is theirs alone and the covered portion is borne
+	// outside the group; only the rest is split
+	covered := e.CoveredMicroCents()
+	splitTotal := e.sharedMicroCents()
+	// a tip with its own weights is split apart from the bill
+	tip := int64(0)
+	if len(e.TipSplitWeights) > 0 {
+		tip = e.TipMicroCents
+	}
+	if splitTotal <= 0 {
+		return fmt.Errorf("payer's personal portion, covered portion and tip leave nothing to split")
+	}
 
 	var shares map[string]int64
+	var durations, incomes map[string]float64
 	switch e.SplitMethod {
 	case "equal":
 		var err error
-		shares, err = splitEqual(e.TotalMicroCents, names)
+		shares, err = splitEqual(splitTotal, names)
 		if err != nil {
 			slog.Error("error while splitting equally", "group", g.Name, "error", err.Error())
 			return err
 		}
 	case "percentage":
 		var err error
-		shares, err = splitByPercent(e.TotalMicroCents, e.SplitPercentages)
+		shares, err = splitByPercent(splitTotal, e.SplitPercentages)
 		if err != nil {
 			slog.Error("error while splitting by percent", "group", g.Name, slog.Any("split_percentages", e.SplitPercentages),
 				"error", err.Error())
@@ -190,14 +524,147 @@ func (g *Group) AddExpense(e *Expense) error {
 		}
 	case "weights":
 		var err error
-		shares, err = splitByWeights(e.TotalMicroCents, e.SplitWeights)
+		shares, err = splitByWeights(splitTotal, e.SplitWeights)
 		if err != nil {
 			slog.Error("error while splitting by weights", "group", g.Name, slog.Any("split_weignts", e.SplitWeights),
 				"error", err.Error())
 			return err
 		}
+	case "headcount":
+		var err error
+		shares, err = splitByHeadcount(splitTotal, e.SplitHeadcount)
+		if err != nil {
+			slog.Error("error while splitting by headcount", "group", g.Name, slog.Any("split_headcount", e.SplitHeadcount),
+				"error", err.Error())
+			return err
+		}
+	case "exact":
+		var err error
+		shares, err = splitExact(splitTotal, e.SplitExactMicroCents)
+		if err != nil {
+			slog.Error("error while splitting by exact amounts", "group", g.Name, slog.Any("split_exact", e.SplitExactMicroCents),
+				"error", err.Error())
+			return err
+		}
+	case "duration":
+		var err error
+		durations, err = g.durationWeights(e.PeriodStart, e.PeriodEnd, excluded)
+		if err == nil {
+			shares, err = splitByWeights(splitTotal, durations)
+		}
+		if err != nil {
+			slog.Error("error while splitting by duration", "group", g.Name, "period_start", e.PeriodStart,
+				"period_end", e.PeriodEnd, "error", err.Error())
+			return err
+		}
+	case "income":
+		var err error
+		incomes, err = g.incomeWeights(excluded)
+		if err == nil {
+			shares, err = splitByWeights(splitTotal, incomes)
+		}
+		if err != nil {
+			slog.Error("error while splitting by income", "group", g.Name, "error", err.Error())
+			return err
+		}
+	}
+
+	if len(e.SettledParticipants) > 0 && len(e.PaidByMap) > 0 {
+		return fmt.Errorf("settled participants are not supported for expenses with several payers")
+	}
+	settled := make(map[string]bool, len(e.SettledParticipants))
+	for i, name := range e.SettledParticipants {
+		key := normalizeName(name)
+		if settled[key] {
+			return fmt.Errorf("duplicate name in settled_participants after normalization: %q", name)
+		}
+		settled[key] = true
+		if shares[key] <= 0 {
+			slog.Error("expense settled_participants validation failed, name is not a participant", "name", name, "group", g.Name)
+			return fmt.Errorf("settled participant(%s) is not a participant of the expense", name)
+		}
+		e.SettledParticipants[i] = g.displayName(key)
+	}
+	notes, err := g.resolveParticipantNotes(e.ParticipantNotes, shares)
+	if err != nil {
+		return err
+	}
+	e.ParticipantNotes = notes
+	e.ResolvedShares = shares
+
+	switch e.SplitMethod {
+	case "equal":
+		e.RemainderMicroCents = remainders(shares, func(string) int64 { return splitTotal / int64(len(shares)) })
+	case "percentage":
+		e.RemainderMicroCents = remainders(shares, proportionalFloor(splitTotal, e.SplitPercentages, 100))
+	case "weights":
+		e.RemainderMicroCents = remainders(shares, proportionalFloor(splitTotal, e.SplitWeights, sumValues(e.SplitWeights)))
+	case "headcount":
+		counts := headcountWeights(e.SplitHeadcount)
+		e.RemainderMicroCents = remainders(shares, proportionalFloor(splitTotal, counts, sumValues(counts)))
+	case "duration":
+		e.RemainderMicroCents = remainders(shares, proportionalFloor(splitTotal, durations, sumValues(durations)))
+	case "income":
+		e.RemainderMicroCents = remainders(shares, proportionalFloor(splitTotal, incomes, sumValues(incomes)))
+	case "exact":
+		// the amounts were given, nothing was rounded; a tip below may still add remainders
+		e.RemainderMicroCents = map[string]int64{}
+	}
+	if tip > 0 {
+		tipShares, err := splitByWeights(tip, e.TipSplitWeights)
+		if err != nil {
+			slog.Error("error while splitting the tip by weights", "group", g.Name, slog.Any("tip_split_weights", e.TipSplitWeights),
+				"error", err.Error())
+			return err
+		}
+		tipRemainders := remainders(tipShares, proportionalFloor(tip, e.TipSplitWeights, sumValues(e.TipSplitWeights)))
+		for key, share := range tipShares {
+			shares[key] += share
+			if extra := tipRemainders[key]; extra > 0 {
+				e.RemainderMicroCents[key] += extra
+			}
+		}
+	}
+	if e.PayerPersonalMicroCents > 0 || covered > 0 {
+		shares[normalizeName(e.PaidBy)] += e.PayerPersonalMicroCents + covered
+	}
+
+	if e.DiscountMicroCents > 0 {
+		// spread the discount in proportion to the shares, largest remainder first
+		weights := make(map[string]float64, len(shares))
+		for key, share := range shares {
+			weights[key] = float64(share)
+		}
+		discounts, err := splitByWeights(e.DiscountMicroCents, weights)
+		if err != nil {
+			return err
+		}
+		for key, d := range discounts {
+			shares[key] -= d
+		}
+	}
+	return nil
+}
+
+// sharedMicroCents is the part of the total split by SplitMethod: the total less the payer's
+// personal portion, the covered portion and a tip with its own weights.
+func (e *Expense) sharedMicroCents() int64 {
+	shared := e.TotalMicroCents - e.PayerPersonalMicroCents - e.CoveredMicroCents()
+	if len(e.TipSplitWeights) > 0 {
+		shared -= e.TipMicroCents
 	}
+	return shared
+}
+
+// NetMicroCents is what was actually paid for the expense: the total minus the discount.
+func (e *Expense) NetMicroCents() int64 {
+	return e.TotalMicroCents - e.DiscountMicroCents
+}
 
+// storeExpense assigns e the next ID and adds it and its debt edges to the group.
+// e must have been resolved by resolveExpense.
+// Caller must hold the group lock.
+func (g *Group) storeExpense(e *Expense) error {
 	if len(g.people) != len(g.graph.nodes) {
 		return fmt.Errorf("group(%s) graph/people out of sync", g.Name)
 	}
@@ -214,29 +681,156 @@ func (g *Group) AddExpense(e *Expense) error {
 
 	g.expenseIdCounter++
 	e.ID = g.expenseIdCounter
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
 	g.expenses[e.ID] = e
 
-	// add edges
-	for fromKey, from := range g.people {
-		if fromKey == paidByKey {
-			// skip this
-			continue
-		}
-		if owed, exists := shares[fromKey]; exists {
-			slog.Debug("AddExpense", "split_method", e.SplitMethod, "from", from.Name, "to", to.Name, "owed_in_micro_cents", owed)
-			metadata := EdgeMetadata{
-				AmountInMicroCents: owed,
-				ExpenseID:          e.ID,
-			}
-			if err := g.graph.addEdge(fromKey, paidByKey, metadata); err != nil {
+	return addExpenseEdges(g.graph, e, g.coalesceEdges)
+}
+
+// addExpenseEdges adds the debt edges of an expense to the graph, merging them into
+// existing edges when coalesce is set.
+// Caller must hold the group lock.
+func addExpenseEdges(gr *graph, e *Expense, coalesce bool) error {
+	for _, d := range expenseDebts(e) {
+		slog.Debug("addExpenseEdges", "split_method", e.SplitMethod, "expense_id", e.ID, "owed_in_micro_cents", d.amount)
+		if coalesce {
+			if err := gr.coalesceEdge(d, e.ID, e.CreatedAt); err != nil {
 				return err
 			}
+			continue
 		}
+		metadata := EdgeMetadata{
+			AmountInMicroCents: d.amount,
+			ExpenseID:          e.ID,
+		}
+		if err := gr.addEdgeAt(d.from, d.to, metadata, e.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePayers validates who paid for the expense and normalizes PaidBy/PaidByMap.
+// Caller must hold the group lock.
+func (g *Group) resolvePayers(e *Expense) error {
+	if len(e.PaidByMap) == 0 {
+		paidByKey := normalizeName(e.PaidBy)
+		payer, exists := g.people[paidByKey]
+		if !exists {
+			slog.Error("expense PaidBy person not in the group", "paid_by", e.PaidBy, "group", g.Name)
+			return fmt.Errorf("expense PaidBy person(%s) must be in the group(%s)", e.PaidBy, g.Name)
+		}
+		e.PaidBy = payer.Name
+		return nil
 	}
 
+	normalizedPaidBy, err := normalizeSplitMap(e.PaidByMap)
+	if err != nil {
+		return err
+	}
+	sum := int64(0)
+	primary := ""
+	for name, dollars := range normalizedPaidBy {
+		if _, exists := g.people[name]; !exists {
+			slog.Error("expense paid_by_map validation failed, name not in the group", "name", name, "group", g.Name)
+			return fmt.Errorf("expense paid_by_map validation failed, name(%s) not in the group(%s)", name, g.Name)
+		}
+		if dollars < 0 {
+			return fmt.Errorf("expense paid_by_map amounts must be >= 0, got %v for %s", dollars, name)
+		}
+		if dollars == 0 {
+			delete(normalizedPaidBy, name)
+			continue
+		}
+		sum += dollarsToMicroCents(dollars)
+		if primary == "" || dollars > normalizedPaidBy[primary] || (dollars == normalizedPaidBy[primary] && name < primary) {
+			primary = name
+		}
+	}
+	if sum != e.NetMicroCents() {
+		slog.Error("expense paid_by_map validation failed, amounts don't add up", "sum", sum, "total", e.NetMicroCents())
+		return fmt.Errorf("expense paid_by_map amounts must add up to the total %s, got %s",
+			formatMicroCentsAsDollars(e.NetMicroCents()), formatMicroCentsAsDollars(sum))
+	}
+	e.PaidByMap = normalizedPaidBy
+	e.PaidBy = g.displayName(primary)
 	return nil
 }
 
+// paidShares returns how much each payer paid towards the expense in micro cents,
+// keyed by normalized name.
+func (e *Expense) paidShares() map[string]int64 {
+	if len(e.PaidByMap) == 0 {
+		return map[string]int64{normalizeName(e.PaidBy): e.NetMicroCents()}
+	}
+	paid := make(map[string]int64, len(e.PaidByMap))
+	for name, dollars := range e.PaidByMap {
+		paid[normalizeName(name)] = dollarsToMicroCents(dollars)
+	}
+	return paid
+}
+
+// namedKeys returns the normalized names e refers to: the payers, the keys of the split
+// and tip maps, and the settled, excluded and annotated participants. Members taking part
+// in an equal split over everyone aren't named. Keys can repeat.
+func (e *Expense) namedKeys() []string {
+	keys := []string{}
+	if e.PaidBy != "" {
+		keys = append(keys, normalizeName(e.PaidBy))
+	}
+	for _, m := range []map[string]float64{e.PaidByMap, e.SplitPercentages, e.SplitWeights, e.TipSplitWeights} {
+		for name := range m {
+			keys = append(keys, normalizeName(name))
+		}
+	}
+	for name := range e.SplitHeadcount {
+		keys = append(keys, normalizeName(name))
+	}
+	for name := range e.SplitExactMicroCents {
+		keys = append(keys, normalizeName(name))
+	}
+	for name := range e.ParticipantNotes {
+		keys = append(keys, normalizeName(name))
+	}
+	for _, name := range slices.Concat(e.SettledParticipants, e.Excluded) {
+		keys = append(keys, normalizeName(name))
+	}
+	return keys
+}
+
+// debt is an amount "from" owes "to" in micro cents, keyed by normalized names.
+type debt struct {
+	from   string
+	to     string
+	amount int64
+}
+
+// expenseDebts returns the debts an expense creates: each participant's share is
+// netted against what they paid, and whoever is short pays whoever is over.
+// Settled participants already handed their share to the payer, so they owe nothing.
+func expenseDebts(e *Expense) []debt {
+	balances := map[string]int64{}
+	for key, amount := range e.paidShares() {
+		balances[key] += amount
+	}
+	for key, share := range e.ResolvedShares {
+		balances[key] -= share
+	}
+	payerKey := normalizeName(e.PaidBy)
+	for _, name := range e.SettledParticipants {
+		key := normalizeName(name)
+		balances[key] += e.ResolvedShares[key]
+		balances[payerKey] -= e.ResolvedShares[key]
+	}
+	return matchDebts(balances)
+}
+
+func dollarsToMicroCents(dollars float64) int64 {
+	return int64(math.Round(dollars*100)) * 1000
+}
+
 func (g *Group) GetExpenseDetails() map[string]float64 {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -296,7 +890,10 @@ func (g *Group) GetGraphDOT() string {
 	edgeSums := make(map[edgeKey]int64)
 	for from, edges := range g.graph.nodes {
 		for _, edge := range edges {
-			edgeInfo := edge.Metadata.(EdgeMetadata)
+			edgeInfo, ok := edgeMetadata(edge)
+			if !ok {
+				continue
+			}
 			edgeSums[edgeKey{from: from, to: edge.To}] += edgeInfo.AmountInMicroCents
 		}
 	}
@@ -483,6 +1080,38 @@ func splitByWeights(totalMicroCents int64, w map[string]float64) (map[string]int
 	return shares, nil
 }
 
+// durationWeights weighs each member by how many days they were part of the group
+// between start and end, for splitting by duration. Members who joined after the period
+// ended are left out, as are the excluded ones.
+// Caller must hold the group lock.
+func (g *Group) durationWeights(start, end time.Time, excluded map[string]bool) (map[string]float64, error) {
+	if start.IsZero() || end.IsZero() {
+		return nil, fmt.Errorf("duration split requires a period start and end")
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("duration split period end(%s) must be after its start(%s)",
+			end.Format(time.DateOnly), start.Format(time.DateOnly))
+	}
+
+	weights := map[string]float64{}
+	for key, p := range g.people {
+		if excluded[key] {
+			continue
+		}
+		from := start
+		if p.AddedAt.After(from) {
+			from = p.AddedAt
+		}
+		if overlap := end.Sub(from); overlap > 0 {
+			weights[key] = overlap.Hours() / 24
+		}
+	}
+	if len(weights) < 2 {
+		return nil, fmt.Errorf("duration split needs at least 2 people in the group during the period, got %d", len(weights))
+	}
+	return weights, nil
+}
+
 // getMoneyToBePaid returns money to be paid by "from" to "to" in dollars
 // The function does not do locking. The callers must ensure to lock group level mutex.
 func (g *Group) getMoneyTobePaid(from, to string) float64 {
@@ -490,7 +1119,10 @@ func (g *Group) getMoneyTobePaid(from, to string) float64 {
 	sum := int64(0)
 	for _, edge := range g.graph.nodes[from] {
 		if edge.To == to {
-			edgeInfo := edge.Metadata.(EdgeMetadata)
+			edgeInfo, ok := edgeMetadata(edge)
+			if !ok {
+				continue
+			}
 			slog.Debug("getMoneyTobePaid sum1:", "from", from, "to", to, slog.Any("edgeMetadata", edgeInfo))
 			sum += edgeInfo.AmountInMicroCents
 		}
@@ -500,25 +1132,35 @@ func (g *Group) getMoneyTobePaid(from, to string) float64 {
 	sum2 := int64(0)
 	for _, edge := range g.graph.nodes[to] {
 		if edge.To == from {
-			edgeInfo := edge.Metadata.(EdgeMetadata)
+			edgeInfo, ok := edgeMetadata(edge)
+			if !ok {
+				continue
+			}
 			slog.Debug("getMoneyTobePaid sum2:", "from", from, "to", to, slog.Any("expense", edgeInfo))
 			sum2 += edgeInfo.AmountInMicroCents
 		}
 	}
-	// return the amount in dollars
-	cents := float64(sum-sum2) / 1000.0
+	return microCentsToPayable(sum - sum2)
+}
+
+// microCentsToPayable converts a net debt in micro cents to dollars, dropping amounts
+// below a cent.
+func microCentsToPayable(net int64) float64 {
+	cents := float64(net) / 1000.0
 	if cents < 1 {
 		cents = 0
 	}
 	return cents / 100.0
 }
 
+// splitMethods are the supported values of Expense.SplitMethod.
+var splitMethods = []string{"equal", "percentage", "weights", "duration", "headcount", "income", "exact"}
+
 func validateSplitMethod(splitMethod string) error {
-	validValues := []string{"equal", "percentage", "weights"}
-	for _, v := range validValues {
+	for _, v := range splitMethods {
 		if v == splitMethod {
 			return nil
 		}
 	}
-	return fmt.Errorf("split method must be one of equal|percentage|weights")
+	return fmt.Errorf("split method must be one of %s", strings.Join(splitMethods, "|"))
 }
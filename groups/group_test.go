@@ -1,8 +1,29 @@
 package groups
 
-import "testing"
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
 
 func TestExpenseSplitByPercentage(t *testing.T) {
+	keepStore(t)
 	groupName := "sf-trip"
 	group, err := Create(groupName)
 	if err != nil {
@@ -29,6 +50,7 @@ func TestExpenseSplitByPercentage(t *testing.T) {
 }
 
 func TestExpenseSplitByWeights(t *testing.T) {
+	keepStore(t)
 	groupName := "napa-trip"
 	group, err := Create(groupName)
 	if err != nil {
@@ -53,3 +75,4781 @@ func TestExpenseSplitByWeights(t *testing.T) {
 
 	t.Log(group.GetExpenseDetails())
 }
+
+func TestCanAddExpenseSinglePerson(t *testing.T) {
+	group, err := NewGroup("solo-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := group.AddPerson("Alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, reason := group.CanAddExpense()
+	if ok {
+		t.Fatal("expected a single-person group to reject expenses")
+	}
+	if !strings.Contains(reason, "add at least one more person") {
+		t.Fatalf("unexpected reason: %q", reason)
+	}
+
+	err = group.AddExpense(&Expense{
+		PaidBy:          "Alice",
+		TotalMicroCents: 10 * 100 * 1000,
+		Description:     "coffee",
+		SplitMethod:     "equal",
+	})
+	if err == nil || err.Error() != reason {
+		t.Fatalf("expected AddExpense to fail with %q, got %v", reason, err)
+	}
+
+	if err := group.AddPerson("Bob"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := group.CanAddExpense(); !ok {
+		t.Fatal("expected a two-person group to accept expenses")
+	}
+}
+
+func TestExpenseSettledParticipants(t *testing.T) {
+	group, err := NewGroup("tahoe-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = group.AddExpense(&Expense{
+		PaidBy:              "Alice",
+		TotalMicroCents:     100 * 100 * 1000,
+		Description:         "cabin cleaning",
+		SplitMethod:         "equal",
+		SettledParticipants: []string{"bob", "Charlie"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	details := group.GetExpenseDetails()
+	want := map[string]float64{"Dave to pay Alice": 25}
+	if len(details) != len(want) {
+		t.Fatalf("expected %v, got %v", want, details)
+	}
+	for k, v := range want {
+		if details[k] != v {
+			t.Fatalf("expected %s=%v, got %v", k, v, details)
+		}
+	}
+
+	err = group.AddExpense(&Expense{
+		PaidBy:              "Alice",
+		TotalMicroCents:     100 * 100 * 1000,
+		Description:         "firewood",
+		SplitMethod:         "weights",
+		SplitWeights:        map[string]float64{"Alice": 1, "Bob": 1},
+		SettledParticipants: []string{"Dave"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a settled participant who is not part of the split")
+	}
+
+	err = group.AddExpense(&Expense{
+		PaidBy:              "Alice",
+		TotalMicroCents:     100 * 100 * 1000,
+		Description:         "firewood",
+		SplitMethod:         "weights",
+		SplitWeights:        map[string]float64{"Alice": 1, "Bob": 1},
+		SettledParticipants: []string{"Bob", "bob"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a settled participant listed twice")
+	}
+}
+
+func TestExportSplitwiseSharesSumToCost(t *testing.T) {
+	group, err := NewGroup("splitwise-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := group.SetHomeCurrency("eur"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "dinner", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 1001 * 1000, Description: "snacks", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1, "Bob": 1, "Charlie": 1}},
+		{PaidBy: "Charlie", TotalMicroCents: 45 * 100 * 1000, Description: "gas", SplitMethod: "equal",
+			SettledParticipants: []string{"Alice"}},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := group.ExportSplitwise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export SplitwiseExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Users) != 3 || len(export.Expenses) != 3 {
+		t.Fatalf("unexpected export: %s", data)
+	}
+
+	toCents := func(s string) int64 {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int64(math.Round(f * 100))
+	}
+	for _, e := range export.Expenses {
+		var paid, owed int64
+		for _, u := range e.Users {
+			paid += toCents(u.PaidShare)
+			owed += toCents(u.OwedShare)
+		}
+		if cost := toCents(e.Cost); paid != cost || owed != cost {
+			t.Fatalf("expense %q: cost=%d paid=%d owed=%d", e.Description, cost, paid, owed)
+		}
+		if e.CurrencyCode != "EUR" {
+			t.Errorf("expense %q: expected the home currency EUR, got %s", e.Description, e.CurrencyCode)
+		}
+	}
+
+	// a share of someone who isn't a member can't be exported without unbalancing the expense
+	group.mu.Lock()
+	dinner := group.expenses[1]
+	dinner.ResolvedShares["dave"] = dinner.ResolvedShares["charlie"]
+	delete(dinner.ResolvedShares, "charlie")
+	group.mu.Unlock()
+	if _, err := group.ExportSplitwise(); err == nil || !strings.Contains(err.Error(), "unknown person(dave)") {
+		t.Errorf("expected the non-member's share reported, got %v", err)
+	}
+}
+
+func TestSettleSubset(t *testing.T) {
+	group, err := NewGroup("ski-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave", "Eve"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		// Bob owes Alice $30, Charlie owes Alice $30
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "lift passes", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1, "Bob": 1, "Charlie": 1}},
+		// Alice owes Bob $10
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "coffee", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1, "Bob": 1}},
+		// Alice, Bob, Charlie and Eve owe Dave $20 each; Dave is not part of the subset
+		{PaidBy: "Dave", TotalMicroCents: 100 * 100 * 1000, Description: "cabin", SplitMethod: "equal"},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	settlements, err := group.SettleSubset([]string{"alice", "Bob", "Charlie"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Settlement{
+		{From: "Charlie", To: "Alice", AmountMicroCents: 30 * 100 * 1000},
+		{From: "Bob", To: "Alice", AmountMicroCents: 20 * 100 * 1000},
+	}
+	if len(settlements) != len(want) {
+		t.Fatalf("expected %v, got %v", want, settlements)
+	}
+	for i := range want {
+		if settlements[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, settlements)
+		}
+	}
+
+	if _, err := group.SettleSubset([]string{"Alice", "Mallory"}); err == nil {
+		t.Fatal("expected an error for a person outside the group")
+	}
+}
+
+func TestPaymentMemoInLedger(t *testing.T) {
+	group, err := NewGroup("memo-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{
+		PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "dinner", SplitMethod: "equal",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := group.RecordPaymentWithMemo("bob", "alice", 20*100*1000, "Venmo on 3/5", false); err != nil {
+		t.Fatal(err)
+	}
+
+	var payment *LedgerEntry
+	for _, entry := range group.Ledger() {
+		if entry.Kind == "payment" {
+			payment = &entry
+		}
+	}
+	if payment == nil {
+		t.Fatal("expected a payment entry in the ledger")
+	}
+	if payment.From != "Bob" || payment.To != "Alice" || payment.Memo != "Venmo on 3/5" ||
+		payment.ExpenseID != PaymentExpenseID || payment.AmountMicroCents != 20*100*1000 {
+		t.Fatalf("unexpected payment entry: %+v", payment)
+	}
+
+	entries, net, err := group.DebtsBetween("Alice", "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || net != 0 {
+		t.Fatalf("expected 2 entries and a zero net, got %d entries and net=%d", len(entries), net)
+	}
+	if len(group.GetExpenseDetails()) != 0 {
+		t.Fatalf("expected the payment to settle the debt, got %v", group.GetExpenseDetails())
+	}
+
+	// the limit counts characters, so 100 accented ones fit although they take 200 bytes
+	if _, err := group.RecordPaymentWithMemo("Alice", "Bob", 1000, strings.Repeat("é", 100), true); err != nil {
+		t.Errorf("expected a 100-character memo to be accepted, got %v", err)
+	}
+	if _, err := group.RecordPaymentWithMemo("Alice", "Bob", 1000, strings.Repeat("é", 101), true); err == nil || !strings.Contains(err.Error(), "got 101") {
+		t.Errorf("expected a 101-character memo to be rejected, got %v", err)
+	}
+}
+
+func TestTopDebts(t *testing.T) {
+	group, err := NewGroup("top-debts-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		// Bob owes Alice $30, Charlie owes Alice $60
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "hotel", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Bob": 1, "Charlie": 2}},
+		// Alice owes Bob $30, which settles Alice/Bob; Charlie owes Bob $10
+		{PaidBy: "Bob", TotalMicroCents: 40 * 100 * 1000, Description: "gas", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 3, "Charlie": 1}},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := group.TopDebts(10)
+	want := []PairDebt{
+		{From: "Charlie", To: "Alice", AmountMicroCents: 60 * 100 * 1000},
+		{From: "Charlie", To: "Bob", AmountMicroCents: 10 * 100 * 1000},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if top := group.TopDebts(1); len(top) != 1 || top[0] != want[0] {
+		t.Fatalf("expected only the largest debt, got %v", top)
+	}
+}
+
+func TestExpenseWithTwoPayers(t *testing.T) {
+	group, err := NewGroup("dinner-club")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &Expense{
+		PaidByMap:       map[string]float64{"alice": 60, "Bob": 40},
+		TotalMicroCents: 100 * 100 * 1000,
+		Description:     "dinner",
+		SplitMethod:     "equal",
+	}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.PaidBy != "Alice" {
+		t.Fatalf("expected the biggest payer Alice as PaidBy, got %q", e.PaidBy)
+	}
+
+	// each share is $33.33; Alice is $26.67 over, Bob $6.67 over, Charlie $33.33 short
+	details := group.GetExpenseDetails()
+	if len(details) != 2 {
+		t.Fatalf("expected only Charlie to owe, got %v", details)
+	}
+	if got := details["Charlie to pay Alice"]; math.Abs(got-26.67) > 0.01 {
+		t.Fatalf("expected Charlie to pay Alice ~26.67, got %v", details)
+	}
+	if got := details["Charlie to pay Bob"]; math.Abs(got-6.67) > 0.01 {
+		t.Fatalf("expected Charlie to pay Bob ~6.67, got %v", details)
+	}
+
+	err = group.AddExpense(&Expense{
+		PaidByMap:       map[string]float64{"Alice": 60, "Bob": 30},
+		TotalMicroCents: 100 * 100 * 1000,
+		Description:     "dinner again",
+		SplitMethod:     "equal",
+	})
+	if err == nil {
+		t.Fatal("expected an error when payer amounts don't add up to the total")
+	}
+
+	err = group.AddExpense(&Expense{
+		PaidBy:          "Charlie",
+		PaidByMap:       map[string]float64{"Alice": 60, "Bob": 40},
+		TotalMicroCents: 100 * 100 * 1000,
+		Description:     "dinner again",
+		SplitMethod:     "equal",
+	})
+	if err == nil {
+		t.Fatal("expected an error when both paid_by and paid_by_map are given")
+	}
+}
+
+func TestCompactExpenseIDs(t *testing.T) {
+	group, err := NewGroup("compact-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 20 * 100 * 1000, Description: "lunch", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 50 * 100 * 1000, Description: "typo", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 10 * 100 * 1000, Description: "coffee", SplitMethod: "equal"},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.DeleteExpense(2); err != nil {
+		t.Fatal(err)
+	}
+	before := group.GetExpenseDetails()
+
+	mapping := group.CompactExpenseIDs()
+	if len(mapping) != 1 || mapping[3] != 2 {
+		t.Fatalf("expected expense 3 to become 2, got %v", mapping)
+	}
+	for id := 1; id <= 2; id++ {
+		if _, ok := group.expenses[id]; !ok || group.expenses[id].ID != id {
+			t.Fatalf("expected contiguous expense IDs, got %v", group.expenses)
+		}
+	}
+	for _, entry := range group.Ledger() {
+		if entry.ExpenseID != 1 && entry.ExpenseID != 2 {
+			t.Fatalf("edge still references expense %d", entry.ExpenseID)
+		}
+	}
+
+	after := group.GetExpenseDetails()
+	if len(after) != 1 || after["Bob to pay Alice"] != 5 || before["Bob to pay Alice"] != 5 {
+		t.Fatalf("expected debts to stay intact, before=%v after=%v", before, after)
+	}
+
+	e := &Expense{PaidBy: "Bob", TotalMicroCents: 10 * 100 * 1000, Description: "snacks", SplitMethod: "equal"}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ID != 3 {
+		t.Fatalf("expected the next expense ID to be 3, got %d", e.ID)
+	}
+}
+
+func TestExpenseSplitByDuration(t *testing.T) {
+	group, err := NewGroup("flat-share")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	joined := map[string]time.Time{
+		"Alice":   start.AddDate(0, -1, 0), // whole month
+		"Bob":     start.AddDate(0, 0, 15), // second half of the month
+		"Charlie": end.AddDate(0, 0, 1),    // after the period
+	}
+	for name, at := range joined {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+		group.people[normalizeName(name)].AddedAt = at
+	}
+
+	e := &Expense{
+		PaidBy:          "Alice",
+		TotalMicroCents: 90 * 100 * 1000,
+		Description:     "internet",
+		SplitMethod:     "duration",
+		PeriodStart:     start,
+		PeriodEnd:       end,
+	}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	// Alice overlaps 30 days, Bob 15 days, Charlie none
+	want := map[string]int64{"alice": 60 * 100 * 1000, "bob": 30 * 100 * 1000}
+	if len(e.ResolvedShares) != len(want) {
+		t.Fatalf("expected shares %v, got %v", want, e.ResolvedShares)
+	}
+	for k, v := range want {
+		if e.ResolvedShares[k] != v {
+			t.Fatalf("expected shares %v, got %v", want, e.ResolvedShares)
+		}
+	}
+
+	err = group.AddExpense(&Expense{
+		PaidBy:          "Alice",
+		TotalMicroCents: 90 * 100 * 1000,
+		Description:     "internet",
+		SplitMethod:     "duration",
+		PeriodStart:     start,
+		PeriodEnd:       start.AddDate(0, 0, 10),
+	})
+	if err == nil {
+		t.Fatal("expected an error when only one person overlaps the period")
+	}
+}
+
+func TestGraphStatsEdgeGrowth(t *testing.T) {
+	group, err := NewGroup("stats-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := group.GraphStats(); stats.Nodes != 4 || stats.Edges != 0 {
+		t.Fatalf("unexpected stats for an empty group: %+v", stats)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := group.AddExpense(&Expense{
+			PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "groceries", SplitMethod: "equal",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		stats := group.GraphStats()
+		if stats.Edges != i*3 {
+			t.Fatalf("expected %d edges after %d expenses, got %+v", i*3, i, stats)
+		}
+		if stats.DistinctPairs != 3 || stats.MaxOutDegree != i {
+			t.Fatalf("unexpected stats after %d expenses: %+v", i, stats)
+		}
+	}
+}
+
+func TestCoalescedEdgesDeleteExpense(t *testing.T) {
+	group, err := NewGroup("coalesce-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	group.SetEdgeCoalescing(true)
+	for _, amount := range []int64{30, 60, 90} {
+		if err := group.AddExpense(&Expense{
+			PaidBy: "Alice", TotalMicroCents: amount * 100 * 1000, Description: "meal", SplitMethod: "equal",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := group.GraphStats(); stats.Edges != 2 {
+		t.Fatalf("expected 2 coalesced edges, got %+v", stats)
+	}
+	if got := group.GetExpenseDetails()["Bob to pay Alice"]; got != 60 {
+		t.Fatalf("expected Bob to owe Alice 60, got %v", got)
+	}
+
+	if err := group.DeleteExpense(2); err != nil {
+		t.Fatal(err)
+	}
+	details := group.GetExpenseDetails()
+	if details["Bob to pay Alice"] != 40 || details["Charlie to pay Alice"] != 40 {
+		t.Fatalf("expected the deleted expense's share to be removed, got %v", details)
+	}
+	for _, entry := range group.Ledger() {
+		if len(entry.ExpenseIDs) != 2 || entry.ExpenseIDs[0] != 1 || entry.ExpenseIDs[1] != 3 {
+			t.Fatalf("expected coalesced edges to reference expenses 1 and 3, got %+v", entry)
+		}
+	}
+
+	for _, id := range []int{1, 3} {
+		if err := group.DeleteExpense(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := group.GraphStats(); stats.Edges != 0 {
+		t.Fatalf("expected no edges once all expenses are deleted, got %+v", stats)
+	}
+}
+
+func BenchmarkAddExpenseEdges(b *testing.B) {
+	for _, coalesce := range []bool{false, true} {
+		b.Run(fmt.Sprintf("coalesce=%v", coalesce), func(b *testing.B) {
+			group, err := NewGroup("bench-trip")
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+				if err := group.AddPerson(name); err != nil {
+					b.Fatal(err)
+				}
+			}
+			group.SetEdgeCoalescing(coalesce)
+			for i := 0; i < b.N; i++ {
+				if err := group.AddExpense(&Expense{
+					PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "groceries", SplitMethod: "equal",
+				}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(group.GraphStats().Edges), "edges")
+		})
+	}
+}
+
+func TestExportGraphML(t *testing.T) {
+	group, err := NewGroup("graphml-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "lunch", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "coffee", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1, "Bob": 1}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := group.ExportGraphML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"graphml"`
+		Graph   struct {
+			EdgeDefault string `xml:"edgedefault,attr"`
+			Nodes       []struct {
+				ID    string `xml:"id,attr"`
+				Label string `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+				Weight string `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("GraphML does not parse: %v\n%s", err, out)
+	}
+	if doc.Graph.EdgeDefault != "directed" || len(doc.Graph.Nodes) != 3 {
+		t.Fatalf("unexpected graph: %+v", doc.Graph)
+	}
+	// bob->alice, charlie->alice and alice->bob
+	if len(doc.Graph.Edges) != 3 {
+		t.Fatalf("expected 3 edges, got %+v", doc.Graph.Edges)
+	}
+	for _, e := range doc.Graph.Edges {
+		if e.Source == "alice" && e.Target == "bob" && e.Weight != "10.00" {
+			t.Fatalf("expected alice->bob weight 10.00, got %q", e.Weight)
+		}
+	}
+}
+
+func TestContributionToEqualize(t *testing.T) {
+	group, err := NewGroup("grocery-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice overpaid: Bob and Charlie owe her $10 each
+	if err := group.AddExpense(&Expense{
+		PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "breakfast", SplitMethod: "equal",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := group.ContributionToEqualize(60 * 100 * 1000)
+	// balances: Alice +20, Bob -10, Charlie -10 => everyone ends at level 0
+	want := map[string]int64{"Alice": 0, "Bob": 30 * 100 * 1000, "Charlie": 30 * 100 * 1000}
+	for name, amount := range want {
+		if got[name] != amount {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	got = group.ContributionToEqualize(90 * 100 * 1000)
+	// $45 each for Bob and Charlie would lift them above Alice, so all three end at +$30
+	want = map[string]int64{"Alice": 10 * 100 * 1000, "Bob": 40 * 100 * 1000, "Charlie": 40 * 100 * 1000}
+	for name, amount := range want {
+		if got[name] != amount {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if got["Alice"] >= got["Bob"] {
+		t.Fatalf("expected the overpaid person to contribute less, got %v", got)
+	}
+}
+
+func TestContributionToEqualizeNegativeLevel(t *testing.T) {
+	group, err := NewGroup("penny-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Bob and Charlie owe Alice 1 cent each, so the level they are raised to is negative
+	if err := group.AddExpense(&Expense{
+		PaidBy: "Alice", TotalMicroCents: 3 * 1000, Description: "gum", SplitMethod: "equal",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []int64{1, 3, 999, 1001} {
+		got := group.ContributionToEqualize(target)
+		sum := int64(0)
+		for _, amount := range got {
+			if amount < 0 {
+				t.Fatalf("target %d: expected no negative contributions, got %v", target, got)
+			}
+			sum += amount
+		}
+		if sum != target {
+			t.Errorf("target %d: expected the contributions to add up, got %v", target, got)
+		}
+		if got["Alice"] != 0 {
+			t.Errorf("target %d: expected Alice, who is owed, to put in nothing, got %v", target, got)
+		}
+	}
+}
+
+func TestSuggestPoolContributions(t *testing.T) {
+	group, err := NewGroup("snack-pool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Bob and Charlie owe Alice $10 each
+	if err := group.AddExpense(&Expense{
+		PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "breakfast", SplitMethod: "equal",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := group.SuggestPoolContributions(50 * 100 * 1000)
+	// Alice is owed $20, so she puts in $50 - $20
+	want := map[string]int64{"Alice": 30 * 100 * 1000, "Bob": 60 * 100 * 1000, "Charlie": 60 * 100 * 1000}
+	if !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	got = group.SuggestPoolContributions(15 * 100 * 1000)
+	if got["Alice"] != 0 {
+		t.Fatalf("expected someone owed more than the target to put in nothing, got %v", got)
+	}
+}
+
+func TestPayerRotation(t *testing.T) {
+	group, err := NewGroup("rotation-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice paid everything so far: she is owed $50, Bob owes $10 and Charlie owes $40
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "breakfast", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "lunch", SplitMethod: "percentage",
+			SplitPercentages: map[string]float64{"Alice": 0, "Charlie": 100}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := group.PayerRotation(4)
+	// average expense $30, $10 a head: Charlie (-$40) pays first, leaving Bob and Charlie
+	// at -$20 each; Alice, who is still owed, never comes up
+	want := []string{"Charlie", "Bob", "Charlie", "Bob"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected rotation %v, got %v", want, got)
+	}
+	if got := group.PayerRotation(0); len(got) != 0 {
+		t.Fatalf("expected no rotation for 0 expenses, got %v", got)
+	}
+
+	// a group credit is neither spending nor an expense to average over
+	credited, err := NewGroup("rotation-credit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := credited.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := credited.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "dinner", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := credited.ApplyGroupCredit(2 * 100 * 1000); err != nil {
+		t.Fatal(err)
+	}
+	// average expense $40: Bob (-$19) pays and ends $1 ahead, so Alice pays next
+	if got, want := credited.PayerRotation(2), []string{"Bob", "Alice"}; !slices.Equal(got, want) {
+		t.Fatalf("expected rotation %v, got %v", want, got)
+	}
+}
+
+func TestReimbursementNeeded(t *testing.T) {
+	group, err := NewGroup("ticket-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice fronted the tickets; Bob bought Charlie a $6 snack
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "tickets", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 6 * 100 * 1000, Description: "snack", SplitMethod: "percentage",
+			SplitPercentages: map[string]float64{"Charlie": 100}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, breakdown, err := group.ReimbursementNeeded("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 60*100*1000 {
+		t.Fatalf("expected Alice to be owed $60, got %d", total)
+	}
+	want := map[string]int64{"Charlie": 36 * 100 * 1000, "Bob": 24 * 100 * 1000}
+	if !maps.Equal(breakdown, want) {
+		t.Fatalf("expected breakdown %v, got %v", want, breakdown)
+	}
+
+	total, breakdown, err = group.ReimbursementNeeded("Charlie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 || len(breakdown) != 0 {
+		t.Fatalf("expected Charlie to be owed nothing, got %d %v", total, breakdown)
+	}
+	if _, _, err := group.ReimbursementNeeded("Nobody"); err == nil {
+		t.Fatal("expected an unknown person to be rejected")
+	}
+}
+
+func TestGrossDebts(t *testing.T) {
+	group, err := NewGroup("gross-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Bob", TotalMicroCents: 60 * 100 * 1000, Description: "hotel", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 20 * 100 * 1000, Description: "lunch", SplitMethod: "equal"},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.RecordPayment("Alice", "Bob", 5*100*1000); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{"Alice to pay Bob": 30, "Bob to pay Alice": 10}
+	if got := group.GrossDebts(); !maps.Equal(got, want) {
+		t.Fatalf("expected gross debts %v, got %v", want, got)
+	}
+	if net := group.GetExpenseDetails(); net["Alice to pay Bob"] != 15 || len(net) != 1 {
+		t.Fatalf("expected the netted view to show only Alice owing Bob $15, got %v", net)
+	}
+}
+
+func TestOneRoundSettlement(t *testing.T) {
+	group, err := NewGroup("banker-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice is owed $50 and Bob $10; Charlie and Dave owe $30 each
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 80 * 100 * 1000, Description: "cabin", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 40 * 100 * 1000, Description: "groceries", SplitMethod: "equal"},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	balances := map[string]int64{}
+	for _, m := range group.MembersByBalance() {
+		balances[m.Name] = m.BalanceMicroCents
+	}
+
+	banker, transfers := group.OneRoundSettlement()
+	if banker != "Alice" {
+		t.Fatalf("expected the biggest creditor Alice to be the banker, got %q", banker)
+	}
+	counts := map[string]int{}
+	for _, s := range transfers {
+		if s.From != banker && s.To != banker {
+			t.Fatalf("expected every transfer to involve the banker, got %+v", s)
+		}
+		counts[s.From]++
+		counts[s.To]++
+		balances[s.From] += s.AmountMicroCents
+		balances[s.To] -= s.AmountMicroCents
+	}
+	for _, name := range []string{"Bob", "Charlie", "Dave"} {
+		if counts[name] != 1 {
+			t.Fatalf("expected %s to have exactly one transfer, got %v", name, transfers)
+		}
+	}
+	for name, balance := range balances {
+		if balance != 0 {
+			t.Fatalf("expected %s to end at zero, got %d", name, balance)
+		}
+	}
+}
+
+func TestSettlementReminderICS(t *testing.T) {
+	group, err := NewGroup("ics-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{
+		PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "dinner", SplitMethod: "equal",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	due := time.Date(2025, time.March, 5, 0, 0, 0, 0, time.Local)
+	ics, err := group.SettlementReminderICS(due)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"BEGIN:VEVENT\r\n",
+		"UID:settle-up-ics-trip@expense-splitter\r\n",
+		"DTSTART;VALUE=DATE:20250305\r\n",
+		"SUMMARY:Settle up: ics-trip\r\n",
+		"DESCRIPTION:Bob pays Alice $20.00\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Fatalf("expected the reminder to contain %q, got:\n%s", want, ics)
+		}
+	}
+	if _, err := group.SettlementReminderICS(time.Time{}); err == nil {
+		t.Fatal("expected a missing due date to be rejected")
+	}
+
+	if got := icsEscaper.Replace("a;b,c\\d\ne"); got != `a\;b\,c\\d\ne` {
+		t.Fatalf("unexpected escaping %q", got)
+	}
+	folded := foldICSLine("DESCRIPTION:" + strings.Repeat("é", 60))
+	for _, line := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		if len(line) > 75 || !utf8.ValidString(line) {
+			t.Fatalf("expected folded lines of at most 75 valid octets, got %q", line)
+		}
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	group, err := NewGroup("generous-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Charlie pays the most and skips the wine
+	for _, e := range []*Expense{
+		{PaidBy: "Charlie", TotalMicroCents: 90 * 100 * 1000, Description: "dinner", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "wine", SplitMethod: "equal", Excluded: []string{"Charlie"}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := group.Leaderboard()
+	want := []LeaderboardEntry{
+		{Name: "Charlie", PaidMicroCents: 90 * 100 * 1000, OwedMicroCents: 30 * 100 * 1000, NetMicroCents: 60 * 100 * 1000},
+		{Name: "Alice", PaidMicroCents: 30 * 100 * 1000, OwedMicroCents: 45 * 100 * 1000, NetMicroCents: -15 * 100 * 1000},
+		{Name: "Bob", PaidMicroCents: 0, OwedMicroCents: 45 * 100 * 1000, NetMicroCents: -45 * 100 * 1000},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected leaderboard %+v, got %+v", want, got)
+	}
+}
+
+func TestMembersByBalance(t *testing.T) {
+	group, err := NewGroup("balance-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Charlie pays $30 for Alice and Bob; Bob pays $10 for Alice
+	for _, e := range []*Expense{
+		{PaidBy: "Charlie", TotalMicroCents: 30 * 100 * 1000, Description: "tickets", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1, "Bob": 2}},
+		{PaidBy: "Bob", TotalMicroCents: 10 * 100 * 1000, Description: "popcorn", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := group.MembersByBalance()
+	want := []MemberBalance{
+		{Name: "Charlie", Balance: 30, BalanceMicroCents: 30 * 100 * 1000},
+		{Name: "Dave", Balance: 0, BalanceMicroCents: 0},
+		{Name: "Bob", Balance: -10, BalanceMicroCents: -10 * 100 * 1000},
+		{Name: "Alice", Balance: -20, BalanceMicroCents: -20 * 100 * 1000},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	group, err := NewGroup("snapshot-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "dinner", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := group.RecordPaymentWithMemo("Bob", "Alice", 5*100*1000, "cash", false); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := group.Snapshot()
+	before, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledgerBefore := group.Ledger()
+
+	if err := group.AddPerson("Dave"); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Dave", TotalMicroCents: 12 * 100 * 1000, Description: "taxi", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.RecordPayment("Charlie", "Alice", 10*100*1000); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := group.RestoreSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	after, err := json.Marshal(group.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("expected snapshot %s after restore, got %s", before, after)
+	}
+	ledgerAfter := group.Ledger()
+	if len(ledgerAfter) != len(ledgerBefore) {
+		t.Fatalf("expected ledger %v after restore, got %v", ledgerBefore, ledgerAfter)
+	}
+	for i := range ledgerBefore {
+		a, b := ledgerBefore[i], ledgerAfter[i]
+		if a.Kind != b.Kind || a.From != b.From || a.To != b.To || a.AmountMicroCents != b.AmountMicroCents || a.Memo != b.Memo {
+			t.Fatalf("expected ledger %v after restore, got %v", ledgerBefore, ledgerAfter)
+		}
+	}
+
+	// new expenses continue from the restored counter
+	e := &Expense{PaidBy: "Bob", TotalMicroCents: 3 * 100 * 1000, Description: "coffee", SplitMethod: "equal"}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ID != snapshot.ExpenseIDCounter+1 {
+		t.Fatalf("expected expense ID %d, got %d", snapshot.ExpenseIDCounter+1, e.ID)
+	}
+
+	other := snapshot
+	other.Name = "another-trip"
+	if err := group.RestoreSnapshot(other); err == nil {
+		t.Fatal("expected error restoring another group's snapshot")
+	}
+	bad := group.Snapshot()
+	bad.People = append(bad.People, PersonSnapshot{Name: "1nvalid"})
+	if err := group.RestoreSnapshot(bad); err == nil {
+		t.Fatal("expected error restoring a snapshot with an invalid person name")
+	}
+
+	// recurring templates may only name members
+	rent := RecurringExpense{ID: 1, Interval: 30 * 24 * time.Hour, Template: Expense{PaidBy: "Zed", TotalMicroCents: 900 * 100 * 1000,
+		Description: "rent", SplitMethod: "equal"}}
+	bad = group.Snapshot()
+	bad.Recurring, bad.RecurringIDCounter = []RecurringExpense{rent}, 1
+	if err := group.RestoreSnapshot(bad); err == nil || !strings.Contains(err.Error(), "person(zed) is not a member") {
+		t.Fatalf("expected a recurring payer outside the group to be rejected, got %v", err)
+	}
+	rent.Template.PaidBy = "Alice"
+	rent.Template.SplitMethod = "weights"
+	rent.Template.SplitWeights = map[string]float64{"Alice": 1, "Mallory": 1}
+	bad.Recurring = []RecurringExpense{rent}
+	if err := group.RestoreSnapshot(bad); err == nil || !strings.Contains(err.Error(), "person(mallory) is not a member") {
+		t.Fatalf("expected a recurring participant outside the group to be rejected, got %v", err)
+	}
+	rent.Template.Description = " "
+	rent.Template.SplitWeights = nil
+	rent.Template.SplitMethod = "equal"
+	bad.Recurring = []RecurringExpense{rent}
+	if err := group.RestoreSnapshot(bad); err == nil {
+		t.Fatal("expected a recurring template with a blank description to be rejected")
+	}
+	rent.Template.Description = "rent"
+	bad.Recurring = []RecurringExpense{rent}
+	if err := group.RestoreSnapshot(bad); err != nil {
+		t.Fatalf("expected a valid recurring template to be restored, got %v", err)
+	}
+}
+
+func TestNameCollisionPolicy(t *testing.T) {
+	group, err := NewGroup("alex-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := group.AddPerson("Alex"); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.AddPerson("alex"); err == nil {
+		t.Fatal("expected the default policy to reject a duplicate name")
+	}
+
+	if err := group.SetNameCollisionPolicy(NameCollisionAutoSuffix); err != nil {
+		t.Fatal(err)
+	}
+	second, err := group.AddMember("Alex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := group.AddMember("Alex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Name != "Alex (2)" || third.Name != "Alex (3)" {
+		t.Fatalf("expected Alex (2) and Alex (3), got %s and %s", second.Name, third.Name)
+	}
+	if group.Size() != 3 {
+		t.Fatalf("expected 3 distinct members, got %d", group.Size())
+	}
+
+	// the suffixed members are distinct people in the graph
+	if err := group.AddExpense(&Expense{PaidBy: "Alex", TotalMicroCents: 30 * 100 * 1000, Description: "dinner", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, net, err := group.DebtsBetween("Alex (2)", "Alex"); err != nil || net != 10*100*1000 {
+		t.Fatalf("expected Alex (2) to owe Alex $10, got %d (err %v)", net, err)
+	}
+
+	if err := group.SetNameCollisionPolicy(NameCollisionDisambiguate); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.AddPerson("Alex"); err == nil {
+		t.Fatal("expected a duplicate without disambiguator to be rejected")
+	}
+	work, err := group.AddMember("Alex (work)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.Name != "Alex (work)" {
+		t.Fatalf("expected Alex (work), got %s", work.Name)
+	}
+	if err := group.AddPerson("Alex (work)"); err == nil {
+		t.Fatal("expected a duplicate disambiguated name to be rejected")
+	}
+
+	if err := group.SetNameCollisionPolicy("rename"); err == nil {
+		t.Fatal("expected an unknown policy to be rejected")
+	}
+}
+
+func TestCompactSummary(t *testing.T) {
+	group, err := NewGroup("summary-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"Alice", "Bob", "Charlie", "Dave", "Erin", "Frank"}
+	for _, name := range names {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice pays $60 for everyone, Bob pays $12.50 for Charlie
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 60 * 100 * 1000, Description: "dinner", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Bob", TotalMicroCents: 1250 * 1000, Description: "taxi", SplitMethod: "weights",
+		SplitWeights: map[string]float64{"Charlie": 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(group.CompactSummary(), "\n")
+	want := "summary-trip | 6 people | 2 expenses | $72.50 total | 5 open debts"
+	if lines[0] != want {
+		t.Fatalf("expected %q, got %q", want, lines[0])
+	}
+	if len(lines) != 1+compactSummarySettlements+1 {
+		t.Fatalf("expected %d settlement lines and a remainder line, got %v", compactSummarySettlements, lines[1:])
+	}
+	if lines[1] != "Charlie->Alice $22.50" {
+		t.Fatalf("expected the biggest transfer first, got %q", lines[1])
+	}
+	if lines[len(lines)-1] != "+2 more" {
+		t.Fatalf("expected a remainder line, got %q", lines[len(lines)-1])
+	}
+	for _, line := range lines {
+		if len(line) > 80 {
+			t.Fatalf("expected short lines, got %q", line)
+		}
+	}
+}
+
+func TestMaterializeRecurring(t *testing.T) {
+	group, err := NewGroup("rent-flat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	month := 30 * 24 * time.Hour
+	rent := Expense{PaidBy: "Alice", TotalMicroCents: 1500 * 100 * 1000, Description: "rent", SplitMethod: "equal", CreatedAt: start}
+	id, err := group.AddRecurring(rent, month)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := group.MaterializeRecurring(start.Add(-time.Hour)); len(ids) != 0 {
+		t.Fatalf("expected nothing due yet, got %v", ids)
+	}
+	ids := group.MaterializeRecurring(start.Add(2 * month))
+	if len(ids) != 3 {
+		t.Fatalf("expected 3 monthly occurrences, got %v", ids)
+	}
+	if again := group.MaterializeRecurring(start.Add(2 * month)); len(again) != 0 {
+		t.Fatalf("expected occurrences to be materialized once, got %v", again)
+	}
+
+	for i, entry := range group.Ledger() {
+		if entry.To != "Alice" || entry.AmountMicroCents != 500*100*1000 {
+			t.Fatalf("expected everyone to owe Alice $500 per month, got %v", entry)
+		}
+		if want := start.Add(time.Duration(i/2) * month); !entry.CreatedAt.Equal(want) {
+			t.Fatalf("expected entry %d dated %v, got %v", i, want, entry.CreatedAt)
+		}
+	}
+	_, net, err := group.DebtsBetween("Bob", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net != 1500*100*1000 {
+		t.Fatalf("expected Bob to owe Alice $1500 after 3 months, got %d", net)
+	}
+	recurring := group.ListRecurring()
+	if len(recurring) != 1 || recurring[0].ID != id || !recurring[0].Next.Equal(start.Add(3*month)) {
+		t.Fatalf("expected the next occurrence in the fourth month, got %v", recurring)
+	}
+
+	bad := Expense{PaidBy: "Zed", TotalMicroCents: 100 * 1000, Description: "internet", SplitMethod: "equal"}
+	if _, err := group.AddRecurring(bad, month); err == nil {
+		t.Fatal("expected a template with an unknown payer to be rejected")
+	}
+	if _, err := group.AddRecurring(rent, time.Hour); err == nil {
+		t.Fatal("expected an interval under a day to be rejected")
+	}
+	if _, err := group.AddRecurring(rent, (MaxRecurringIntervalDays+1)*24*time.Hour); err == nil {
+		t.Fatal("expected an interval over a year to be rejected")
+	}
+}
+
+func TestMaterializeRecurringCapsOccurrences(t *testing.T) {
+	group, err := NewGroup("daily-coffee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a start date years back would otherwise add an expense for every day since
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	coffee := Expense{PaidBy: "Alice", TotalMicroCents: 4 * 100 * 1000, Description: "coffee", SplitMethod: "equal", CreatedAt: start}
+	if _, err := group.AddRecurring(coffee, day); err != nil {
+		t.Fatal(err)
+	}
+
+	upTo := start.Add(700 * day)
+	if ids := group.MaterializeRecurring(upTo); len(ids) != MaxOccurrencesPerMaterialize {
+		t.Fatalf("expected %d occurrences, got %d", MaxOccurrencesPerMaterialize, len(ids))
+	}
+	if next := group.ListRecurring()[0].Next; !next.Equal(start.Add(MaxOccurrencesPerMaterialize * day)) {
+		t.Fatalf("expected the occurrences past the cap to stay due, next is %v", next)
+	}
+	if ids := group.MaterializeRecurring(upTo); len(ids) != 701-MaxOccurrencesPerMaterialize {
+		t.Fatalf("expected the rest on the next call, got %d", len(ids))
+	}
+}
+
+func TestMaterializeRecurringRunsAddExpenseChecks(t *testing.T) {
+	group, err := NewGroup("euro-flat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	month := 30 * 24 * time.Hour
+
+	// 90 EUR at 2 USD each is stored as $180
+	gym := Expense{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Currency: "EUR", ExchangeRate: 2, Description: "gym",
+		SplitMethod: "equal", CreatedAt: start}
+	if _, err := group.AddRecurring(gym, month); err != nil {
+		t.Fatal(err)
+	}
+	noRate := gym
+	noRate.ExchangeRate = 0
+	if _, err := group.AddRecurring(noRate, month); err == nil {
+		t.Fatal("expected a foreign-currency template without a rate to be rejected")
+	}
+	both := gym
+	both.PaidByMap = map[string]float64{"Alice": 90}
+	if _, err := group.AddRecurring(both, month); err == nil {
+		t.Fatal("expected paid_by together with paid_by_map to be rejected")
+	}
+
+	ids := group.MaterializeRecurring(start)
+	if len(ids) != 1 {
+		t.Fatalf("expected one occurrence, got %v", ids)
+	}
+	e, err := group.GetExpense(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.TotalMicroCents != 180*100*1000 || e.OriginalMicroCents != 90*100*1000 || e.ResolvedShares["bob"] != 60*100*1000 {
+		t.Fatalf("expected $180 converted from 90 EUR with $60 shares, got %+v", e)
+	}
+
+	group.SetRequireAllParticipate(true)
+	partial := Expense{PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "internet", SplitMethod: "percentage",
+		SplitPercentages: map[string]float64{"Alice": 50, "Bob": 50}, CreatedAt: start}
+	if _, err := group.AddRecurring(partial, month); err == nil {
+		t.Fatal("expected a template leaving Carol out to be rejected under the strict policy")
+	}
+	// the gym stays valid: an equal split covers everyone
+	if ids := group.MaterializeRecurring(start.Add(month)); len(ids) != 1 {
+		t.Fatalf("expected the next gym occurrence, got %v", ids)
+	}
+
+	// registered before the policy was turned on, it isn't materialized under it
+	group.SetRequireAllParticipate(false)
+	partial.CreatedAt = start.Add(2 * month)
+	if _, err := group.AddRecurring(partial, month); err != nil {
+		t.Fatal(err)
+	}
+	group.SetRequireAllParticipate(true)
+	ids = group.MaterializeRecurring(start.Add(2 * month))
+	if len(ids) != 1 {
+		t.Fatalf("expected only the gym to be materialized, got %v", ids)
+	}
+	if e, err := group.GetExpense(ids[0]); err != nil || e.Description != "gym" {
+		t.Fatalf("expected the gym, got %v (err %v)", e, err)
+	}
+}
+
+func TestSettlementChains(t *testing.T) {
+	group, err := NewGroup("chain-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice owes Bob $30, Bob owes Charlie $20
+	for _, e := range []*Expense{
+		{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "tickets", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1}},
+		{PaidBy: "Charlie", TotalMicroCents: 20 * 100 * 1000, Description: "snacks", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Bob": 1}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := group.SettlementChains()
+	want := []Chain{{From: "Alice", Via: "Bob", To: "Charlie", AmountMicroCents: 20 * 100 * 1000}}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestExcludedAcrossSplitMethods(t *testing.T) {
+	group, err := NewGroup("exclude-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now()
+
+	total := int64(90 * 100 * 1000)
+	expenses := []*Expense{
+		{SplitMethod: "equal"},
+		{SplitMethod: "percentage", SplitPercentages: map[string]float64{"Alice": 50, "Bob": 25, "Charlie": 25, "Dave": 0}},
+		{SplitMethod: "weights", SplitWeights: map[string]float64{"Alice": 1, "Bob": 1, "Charlie": 1}},
+		{SplitMethod: "duration", PeriodStart: start, PeriodEnd: start.Add(10 * 24 * time.Hour)},
+	}
+	for _, e := range expenses {
+		e.PaidBy = "Alice"
+		e.TotalMicroCents = total
+		e.Description = e.SplitMethod + " dinner"
+		e.Excluded = []string{"dave"}
+		if err := group.AddExpense(e); err != nil {
+			t.Fatalf("%s: %v", e.SplitMethod, err)
+		}
+		participants := []string{}
+		for key, share := range e.ResolvedShares {
+			if share > 0 {
+				participants = append(participants, key)
+			}
+		}
+		sort.Strings(participants)
+		if strings.Join(participants, ",") != "alice,bob,charlie" {
+			t.Fatalf("%s: expected participants alice,bob,charlie, got %v", e.SplitMethod, participants)
+		}
+		if e.Excluded[0] != "Dave" {
+			t.Fatalf("%s: expected excluded name to be the display name, got %v", e.SplitMethod, e.Excluded)
+		}
+	}
+
+	for _, e := range []*Expense{
+		{SplitMethod: "percentage", SplitPercentages: map[string]float64{"Alice": 50, "Dave": 50}},
+		{SplitMethod: "weights", SplitWeights: map[string]float64{"Alice": 1, "Dave": 1}},
+	} {
+		e.PaidBy = "Alice"
+		e.TotalMicroCents = total
+		e.Description = "excluded with a share"
+		e.Excluded = []string{"Dave"}
+		if err := group.AddExpense(e); err == nil {
+			t.Fatalf("%s: expected an excluded person with a positive share to be rejected", e.SplitMethod)
+		}
+	}
+	unknown := &Expense{PaidBy: "Alice", TotalMicroCents: total, Description: "taxi", SplitMethod: "equal", Excluded: []string{"Zed"}}
+	if err := group.AddExpense(unknown); err == nil {
+		t.Fatal("expected an unknown excluded person to be rejected")
+	}
+}
+
+func TestRoundingFairnessReport(t *testing.T) {
+	group, err := NewGroup("rounding-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// $10.00 split three ways leaves 1 micro cent, which always goes to Alice
+	for i := 0; i < 6; i++ {
+		if err := group.AddExpense(&Expense{PaidBy: "Bob", TotalMicroCents: 1000 * 1000, Description: "coffee", SplitMethod: "equal"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Charlie", TotalMicroCents: 1000*1000 + 1, Description: "snacks", SplitMethod: "weights",
+		SplitWeights: map[string]float64{"Bob": 1, "Charlie": 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := group.RoundingFairnessReport()
+	sum := int64(0)
+	for _, extra := range report {
+		sum += extra
+	}
+	if sum != 0 {
+		t.Fatalf("expected the report to sum to zero, got %d (%v)", sum, report)
+	}
+	// Alice absorbed 6 micro cents where 2 would have been fair
+	if report["Alice"] != 4 {
+		t.Fatalf("expected Alice to have absorbed 4 extra micro cents, got %v", report)
+	}
+	if report["Bob"] >= 0 || report["Charlie"] >= 0 {
+		t.Fatalf("expected Bob and Charlie to have absorbed less, got %v", report)
+	}
+}
+
+func TestTransferDebt(t *testing.T) {
+	group, err := NewGroup("transfer-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice owes Bob $30
+	if err := group.AddExpense(&Expense{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "tickets", SplitMethod: "weights",
+		SplitWeights: map[string]float64{"Alice": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	before := group.MembersByBalance()
+
+	// Alice will owe Charlie $20 of it instead
+	if err := group.TransferDebt("Alice", "Bob", "Charlie", 20*100*1000); err != nil {
+		t.Fatal(err)
+	}
+
+	after := group.MembersByBalance()
+	if len(after) != len(before) {
+		t.Fatalf("expected balances %v, got %v", before, after)
+	}
+	for i := range before {
+		if after[i] != before[i] {
+			t.Fatalf("expected net balances to stay %v, got %v", before, after)
+		}
+	}
+	for _, tc := range []struct {
+		a, b string
+		want int64
+	}{
+		{"Alice", "Bob", 10 * 100 * 1000},
+		{"Alice", "Charlie", 20 * 100 * 1000},
+		{"Charlie", "Bob", 20 * 100 * 1000},
+	} {
+		_, net, err := group.DebtsBetween(tc.a, tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if net != tc.want {
+			t.Fatalf("expected %s to owe %s %d, got %d", tc.a, tc.b, tc.want, net)
+		}
+	}
+
+	if err := group.TransferDebt("Alice", "Bob", "Charlie", 20*100*1000); err == nil {
+		t.Fatal("expected transferring more than is owed to fail")
+	}
+	if err := group.TransferDebt("Alice", "Bob", "Alice", 100*1000); err == nil {
+		t.Fatal("expected transferring a debt to the debtor to fail")
+	}
+	if err := group.TransferDebt("Alice", "Bob", "Zed", 100*1000); err == nil {
+		t.Fatal("expected an unknown person to fail")
+	}
+}
+
+func TestForgiveAllDebts(t *testing.T) {
+	group, err := NewGroup("family-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Kid"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "museum", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 60 * 100 * 1000, Description: "dinner", SplitMethod: "equal"},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := group.PreviewForgiveAll("Nobody"); err == nil {
+		t.Fatal("expected an unknown person to be rejected")
+	}
+	preview, err := group.PreviewForgiveAll("Kid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := group.GetExpenseDetails()
+	if before["Kid to pay Bob"] != 20 {
+		t.Fatalf("expected Kid to owe Bob $20 before forgiving, got %v", before)
+	}
+
+	total, err := group.ForgiveAllDebts("kid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 30*100*1000 {
+		t.Fatalf("expected $30 to be written off, got %d", total)
+	}
+	after := group.GetExpenseDetails()
+	if !maps.Equal(preview, after) {
+		t.Fatalf("expected the preview %v to match the result %v", preview, after)
+	}
+	for key := range after {
+		if strings.HasPrefix(key, "Kid to pay") {
+			t.Fatalf("expected Kid to owe nothing, got %v", after)
+		}
+	}
+	if after["Alice to pay Bob"] != 10 {
+		t.Fatalf("expected debts between others to stay, got %v", after)
+	}
+}
+
+func TestExpensesWithLabel(t *testing.T) {
+	group, err := NewGroup("label-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "client lunch", SplitMethod: "equal", Labels: []string{"Business", "reimbursable"}},
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "snacks", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "taxi to office", SplitMethod: "equal", Labels: []string{"business", "business"}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := group.ExpensesWithLabel("business")
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Fatalf("expected expenses 1 and 3 to carry business, got %+v", got)
+	}
+	if !slices.Equal(got[1].Labels, []string{"business"}) {
+		t.Fatalf("expected duplicate labels to be dropped, got %v", got[1].Labels)
+	}
+	if got := group.ExpensesWithLabel("Reimbursable"); len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("expected expense 1 to carry reimbursable, got %+v", got)
+	}
+	if got := group.ExpensesWithLabel("recurring"); len(got) != 0 {
+		t.Fatalf("expected no recurring expenses, got %+v", got)
+	}
+
+	bad := &Expense{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "bad label", SplitMethod: "equal", Labels: []string{"has space"}}
+	if err := group.AddExpense(bad); err == nil {
+		t.Fatal("expected a malformed label to be rejected")
+	}
+	many := make([]string, 0, maxExpenseLabels+1)
+	for i := range maxExpenseLabels + 1 {
+		many = append(many, fmt.Sprintf("label%d", i))
+	}
+	tooMany := &Expense{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "too many", SplitMethod: "equal", Labels: many}
+	if err := group.AddExpense(tooMany); err == nil {
+		t.Fatal("expected too many labels to be rejected")
+	}
+}
+
+func TestExpensesByEvent(t *testing.T) {
+	group, err := NewGroup("festival-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "tickets", SplitMethod: "equal", Event: "Concert"},
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "pasta", SplitMethod: "equal", Event: " Day 1 dinner "},
+		{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "gas", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 5 * 100 * 1000, Description: "drinks", SplitMethod: "equal", Event: "concert"},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := group.ExpensesByEvent()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %v", got)
+	}
+	if concert := got["Concert"]; len(concert) != 2 || concert[0].ID != 1 || concert[1].ID != 4 {
+		t.Fatalf("expected expenses 1 and 4 under Concert, got %+v", concert)
+	}
+	if dinner := got["Day 1 dinner"]; len(dinner) != 1 || dinner[0].ID != 2 {
+		t.Fatalf("expected expense 2 under Day 1 dinner, got %+v", dinner)
+	}
+
+	long := &Expense{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "long event", SplitMethod: "equal",
+		Event: strings.Repeat("x", maxEventLength+1)}
+	if err := group.AddExpense(long); err == nil {
+		t.Fatal("expected an over-long event name to be rejected")
+	}
+}
+
+func TestFlagUnevenSplits(t *testing.T) {
+	group, err := NewGroup("review-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "lunch", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 60 * 100 * 1000, Description: "cabin", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 50, "Bob": 5, "Charlie": 5}},
+		{PaidBy: "Charlie", TotalMicroCents: 40 * 100 * 1000, Description: "gas", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 2, "Bob": 1, "Charlie": 1}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Alice's cabin share is $50 against a $20 average; her gas share is 1.5x the average
+	if got := group.FlagUnevenSplits(2); !slices.Equal(got, []int{2}) {
+		t.Fatalf("expected only the lopsided expense 2 to be flagged, got %v", got)
+	}
+	if got := group.FlagUnevenSplits(1.2); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("expected a lower threshold to flag expenses 2 and 3, got %v", got)
+	}
+}
+
+func TestAddMileageExpense(t *testing.T) {
+	group, err := NewGroup("carpool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Alice drove 100 miles, Bob got in for 60 and Charlie for the last 40; Dave stayed home
+	e, err := group.AddMileageExpense("Alice", 50*100*1000, map[string]float64{"Alice": 100, "Bob": 60, "Charlie": 40}, "gas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.SplitMethod != "weights" {
+		t.Fatalf("expected a weights split, got %s", e.SplitMethod)
+	}
+	want := map[string]int64{"alice": 25 * 100 * 1000, "bob": 15 * 100 * 1000, "charlie": 10 * 100 * 1000}
+	for key, share := range want {
+		if e.ResolvedShares[key] != share {
+			t.Fatalf("expected shares %v, got %v", want, e.ResolvedShares)
+		}
+	}
+	if e.ResolvedShares["dave"] != 0 {
+		t.Fatalf("expected Dave to owe nothing, got %v", e.ResolvedShares)
+	}
+
+	if _, err := group.AddMileageExpense("Alice", 10*100*1000, map[string]float64{"Alice": 10, "Bob": -5}, "gas"); err == nil {
+		t.Fatal("expected negative miles to be rejected")
+	}
+	if _, err := group.AddMileageExpense("Alice", 10*100*1000, map[string]float64{"Alice": 0, "Bob": 0}, "gas"); err == nil {
+		t.Fatal("expected zero total miles to be rejected")
+	}
+}
+
+func TestPreviewEditAmount(t *testing.T) {
+	group, err := NewGroup("typo-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{
+		PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "pizza", SplitMethod: "equal",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Dave joined afterwards and must not be pulled into the pizza
+	if err := group.AddPerson("Dave"); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := json.Marshal(group.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, after, err := group.PreviewEditAmount(1, 60*100*1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(before, group.GetExpenseDetails()) {
+		t.Fatalf("expected before %v to match the current debts %v", before, group.GetExpenseDetails())
+	}
+	for key, amount := range before {
+		if after[key] != 2*amount {
+			t.Fatalf("expected doubling the amount to double %s, got before %v after %v", key, before, after)
+		}
+	}
+	if len(after) != len(before) {
+		t.Fatalf("expected the same debts after the edit, got before %v after %v", before, after)
+	}
+
+	unchanged, err := json.Marshal(group.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(unchanged) != string(snapshot) {
+		t.Fatal("expected the preview to leave the group unchanged")
+	}
+	if _, _, err := group.PreviewEditAmount(99, 10*100*1000); err == nil {
+		t.Fatal("expected an unknown expense to be rejected")
+	}
+}
+
+func TestCoveredPercent(t *testing.T) {
+	group, err := NewGroup("work-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &Expense{
+		PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "client dinner", SplitMethod: "equal",
+		CoveredPercent: 80, CoveredBy: " Company ",
+	}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.CoveredBy != "Company" || e.CoveredMicroCents() != 80*100*1000 {
+		t.Fatalf("expected Company to cover $80, got %q %d", e.CoveredBy, e.CoveredMicroCents())
+	}
+	// the other $20 is split two ways; the covered $80 stays on Alice's share
+	want := map[string]int64{"alice": 90 * 100 * 1000, "bob": 10 * 100 * 1000}
+	if !maps.Equal(e.ResolvedShares, want) {
+		t.Fatalf("expected shares %v, got %v", want, e.ResolvedShares)
+	}
+	if got := group.GetExpenseDetails(); len(got) != 1 || got["Bob to pay Alice"] != 10 {
+		t.Fatalf("expected only Bob owing Alice $10, got %v", got)
+	}
+
+	for _, bad := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "too much", SplitMethod: "equal", CoveredPercent: 100, CoveredBy: "Company"},
+		{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "negative", SplitMethod: "equal", CoveredPercent: -5, CoveredBy: "Company"},
+		{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "nobody", SplitMethod: "equal", CoveredPercent: 50},
+	} {
+		if err := group.AddExpense(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad.Description)
+		}
+	}
+}
+
+func TestIncomeSplit(t *testing.T) {
+	group, err := NewGroup("income-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 60 * 100 * 1000, Description: "groceries", SplitMethod: "income"}
+	if err := group.AddExpense(e); err == nil {
+		t.Fatal("expected an income split without brackets to be rejected")
+	}
+	if err := group.SetIncomeBracket("Alice", "rich"); err == nil {
+		t.Fatal("expected an undefined bracket to be rejected")
+	}
+	for name, bracket := range map[string]string{"Alice": "low", "Bob": "mid", "Charlie": "HIGH"} {
+		if err := group.SetIncomeBracket(name, bracket); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"alice": 10 * 100 * 1000, "bob": 20 * 100 * 1000, "charlie": 30 * 100 * 1000}
+	if !maps.Equal(e.ResolvedShares, want) {
+		t.Fatalf("expected shares %v, got %v", want, e.ResolvedShares)
+	}
+
+	// brackets survive a snapshot round trip and follow a rename
+	if err := group.DefineIncomeBracket("student", 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.RestoreSnapshot(group.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.RenamePerson("Charlie", "Chuck"); err != nil {
+		t.Fatal(err)
+	}
+	if got := group.Snapshot().MemberBrackets; got["Chuck"] != "high" || len(got) != 3 {
+		t.Fatalf("expected Chuck to keep the high bracket, got %v", got)
+	}
+	if got := group.IncomeBrackets(); got["student"] != 0.5 || got["high"] != 3 {
+		t.Fatalf("expected the student bracket next to the defaults, got %v", got)
+	}
+}
+
+func TestNormalizePercentageMaps(t *testing.T) {
+	group, err := NewGroup("drift-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "drifted", SplitMethod: "percentage",
+			SplitPercentages: map[string]float64{"Alice": 50.00004, "Bob": 49.99996}},
+		{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "clean", SplitMethod: "percentage",
+			SplitPercentages: map[string]float64{"Alice": 25, "Bob": 75}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, before, err := group.DebtsBetween("Bob", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before != 4999996+75*100*1000 {
+		t.Fatalf("expected the drifted share to leave Bob owing %d, got %d", 4999996+75*100*1000, before)
+	}
+
+	if n := group.NormalizePercentageMaps(); n != 1 {
+		t.Fatalf("expected 1 expense to be adjusted, got %d", n)
+	}
+	e, err := group.GetExpense(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]float64{"alice": 50, "bob": 50}; !maps.Equal(e.SplitPercentages, want) {
+		t.Fatalf("expected percentages %v, got %v", want, e.SplitPercentages)
+	}
+	_, after, err := group.DebtsBetween("Bob", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != 125*100*1000 {
+		t.Fatalf("expected Bob to owe exactly $125 after normalizing, got %d", after)
+	}
+	if n := group.NormalizePercentageMaps(); n != 0 {
+		t.Fatalf("expected nothing left to normalize, got %d", n)
+	}
+}
+
+func TestBackChargePerson(t *testing.T) {
+	group, err := NewGroup("dinner-club")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "dinner", SplitMethod: "equal", Excluded: []string{"Dave"}},
+		{PaidBy: "Bob", TotalMicroCents: 60 * 100 * 1000, Description: "drinks", SplitMethod: "equal", Excluded: []string{"Dave"}},
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "taxi", SplitMethod: "equal", Excluded: []string{"Dave"}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]int64{"Alice": -25 * 100 * 1000, "Bob": -25 * 100 * 1000, "Dave": 50 * 100 * 1000}
+	preview, err := group.BackChargePerson("dave", []int{1, 2}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(preview, want) {
+		t.Fatalf("expected adjustments %v, got %v", want, preview)
+	}
+	if e, _ := group.GetExpense(1); len(e.ResolvedShares) != 2 {
+		t.Fatalf("expected a preview to leave the expense alone, got shares %v", e.ResolvedShares)
+	}
+
+	committed, err := group.BackChargePerson("Dave", []int{1, 2}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(committed, want) {
+		t.Fatalf("expected adjustments %v, got %v", want, committed)
+	}
+	for id, share := range map[int]int64{1: 30 * 100 * 1000, 2: 20 * 100 * 1000} {
+		e, err := group.GetExpense(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"alice", "bob", "dave"} {
+			if e.ResolvedShares[key] != share {
+				t.Fatalf("expected %s's share of expense %d to be %d, got %d", key, id, share, e.ResolvedShares[key])
+			}
+		}
+	}
+	if e, _ := group.GetExpense(3); len(e.ResolvedShares) != 2 {
+		t.Fatalf("expected the taxi to stay between Alice and Bob, got shares %v", e.ResolvedShares)
+	}
+	_, daveToAlice, err := group.DebtsBetween("Dave", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if daveToAlice != 30*100*1000 {
+		t.Fatalf("expected Dave to owe Alice $30, got %d", daveToAlice)
+	}
+
+	if _, err := group.BackChargePerson("Dave", []int{1}, false); err == nil {
+		t.Fatal("expected back-charging Dave into an expense he already shares to fail")
+	}
+}
+
+func TestExplainBalance(t *testing.T) {
+	group, err := NewGroup("road-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 60 * 100 * 1000, Description: "hotel", SplitMethod: "equal", Excluded: []string{"Bob", "Carol"}},
+		{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "gas", SplitMethod: "equal", Excluded: []string{"Alice", "Carol"}},
+		{PaidBy: "Dave", TotalMicroCents: 20 * 100 * 1000, Description: "snacks", SplitMethod: "equal", Excluded: []string{"Alice", "Bob"}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := group.ExplainBalance("dave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "You owe $45.00 total: $30.00 to Alice for hotel (#1) and $15.00 to Bob for gas (#2). " +
+		"You're owed $10.00 total: $10.00 by Carol for snacks (#3). Net: you owe $35.00."
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if _, err := group.ExplainBalance("Eve"); err == nil {
+		t.Fatal("expected an unknown person to be rejected")
+	}
+}
+
+func TestRequireAllParticipate(t *testing.T) {
+	group, err := NewGroup("strict-house")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	partial := func() *Expense {
+		return &Expense{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "groceries", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1, "Bob": 2}}
+	}
+	if err := group.AddExpense(partial()); err != nil {
+		t.Fatalf("expected a partial split to be accepted by default, got %v", err)
+	}
+
+	group.SetRequireAllParticipate(true)
+	err = group.AddExpense(partial())
+	if err == nil || !strings.Contains(err.Error(), "Carol") {
+		t.Fatalf("expected the partial split to be rejected for leaving Carol out, got %v", err)
+	}
+	if n := group.ExpenseCount(); n != 1 {
+		t.Fatalf("expected the rejected expense not to be stored, got %d expenses", n)
+	}
+
+	excluded := partial()
+	excluded.Excluded = []string{"carol"}
+	if err := group.AddExpense(excluded); err != nil {
+		t.Fatalf("expected the split to be accepted with Carol excluded, got %v", err)
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "pizza", SplitMethod: "equal"}); err != nil {
+		t.Fatalf("expected an equal split among everyone to be accepted, got %v", err)
+	}
+}
+
+func TestSettleUpConverted(t *testing.T) {
+	group, err := NewGroup("euro-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Currency: "EUR", ExchangeRate: 1.10, Description: "hotel", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 60 * 100 * 1000, Description: "dinner", SplitMethod: "equal", Excluded: []string{"Carol"}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := group.SettleUpConverted("EUR", 0); err == nil || !strings.Contains(err.Error(), "USD") {
+		t.Fatalf("expected a missing USD rate to be reported, got %v", err)
+	}
+
+	// The hotel is $99 at 1.10 USD per EUR: Alice +66, Bob -33, Carol -33; the dinner
+	// moves $30 from Alice to Bob: Alice +36, Bob -3, Carol -33.
+	got, err := group.SettleUpConverted("usd", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Settlement{
+		{From: "Carol", To: "Alice", AmountMicroCents: 33 * 100 * 1000},
+		{From: "Bob", To: "Alice", AmountMicroCents: 3 * 100 * 1000},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if plan := group.DissolutionPlan(); !slices.Equal(got, plan) {
+		t.Errorf("expected settling in the home currency to match the dissolution plan %v, got %v", plan, got)
+	}
+
+	// back into EUR at 1/1.10: $33 is 30.00 EUR and $3 is 2.73 EUR
+	got, err = group.SettleUpConverted("EUR", 1/1.10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Settlement{
+		{From: "Carol", To: "Alice", AmountMicroCents: 30 * 100 * 1000},
+		{From: "Bob", To: "Alice", AmountMicroCents: 273 * 1000},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	group, err := NewGroup("statement-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	day := func(d int) time.Time {
+		return time.Date(2026, time.March, d, 0, 0, 0, 0, time.UTC)
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 200 * 100 * 1000, Description: "hotel", SplitMethod: "equal", CreatedAt: day(1).Add(15 * time.Hour)},
+		{PaidBy: "Alice", TotalMicroCents: 80 * 100 * 1000, Description: "dinner", SplitMethod: "equal", CreatedAt: day(2).Add(20 * time.Hour)},
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "cash tip", SplitMethod: "equal", CreatedAt: day(2).Add(21 * time.Hour)},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	extra := StatementEntry{Date: day(3), AmountMicroCents: 35 * 100 * 1000, Description: "PARKING GARAGE"}
+	report, err := group.Reconcile([]StatementEntry{
+		{Date: day(4), AmountMicroCents: 8050 * 1000, Description: "BISTRO 42"},
+		{Date: day(2), AmountMicroCents: 200 * 100 * 1000, Description: "GRAND HOTEL"},
+		extra,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched := map[int]string{}
+	for _, m := range report.Matched {
+		matched[m.ExpenseID] = m.Entry.Description
+	}
+	if want := map[int]string{1: "GRAND HOTEL", 2: "BISTRO 42"}; !maps.Equal(matched, want) {
+		t.Fatalf("expected matches %v, got %v", want, matched)
+	}
+	if !slices.Equal(report.UnmatchedExpenses, []int{3}) {
+		t.Fatalf("expected the cash tip to be missing from the statement, got %v", report.UnmatchedExpenses)
+	}
+	if len(report.UnmatchedEntries) != 1 || report.UnmatchedEntries[0] != extra {
+		t.Fatalf("expected the parking charge to be unlogged, got %v", report.UnmatchedEntries)
+	}
+
+	if _, err := group.Reconcile([]StatementEntry{{Date: day(1)}}); err == nil {
+		t.Fatal("expected an entry without an amount to be rejected")
+	}
+}
+
+func TestSettleUpWithConstraints(t *testing.T) {
+	group, err := NewGroup("no-app-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice owes Bob $10 and Bob owes Carol $10.
+	for _, e := range []*Expense{
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "snacks", SplitMethod: "equal", Excluded: []string{"Carol"}},
+		{PaidBy: "Carol", TotalMicroCents: 20 * 100 * 1000, Description: "tickets", SplitMethod: "equal", Excluded: []string{"Alice"}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if details := group.GetExpenseDetails(); details["Bob to pay Carol"] != 10 {
+		t.Fatalf("expected Bob to owe Carol $10 pairwise, got %v", details)
+	}
+
+	got, err := group.SettleUpWithConstraints([]string{"bob"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Settlement{{From: "Alice", To: "Carol", AmountMicroCents: 10 * 100 * 1000}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected Bob to be routed around with %v, got %v", want, got)
+	}
+
+	if _, err := group.SettleUpWithConstraints([]string{"Alice"}, nil); err == nil || !strings.Contains(err.Error(), "Alice owes") {
+		t.Fatalf("expected settling to be impossible when Alice can't send, got %v", err)
+	}
+	if _, err := group.SettleUpWithConstraints(nil, []string{"Carol"}); err == nil {
+		t.Fatal("expected settling to be impossible when Carol can't receive")
+	}
+	if _, err := group.SettleUpWithConstraints([]string{"Eve"}, nil); err == nil {
+		t.Fatal("expected an unknown person to be rejected")
+	}
+}
+
+func TestPersonStatement(t *testing.T) {
+	group, err := NewGroup("bill-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "hotel", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "gas", SplitMethod: "equal", Excluded: []string{"Carol"}},
+		{PaidBy: "Carol", TotalMicroCents: 10 * 100 * 1000, Description: "coffee", SplitMethod: "equal", Excluded: []string{"Bob"}},
+		{PaidByMap: map[string]float64{"Carol": 10, "Alice": 20}, TotalMicroCents: 30 * 100 * 1000, Description: "snacks", SplitMethod: "equal"},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statement, err := group.PersonStatement("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bob's shares are $30, $15 and $10 and he paid $30, so he owes $25; Alice is the only creditor.
+	for _, want := range []string{
+		"Statement for Bob in bill-trip",
+		"#1 hotel, paid by Alice: $30.00",
+		"#2 gas, paid by Bob: $15.00",
+		"#4 snacks, paid by Alice $20.00 and Carol $10.00: $10.00",
+		"You paid:\n  #2 gas: $30.00",
+		"Net: you owe $25.00",
+		"pay Alice $25.00",
+	} {
+		if !strings.Contains(statement, want) {
+			t.Fatalf("expected the statement to contain %q, got:\n%s", want, statement)
+		}
+	}
+	if strings.Contains(statement, "coffee") {
+		t.Fatalf("expected Carol's coffee to be left out of Bob's statement, got:\n%s", statement)
+	}
+	if _, err := group.PersonStatement("Eve"); err == nil {
+		t.Fatal("expected an unknown person to be rejected")
+	}
+}
+
+func TestTipSplitWeights(t *testing.T) {
+	group, err := NewGroup("tip-dinner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a $90 bill split equally plus a $20 tip split 2:1:1
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 110 * 100 * 1000, TipMicroCents: 20 * 100 * 1000, Description: "dinner",
+		SplitMethod: "equal", TipSplitWeights: map[string]float64{"Alice": 2, "Bob": 1, "Carol": 1}}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"alice": 40 * 100 * 1000, "bob": 35 * 100 * 1000, "carol": 35 * 100 * 1000}
+	if !maps.Equal(e.ResolvedShares, want) {
+		t.Fatalf("expected shares %v, got %v", want, e.ResolvedShares)
+	}
+	_, net, err := group.DebtsBetween("Bob", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net != 35*100*1000 {
+		t.Fatalf("expected Bob to owe Alice $35, got %d", net)
+	}
+
+	// without tip weights the tip is split with the bill
+	plain := &Expense{PaidBy: "Alice", TotalMicroCents: 120 * 100 * 1000, TipMicroCents: 30 * 100 * 1000, Description: "lunch", SplitMethod: "equal"}
+	if err := group.AddExpense(plain); err != nil {
+		t.Fatal(err)
+	}
+	if plain.ResolvedShares["bob"] != 40*100*1000 {
+		t.Fatalf("expected the tip to be split equally with the bill, got %v", plain.ResolvedShares)
+	}
+
+	for _, bad := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, TipMicroCents: 10 * 100 * 1000, Description: "brunch", SplitMethod: "equal",
+			TipSplitWeights: map[string]float64{"Eve": 1}},
+		{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "brunch", SplitMethod: "equal",
+			TipSplitWeights: map[string]float64{"Bob": 1}},
+		{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, TipMicroCents: 10 * 100 * 1000, Description: "brunch", SplitMethod: "equal",
+			TipSplitWeights: map[string]float64{"Bob": 1}, Excluded: []string{"Bob"}},
+	} {
+		if err := group.AddExpense(bad); err == nil {
+			t.Fatalf("expected tip split weights %v with tip %d to be rejected", bad.TipSplitWeights, bad.TipMicroCents)
+		}
+	}
+}
+
+func TestResolveName(t *testing.T) {
+	group, err := NewGroup("typo-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolved, candidates, err := group.ResolveName(" bob ")
+	if err != nil || resolved != "Bob" || len(candidates) != 0 {
+		t.Fatalf("expected an exact match for Bob, got %q %v %v", resolved, candidates, err)
+	}
+
+	resolved, candidates, err = group.ResolveName("Alcie")
+	if resolved != "" || !slices.Equal(candidates, []string{"Alice"}) {
+		t.Fatalf("expected Alice as the only candidate, got %q %v", resolved, candidates)
+	}
+	if err == nil || !strings.Contains(err.Error(), "did you mean Alice?") {
+		t.Fatalf("expected a did-you-mean error, got %v", err)
+	}
+
+	if _, candidates, err := group.ResolveName("Zed"); err == nil || len(candidates) != 0 {
+		t.Fatalf("expected no candidates for Zed, got %v %v", candidates, err)
+	}
+}
+
+func TestSpendingByPeriod(t *testing.T) {
+	group, err := NewGroup("roommates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 1200 * 100 * 1000, Description: "rent", SplitMethod: "equal",
+			CreatedAt: time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)},
+		{PaidBy: "Bob", TotalMicroCents: 80 * 100 * 1000, DiscountMicroCents: 5 * 100 * 1000, Description: "utilities", SplitMethod: "equal",
+			CreatedAt: time.Date(2024, time.March, 28, 9, 0, 0, 0, time.UTC)},
+		{PaidBy: "Alice", TotalMicroCents: 1200 * 100 * 1000, Description: "rent", SplitMethod: "equal",
+			CreatedAt: time.Date(2024, time.April, 1, 9, 0, 0, 0, time.UTC)},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	months, err := group.SpendingByPeriod("month")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"2024-03": 1275 * 100 * 1000, "2024-04": 1200 * 100 * 1000}
+	if !maps.Equal(months, want) {
+		t.Fatalf("expected %v, got %v", want, months)
+	}
+
+	weeks, err := group.SpendingByPeriod("week")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]int64{"2024-W09": 1200 * 100 * 1000, "2024-W13": 75 * 100 * 1000, "2024-W14": 1200 * 100 * 1000}
+	if !maps.Equal(weeks, want) {
+		t.Fatalf("expected %v, got %v", want, weeks)
+	}
+
+	if _, err := group.SpendingByPeriod("year"); err == nil {
+		t.Fatal("expected an unknown granularity to be rejected")
+	}
+}
+
+func TestSettlementVelocity(t *testing.T) {
+	group, err := NewGroup("slow-payers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "couch", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if v := group.SettlementVelocity(); v != 0 {
+		t.Fatalf("expected no velocity without payments, got %v", v)
+	}
+	if _, _, ok := group.ProjectedSettlement(); ok {
+		t.Fatal("expected no projection without payments")
+	}
+
+	// Bob pays $10 twice, four days apart
+	start := time.Date(2026, time.May, 1, 12, 0, 0, 0, time.UTC)
+	group.mu.Lock()
+	for _, at := range []time.Time{start, start.AddDate(0, 0, 4)} {
+		if err := group.addPayment("bob", "alice", 10*100*1000, "", at); err != nil {
+			group.mu.Unlock()
+			t.Fatal(err)
+		}
+	}
+	group.mu.Unlock()
+
+	if v := group.SettlementVelocity(); v != 5 {
+		t.Fatalf("expected $5/day, got %v", v)
+	}
+	outstanding, settledBy, ok := group.ProjectedSettlement()
+	if !ok || outstanding != 30*100*1000 {
+		t.Fatalf("expected $30 outstanding, got %d (ok=%v)", outstanding, ok)
+	}
+	if want := start.AddDate(0, 0, 10); !settledBy.Equal(want) {
+		t.Fatalf("expected the $30 left to be paid off 6 days after the last payment, on %v, got %v", want, settledBy)
+	}
+}
+
+func TestSplitExpenseIntoItems(t *testing.T) {
+	group, err := NewGroup("itemized-dinner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "dinner", SplitMethod: "equal",
+		Labels: []string{"trip"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := group.SplitExpenseIntoItems(1, []ItemSpec{
+		{Description: "food", AmountMicroCents: 60 * 100 * 1000},
+		{Description: "drinks", AmountMicroCents: 30 * 100 * 1000},
+	}); err == nil {
+		t.Fatal("expected items that don't add up to the total to be rejected")
+	}
+
+	ids, err := group.SplitExpenseIntoItems(1, []ItemSpec{
+		{Description: "food", AmountMicroCents: 60 * 100 * 1000},
+		{Description: "drinks", AmountMicroCents: 40 * 100 * 1000, Participants: []string{"alice", "Bob"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, []int{2, 3}) {
+		t.Fatalf("expected new expenses 2 and 3, got %v", ids)
+	}
+	if _, err := group.GetExpense(1); err == nil {
+		t.Fatal("expected the original expense to be removed")
+	}
+	drinks, err := group.GetExpense(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{"alice": 20 * 100 * 1000, "bob": 20 * 100 * 1000}; !maps.Equal(drinks.ResolvedShares, want) {
+		t.Fatalf("expected drinks shares %v, got %v", want, drinks.ResolvedShares)
+	}
+	if !slices.Equal(drinks.Labels, []string{"trip"}) {
+		t.Fatalf("expected the items to keep the original labels, got %v", drinks.Labels)
+	}
+
+	// Bob: $20 food + $20 drinks; Carol: $20 food only
+	for name, want := range map[string]int64{"Bob": 40 * 100 * 1000, "Carol": 20 * 100 * 1000} {
+		_, net, err := group.DebtsBetween(name, "Alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if net != want {
+			t.Fatalf("expected %s to owe Alice %d, got %d", name, want, net)
+		}
+	}
+}
+
+func TestSplitExpenseIntoItemsRequireAllParticipate(t *testing.T) {
+	group, err := NewGroup("strict-itemized-dinner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	group.SetRequireAllParticipate(true)
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "dinner", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []ItemSpec{
+		{Description: "food", AmountMicroCents: 60 * 100 * 1000},
+		{Description: "drinks", AmountMicroCents: 40 * 100 * 1000, Participants: []string{"Alice", "Bob"}},
+	}
+	if _, err := group.SplitExpenseIntoItems(1, items); err == nil || !strings.Contains(err.Error(), "item 2") {
+		t.Fatalf("expected the item leaving Carol out to be rejected, got %v", err)
+	}
+	if _, err := group.GetExpense(1); err != nil {
+		t.Fatal("expected the original expense to stay after a rejected itemization")
+	}
+
+	items[1].Excluded = []string{"carol"}
+	if _, err := group.SplitExpenseIntoItems(1, items); err != nil {
+		t.Fatalf("expected an explicit exclusion to be accepted, got %v", err)
+	}
+}
+
+func TestPersonCategoryShare(t *testing.T) {
+	g, err := NewGroup("category-share")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "dinner", SplitMethod: "equal", Labels: []string{"food"}},
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "snacks", SplitMethod: "equal", Labels: []string{"food"}, Excluded: []string{"Charlie"}},
+		{PaidBy: "Charlie", TotalMicroCents: 60 * 100 * 1000, Description: "hotel", SplitMethod: "equal", Labels: []string{"lodging"}, Excluded: []string{"Bob"}},
+	} {
+		if err := g.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name, category string
+		want           int64
+	}{
+		{"Alice", "food", 40 * 100 * 1000},
+		{"bob", "FOOD", 40 * 100 * 1000},
+		{"Charlie", "food", 30 * 100 * 1000},
+		{"Alice", "lodging", 30 * 100 * 1000},
+		{"Bob", "lodging", 0},
+	}
+	for _, tt := range tests {
+		got, err := g.PersonCategoryShare(tt.name, tt.category)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tt.name, tt.category, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s/%s: expected %d, got %d", tt.name, tt.category, tt.want, got)
+		}
+	}
+
+	if _, err := g.PersonCategoryShare("Dave", "food"); err == nil {
+		t.Error("expected an error for an unknown person")
+	}
+	if _, err := g.PersonCategoryShare("Alice", "travel"); err == nil {
+		t.Error("expected an error for a label no expense carries")
+	}
+}
+
+func TestApplySplitTemplate(t *testing.T) {
+	g, err := NewGroup("bulk-resplit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "groceries", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 60 * 100 * 1000, Description: "fuel", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "cabin", SplitMethod: "percentage",
+			SplitPercentages: map[string]float64{"Alice": 50, "Bob": 50}},
+	} {
+		if err := g.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := g.GetExpenseDetails()["Bob to pay Alice"]; got != 90 {
+		t.Fatalf("expected Bob to owe $90 before, got %v", got)
+	}
+
+	updated, errs := g.ApplySplitTemplate([]int{1, 2, 3, 7}, "weights", map[string]float64{"Alice": 1, "Bob": 2})
+	if !slices.Equal(updated, []int{1, 2, 3}) {
+		t.Fatalf("expected expenses 1, 2 and 3 updated, got %v", updated)
+	}
+	if len(errs) != 1 || errs["7"] == nil {
+		t.Fatalf("expected only an error for expense 7, got %v", errs)
+	}
+	for id := 1; id <= 3; id++ {
+		e, err := g.GetExpense(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.SplitMethod != "weights" || len(e.SplitPercentages) != 0 {
+			t.Errorf("expense(%d): expected a weights split, got %s %v", id, e.SplitMethod, e.SplitPercentages)
+		}
+		if want := e.TotalMicroCents * 2 / 3; e.ResolvedShares["bob"] != want {
+			t.Errorf("expense(%d): expected Bob's share %d, got %d", id, want, e.ResolvedShares["bob"])
+		}
+	}
+	if got := g.GetExpenseDetails()["Bob to pay Alice"]; got != 120 {
+		t.Errorf("expected Bob to owe $120 after, got %v", got)
+	}
+
+	if _, errs := g.ApplySplitTemplate([]int{1}, "equal", map[string]float64{"Alice": 1}); errs["template"] == nil {
+		t.Error("expected a template error for equal with params")
+	}
+}
+
+func TestExitPlan(t *testing.T) {
+	g, err := NewGroup("early-leaver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice and Carol are owed $60 and $20; Bob owes $40 and Dave $40.
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 80 * 100 * 1000, Description: "hotel", SplitMethod: "equal"},
+		{PaidBy: "Carol", TotalMicroCents: 40 * 100 * 1000, Description: "food", SplitMethod: "equal"},
+	} {
+		if err := g.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"Bob", "Alice"} {
+		plan, err := g.ExitPlan(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		net := int64(0)
+		for _, s := range plan {
+			switch name {
+			case s.From:
+				net += s.AmountMicroCents
+			case s.To:
+				net -= s.AmountMicroCents
+			default:
+				t.Errorf("%s: transfer %v doesn't involve them", name, s)
+			}
+		}
+		for _, b := range g.MembersByBalance() {
+			if b.Name == name && b.BalanceMicroCents+net != 0 {
+				t.Errorf("%s: plan %v leaves a balance of %d", name, plan, b.BalanceMicroCents+net)
+			}
+		}
+		if name == "Bob" && (len(plan) != 1 || plan[0].To != "Alice") {
+			t.Errorf("expected Bob to pay only Alice, got %v", plan)
+		}
+		if name == "Alice" && len(plan) != 2 {
+			t.Errorf("expected Alice to be paid by Bob and Dave, got %v", plan)
+		}
+	}
+
+	if _, err := g.ExitPlan("Eve"); err == nil {
+		t.Error("expected an error for an unknown person")
+	}
+}
+
+func TestSplitFairnessDelta(t *testing.T) {
+	g, err := NewGroup("fairness-delta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 60 * 100 * 1000, Description: "groceries", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 120 * 100 * 1000, Description: "cabin", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1, "Bob": 1, "Carol": 4}},
+	} {
+		if err := g.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// an equal split of the $180 would be $60 each
+	want := map[string]int64{
+		"Alice": -20 * 100 * 1000,
+		"Bob":   -20 * 100 * 1000,
+		"Carol": 40 * 100 * 1000,
+	}
+	if got := g.SplitFairnessDelta(); !maps.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestVerifyAll(t *testing.T) {
+	clean, err := Create("verify-clean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Delete(clean.Name) })
+	broken, err := Create("verify-broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Delete(broken.Name) })
+	for _, g := range []*Group{clean, broken} {
+		for _, name := range []string{"Alice", "Bob"} {
+			if err := g.AddPerson(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 20 * 100 * 1000, Description: "lunch", SplitMethod: "equal"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	broken.mu.Lock()
+	broken.expenses[1].ResolvedShares["bob"] = -10 * 100 * 1000
+	broken.mu.Unlock()
+
+	problems := VerifyAll()
+	if errs, ok := problems[clean.Name]; !ok || len(errs) != 0 {
+		t.Errorf("expected %s checked without problems, got %v (checked: %v)", clean.Name, errs, ok)
+	}
+	if errs := problems[broken.Name]; len(errs) == 0 {
+		t.Errorf("expected problems in %s", broken.Name)
+	}
+}
+
+func TestReadOnlyToken(t *testing.T) {
+	g, err := NewGroup("shared-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "museum", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.ExportReadOnlyToken(); !errors.Is(err, errNoReadOnlyTokenKey) {
+		t.Fatalf("expected no token without a key, got %v", err)
+	}
+	if err := SetReadOnlyTokenKey([]byte("0123456789abcdef0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { readOnlyTokenKey = nil })
+
+	token, err := g.ExportReadOnlyToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := ImportReadOnlyToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Name != g.Name || !slices.Equal(view.GetPeople(), g.GetPeople()) {
+		t.Errorf("expected %s with %v, got %s with %v", g.Name, g.GetPeople(), view.Name, view.GetPeople())
+	}
+	if !maps.Equal(view.GetExpenseDetails(), g.GetExpenseDetails()) {
+		t.Errorf("expected debts %v, got %v", g.GetExpenseDetails(), view.GetExpenseDetails())
+	}
+	if _, exists := Get(g.Name); exists {
+		t.Error("expected the imported group to stay out of the store")
+	}
+
+	// flip one character of the payload
+	i := len(readOnlyTokenPrefix) + 10
+	flipped := byte('A')
+	if token[i] == 'A' {
+		flipped = 'B'
+	}
+	tampered := token[:i] + string(flipped) + token[i+1:]
+	if _, err := ImportReadOnlyToken(tampered); err == nil {
+		t.Error("expected a tampered token to be rejected")
+	}
+	if _, err := ImportReadOnlyToken("not a token"); err == nil {
+		t.Error("expected a malformed token to be rejected")
+	}
+
+	// a correctly signed payload that inflates past the cap
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(make([]byte, maxReadOnlyTokenBytes+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signReadOnlyPayload(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bomb := readOnlyTokenPrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes()) + "." + base64.RawURLEncoding.EncodeToString(sig)
+	if _, err := ImportReadOnlyToken(bomb); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("expected an oversized payload to be rejected, got %v", err)
+	}
+}
+
+func TestSuggestBuffer(t *testing.T) {
+	g, err := NewGroup("buffer-pot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice is owed $40; Bob and Carol owe $20 each
+	if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 60 * 100 * 1000, Description: "fuel", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int64{
+		"Alice": 60 * 100 * 1000,
+		"Bob":   120 * 100 * 1000,
+		"Carol": 120 * 100 * 1000,
+	}
+	got := g.SuggestBuffer(300 * 100 * 1000)
+	if !maps.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// owed more than her share, Alice is paid out of the pot
+	if got := g.SuggestBuffer(30 * 100 * 1000)["Alice"]; got != -30*100*1000 {
+		t.Errorf("expected Alice to take $30 out, got %d", got)
+	}
+	if got := g.SuggestBuffer(0); len(got) != 0 {
+		t.Errorf("expected no buffer for nothing anticipated, got %v", got)
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	g, err := NewGroup("double-entry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "Pizza", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "pizza", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 31 * 100 * 1000, Description: "pizza", SplitMethod: "equal"},
+	} {
+		if err := g.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := g.MergeDuplicates([]int{1, 3}); err == nil {
+		t.Fatal("expected an error merging expenses with different amounts")
+	}
+	if err := g.DeleteExpense(3); err != nil {
+		t.Fatal(err)
+	}
+	if got := g.GetExpenseDetails()["Bob to pay Alice"]; got != 30 {
+		t.Fatalf("expected Bob to owe $30 before merging, got %v", got)
+	}
+
+	kept, err := g.MergeDuplicates([]int{2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept != 1 {
+		t.Errorf("expected expense 1 kept, got %d", kept)
+	}
+	if _, err := g.GetExpense(2); err == nil {
+		t.Error("expected expense 2 deleted")
+	}
+	if got := g.GetExpenseDetails()["Bob to pay Alice"]; got != 15 {
+		t.Errorf("expected Bob to owe $15 after merging, got %v", got)
+	}
+}
+
+func TestExactSplit(t *testing.T) {
+	g, err := NewGroup("exact-receipt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 1980 * 1000, Description: "lunch", SplitMethod: "exact",
+		SplitExactMicroCents: map[string]int64{"Alice": 1250 * 1000, "bob": 730 * 1000}}
+	if err := g.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"alice": 1250 * 1000, "bob": 730 * 1000}
+	if !maps.Equal(e.ResolvedShares, want) {
+		t.Errorf("expected shares %v, got %v", want, e.ResolvedShares)
+	}
+	if got := g.GetExpenseDetails()["Bob to pay Alice"]; got != 7.30 {
+		t.Errorf("expected Bob to owe $7.30, got %v", got)
+	}
+
+	tests := []struct {
+		name  string
+		exact map[string]int64
+	}{
+		{"short of the total", map[string]int64{"Alice": 1250 * 1000, "Bob": 729 * 1000}},
+		{"non-member", map[string]int64{"Alice": 1250 * 1000, "Dave": 730 * 1000}},
+		{"negative amount", map[string]int64{"Alice": 2000 * 1000, "Bob": -20 * 1000}},
+		{"missing map", nil},
+	}
+	for _, tt := range tests {
+		bad := &Expense{PaidBy: "Alice", TotalMicroCents: 1980 * 1000, Description: "lunch", SplitMethod: "exact", SplitExactMicroCents: tt.exact}
+		if err := g.AddExpense(bad); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 1980 * 1000, Description: "lunch", SplitMethod: "equal",
+		SplitExactMicroCents: map[string]int64{"Alice": 1980 * 1000}}); err == nil {
+		t.Error("expected an error for split_exact on an equal split")
+	}
+}
+
+func TestExactSplitWithTip(t *testing.T) {
+	g, err := NewGroup("exact-tip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// $9 split exactly plus a $1 tip weighted 1:1:1, which doesn't divide evenly
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, TipMicroCents: 100 * 1000, Description: "brunch", SplitMethod: "exact",
+		SplitExactMicroCents: map[string]int64{"Alice": 3 * 100 * 1000, "Bob": 3 * 100 * 1000, "Carol": 3 * 100 * 1000},
+		TipSplitWeights:      map[string]float64{"Alice": 1, "Bob": 1, "Carol": 1}}
+	if err := g.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	sum := int64(0)
+	for _, share := range e.ResolvedShares {
+		sum += share
+	}
+	if sum != e.TotalMicroCents {
+		t.Errorf("expected the shares to add up to %d, got %d (%v)", e.TotalMicroCents, sum, e.ResolvedShares)
+	}
+	if len(e.RemainderMicroCents) != 1 {
+		t.Errorf("expected the tip's leftover micro cent to be recorded once, got %v", e.RemainderMicroCents)
+	}
+
+	// the exact amounts cover the shared part only, not the tip
+	bad := &Expense{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, TipMicroCents: 100 * 1000, Description: "brunch", SplitMethod: "exact",
+		SplitExactMicroCents: map[string]int64{"Alice": 4 * 100 * 1000, "Bob": 3 * 100 * 1000, "Carol": 3 * 100 * 1000},
+		TipSplitWeights:      map[string]float64{"Alice": 1, "Bob": 1, "Carol": 1}}
+	if err := g.AddExpense(bad); err == nil || !strings.Contains(err.Error(), "shared amount $9.00") {
+		t.Errorf("expected an error naming the $9.00 shared amount, got %v", err)
+	}
+}
+
+func TestBestPayerFor(t *testing.T) {
+	g, err := NewGroup("next-payer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice is owed $70; Bob owes $50 and Carol $20
+	if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 70 * 100 * 1000, Description: "rental", SplitMethod: "weights",
+		SplitWeights: map[string]float64{"Bob": 5, "Carol": 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		amount int64
+		want   string
+	}{
+		// either debtor leaves $120 owed, but Carol ends up even and Bob doesn't
+		{30 * 100 * 1000, "Carol"},
+		// Carol would overshoot into credit; Bob paying leaves less owed
+		{60 * 100 * 1000, "Bob"},
+	}
+	for _, tt := range tests {
+		if got := g.BestPayerFor(tt.amount); got != tt.want {
+			t.Errorf("amount %d: expected %s, got %s", tt.amount, tt.want, got)
+		}
+	}
+	if got := g.BestPayerFor(0); got != "" {
+		t.Errorf("expected no payer for a zero amount, got %s", got)
+	}
+}
+
+func TestRecordPayment(t *testing.T) {
+	g, err := NewGroup("record-payment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 80 * 100 * 1000, Description: "concert", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+
+	remaining, err := g.RecordPaymentWithMemo("bob", "Alice", 25*100*1000, "Venmo on 3/5", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 15*100*1000 {
+		t.Errorf("expected $15 still owed, got %d", remaining)
+	}
+	if got := g.GetExpenseDetails()["Bob to pay Alice"]; got != 15 {
+		t.Errorf("expected Bob to owe $15, got %v", got)
+	}
+	if payments := g.CompletedPayments(); len(payments) != 1 || payments[0].AmountMicroCents != 25*100*1000 || payments[0].Memo != "Venmo on 3/5" {
+		t.Errorf("expected the $25 payment recorded with its memo, got %v", payments)
+	}
+
+	if err := g.RecordPayment("Bob", "Alice", 20*100*1000); err == nil {
+		t.Fatal("expected an overpayment to be rejected")
+	}
+	if err := g.RecordPayment("Alice", "Bob", 1000); err == nil {
+		t.Fatal("expected a payment against the direction of the debt to be rejected")
+	}
+	remaining, err = g.RecordPaymentWithMemo("Bob", "Alice", 20*100*1000, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining != -5*100*1000 {
+		t.Errorf("expected Alice to owe $5 back, got %d", remaining)
+	}
+	if _, err := g.RecordPaymentWithMemo("Bob", "Carol", 1000, "", true); err == nil {
+		t.Error("expected an error for an unknown person")
+	}
+}
+
+func TestSoleBeneficiaryExpenses(t *testing.T) {
+	g, err := NewGroup("sole-beneficiary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "dinner", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 45 * 100 * 1000, Description: "Bob's ticket", SplitMethod: "weights", SplitWeights: map[string]float64{"Bob": 1}},
+		{PaidBy: "Carol", TotalMicroCents: 12 * 100 * 1000, Description: "Bob's taxi", SplitMethod: "exact",
+			SplitExactMicroCents: map[string]int64{"Bob": 12 * 100 * 1000, "Carol": 0}},
+		{PaidBy: "Carol", TotalMicroCents: 20 * 100 * 1000, Description: "snacks", SplitMethod: "equal", Excluded: []string{"Alice"}},
+	} {
+		if err := g.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := g.SoleBeneficiaryExpenses("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != 2 || got[1].ID != 3 {
+		t.Errorf("expected expenses 2 and 3, got %v", got)
+	}
+	if got, err := g.SoleBeneficiaryExpenses("Alice"); err != nil || len(got) != 0 {
+		t.Errorf("expected nothing for Alice, got %v (err %v)", got, err)
+	}
+	if _, err := g.SoleBeneficiaryExpenses("Dave"); err == nil {
+		t.Error("expected an error for an unknown person")
+	}
+}
+
+func TestRemovePerson(t *testing.T) {
+	g, err := NewGroup("typo-member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Bobb", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "dinner", SplitMethod: "equal",
+		Excluded: []string{"Bobb"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.RecordPaymentWithMemo("Carol", "Bobb", 5*100*1000, "", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePerson("bobb", false); err == nil || !strings.Contains(err.Error(), "Bobb owes Carol $5.00") {
+		t.Errorf("expected the outstanding balance listed, got %v", err)
+	}
+	if err := g.RemovePerson("bobb", true); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(g.GetPeople(), "Bobb") {
+		t.Errorf("expected Bobb removed, got %v", g.GetPeople())
+	}
+	g.mu.Lock()
+	_, node := g.graph.nodes["bobb"]
+	g.mu.Unlock()
+	if node {
+		t.Error("expected Bobb's graph node removed")
+	}
+	if got := g.GetExpenseDetails(); len(got) != 2 || got["Bob to pay Alice"] != 10 || got["Carol to pay Alice"] != 10 {
+		t.Errorf("expected the other debts untouched, got %v", got)
+	}
+	if errs := g.Verify(); len(errs) != 0 {
+		t.Errorf("expected a consistent group, got %v", errs)
+	}
+
+	if err := g.AddPerson("Dave"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePerson("Dave", false); err != nil {
+		t.Errorf("expected a settled member without expenses to be removable, got %v", err)
+	}
+}
+
+func TestRemovePersonInExpenses(t *testing.T) {
+	g, err := NewGroup("left-early")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "dinner", SplitMethod: "percentage",
+			SplitPercentages: map[string]float64{"Alice": 25, "Bob": 25, "Carol": 25, "Dave": 25}},
+		{PaidBy: "Dave", TotalMicroCents: 30 * 100 * 1000, Description: "taxi", SplitMethod: "equal", Excluded: []string{"Carol"}},
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "snacks", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "museum", SplitMethod: "exact",
+			SplitExactMicroCents: map[string]int64{"Dave": 10 * 100 * 1000}},
+		{PaidByMap: map[string]float64{"Alice": 6, "Carol": 6}, TotalMicroCents: 12 * 100 * 1000, Description: "coffee", SplitMethod: "equal",
+			Excluded: []string{"Dave"}},
+	}
+	for _, e := range expenses {
+		if err := g.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Dave owes Alice $20 and Bob $5, and Alice and Bob owe him $10 each for the taxi
+	if err := g.RecordPayment("Dave", "Alice", 10*100*1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePerson("Dave", false); err == nil || !strings.Contains(err.Error(), "Bob owes Dave $5.00") {
+		t.Errorf("expected the outstanding balance listed, got %v", err)
+	}
+	if err := g.RecordPayment("Bob", "Dave", 5*100*1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePerson("Carol", true); err == nil || !strings.Contains(err.Error(), "expenses 5 paid by several people") {
+		t.Errorf("expected the shared payment to block even a forced removal, got %v", err)
+	}
+
+	before := g.GetExpenseDetails()
+	if err := g.RemovePerson("Dave", false); err != nil {
+		t.Fatalf("expected a settled member to be removable, got %v", err)
+	}
+	if got := g.GetExpenseDetails(); !maps.Equal(got, before) {
+		t.Errorf("expected the others' debts untouched, got %v, want %v", got, before)
+	}
+	if _, err := g.GetExpense(2); err == nil {
+		t.Error("expected the taxi Dave paid for deleted")
+	}
+	if _, err := g.GetExpense(4); err == nil {
+		t.Error("expected the museum only Dave had a share of deleted")
+	}
+	dinner, err := g.GetExpense(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dinner.TotalMicroCents != 30*100*1000 || dinner.SplitMethod != "weights" || len(dinner.SplitWeights) != 3 || len(dinner.ResolvedShares) != 3 {
+		t.Errorf("expected Dave's $10 taken out of the dinner, got %+v", dinner)
+	}
+	snacks, err := g.GetExpense(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snacks.TotalMicroCents != 15*100*1000 || len(snacks.ResolvedShares) != 3 {
+		t.Errorf("expected Dave's $5 taken out of the snacks, got %+v", snacks)
+	}
+	if errs := g.Verify(); len(errs) != 0 {
+		t.Errorf("expected a consistent group, got %v", errs)
+	}
+	if err := g.RestoreSnapshot(g.Snapshot()); err != nil {
+		t.Errorf("expected the group to round-trip through a snapshot, got %v", err)
+	}
+	// $60 split by the remaining weights is $20 each, $10 more for Bob and Carol
+	if before, after, err := g.PreviewEditAmount(1, 60*100*1000); err != nil || after["Carol to pay Alice"]-before["Carol to pay Alice"] != 10 {
+		t.Errorf("expected the rewritten dinner to split again among the rest, got %v -> %v, %v", before, after, err)
+	}
+}
+
+func TestRemovePersonFromCoveredExpense(t *testing.T) {
+	g, err := NewGroup("covered-dinner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// $15 of the $30 is covered, so everyone owes $5
+	if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "dinner", SplitMethod: "equal",
+		CoveredPercent: 50, CoveredBy: "Company"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePerson("Bob", true); err != nil {
+		t.Fatal(err)
+	}
+
+	dinner, err := g.GetExpense(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dinner.TotalMicroCents != 25*100*1000 || dinner.CoveredMicroCents() != 15*100*1000 {
+		t.Fatalf("expected $15 of the remaining $25 still covered, got %+v", dinner)
+	}
+	// splitting the expense again must give the shares it was left with
+	before, after, err := g.PreviewEditAmount(1, dinner.TotalMicroCents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(before, after) || before["Carol to pay Alice"] != 5 {
+		t.Errorf("expected Carol to keep owing Alice $5 when re-resolved, got %v -> %v", before, after)
+	}
+}
+
+func TestRemovePersonNamedInRecurring(t *testing.T) {
+	g, err := NewGroup("recurring-member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rent := Expense{PaidBy: "Carol", TotalMicroCents: 900 * 100 * 1000, Description: "rent", SplitMethod: "equal"}
+	id, err := g.AddRecurring(rent, 30*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utilities := Expense{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "utilities", SplitMethod: "equal"}
+	if _, err := g.AddRecurring(utilities, 30*24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.RemovePerson("Carol", false); err == nil || !strings.Contains(err.Error(), fmt.Sprintf("recurring expense %d names Carol", id)) {
+		t.Errorf("expected the recurring expense listed, got %v", err)
+	}
+	if err := g.RemovePerson("Bob", false); err != nil {
+		t.Errorf("expected an equal split over everyone not to block the removal, got %v", err)
+	}
+	if err := g.RemovePerson("Carol", true); err != nil {
+		t.Fatal(err)
+	}
+	if list := g.ListRecurring(); len(list) != 1 || list[0].Template.Description != "utilities" {
+		t.Errorf("expected only the utilities left, got %v", list)
+	}
+}
+
+func TestApplyGroupCredit(t *testing.T) {
+	g, err := NewGroup("late-refund")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := g.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Alice bore $60, Bob $30 and Carol $30 of the $120 Alice paid
+	if err := g.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 120 * 100 * 1000, Description: "villa", SplitMethod: "weights",
+		SplitWeights: map[string]float64{"Alice": 2, "Bob": 1, "Carol": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	before := map[string]int64{}
+	for _, b := range g.MembersByBalance() {
+		before[b.Name] = b.BalanceMicroCents
+	}
+
+	if err := g.ApplyGroupCredit(40 * 100 * 1000); err != nil {
+		t.Fatal(err)
+	}
+	// credits of $20, $10 and $10, all refunded to Alice
+	want := map[string]int64{
+		"Alice": -20 * 100 * 1000,
+		"Bob":   10 * 100 * 1000,
+		"Carol": 10 * 100 * 1000,
+	}
+	total := int64(0)
+	for _, b := range g.MembersByBalance() {
+		delta := b.BalanceMicroCents - before[b.Name]
+		if delta != want[b.Name] {
+			t.Errorf("%s: expected balance to move by %d, got %d", b.Name, want[b.Name], delta)
+		}
+		total += b.BalanceMicroCents
+	}
+	if total != 0 {
+		t.Errorf("expected balances to net to zero, got %d", total)
+	}
+	credits := g.ExpensesWithLabel("group-credit")
+	if len(credits) != 1 || credits[0].TotalMicroCents != 40*100*1000 {
+		t.Fatalf("expected one $40 group-credit expense, got %v", credits)
+	}
+	sum := 0.0
+	for _, dollars := range credits[0].PaidByMap {
+		sum += dollars
+	}
+	if sum != 40 {
+		t.Errorf("expected the whole $40 distributed, got %v", sum)
+	}
+
+	// the credit isn't spending
+	if perDay, err := g.CostPerDay(); err != nil || perDay != 120 {
+		t.Errorf("expected $120 per day, got %v, %v", perDay, err)
+	}
+	if months, err := g.SpendingByPeriod("month"); err != nil || len(months) != 1 {
+		t.Errorf("expected one month of spending, got %v, %v", months, err)
+	} else {
+		for month, spent := range months {
+			if spent != 120*100*1000 {
+				t.Errorf("%s: expected $120 spent, got %d", month, spent)
+			}
+		}
+	}
+	if paidOwed := g.PaidVsOwed(); paidOwed["Bob"] != [2]int64{0, 30 * 100 * 1000} {
+		t.Errorf("expected Bob to have paid nothing and owe $30, got %v", paidOwed["Bob"])
+	}
+	if most, _ := g.ExtremaExpenses(); most == nil || most.Description != "villa" {
+		t.Errorf("expected the villa to be the biggest expense, got %v", most)
+	}
+
+	for _, amount := range []int64{0, -1000, 500, 1000 * 100 * 1000} {
+		if err := g.ApplyGroupCredit(amount); err == nil {
+			t.Errorf("expected credit %d to be rejected", amount)
+		}
+	}
+}
+
+func TestPersonTotalExposure(t *testing.T) {
+	beach, err := Create("exposure-beach")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Delete(beach.Name) })
+	ski, err := Create("exposure-ski")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Delete(ski.Name) })
+	for _, name := range []string{"Quinn", "Pat"} {
+		if err := beach.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"quinn", "Ola"} {
+		if err := ski.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Quinn is owed $40 at the beach and owes $15 on the ski trip
+	if err := beach.AddExpense(&Expense{PaidBy: "Quinn", TotalMicroCents: 80 * 100 * 1000, Description: "cabana", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ski.AddExpense(&Expense{PaidBy: "Ola", TotalMicroCents: 30 * 100 * 1000, Description: "lift", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{
+		beach.Name:       40,
+		ski.Name:         -15,
+		ExposureTotalKey: 25,
+	}
+	if got := PersonTotalExposure("QUINN"); !maps.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := PersonTotalExposure("Nobody"); len(got) != 0 {
+		t.Errorf("expected nothing for a person in no group, got %v", got)
+	}
+}
+
+func TestSaveLoadFile(t *testing.T) {
+	keepStore(t)
+	group, err := Create("persisted-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gone, err := Create("persisted-gone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gone.AddPerson("Wendell"); err != nil {
+		t.Fatal(err)
+	}
+	Delete("persisted-gone")
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 50 * 100 * 1000, Description: "ferry", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := LoadFromFile(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a missing file to be reported as not existing, got %v", err)
+	}
+	if err := SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	Delete("persisted-trip")
+	groupMgr.mu.Lock()
+	groupMgr.deleted = nil
+	groupMgr.mu.Unlock()
+	if err := LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, ok := Get("persisted-trip")
+	if !ok {
+		t.Fatal("expected persisted-trip to be loaded")
+	}
+	if got := restored.GetExpenseDetails()["Bob to pay Alice"]; got != 25 {
+		t.Errorf("expected Bob to owe $25 after loading, got %v", got)
+	}
+	if err := restored.AddExpense(&Expense{PaidBy: "Bob", TotalMicroCents: 10 * 100 * 1000, Description: "coffee", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := restored.GetExpense(2); err != nil || e.Description != "coffee" {
+		t.Errorf("expected the next expense to get ID 2, got %v (err %v)", e, err)
+	}
+	if !slices.Contains(PeopleInDeletedGroups(), "Wendell") {
+		t.Errorf("expected the recycle bin to be loaded, got %v", PeopleInDeletedGroups())
+	}
+
+	// a blank description fails validation but is loaded rather than lost
+	stale := `{"groups":[{"name":"stale-trip","people":[{"name":"Alice"},{"name":"Bob"}],
+		"expenses":[{"id":1,"paid_by":"Alice","total_micro_cents":4000000,"description":" ","split_type":"equal",
+		 "resolved_shares":{"alice":2000000,"bob":2000000}}],"expense_id_counter":1}]}`
+	if err := os.WriteFile(path, []byte(stale), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFromFile(path); err != nil {
+		t.Fatalf("expected a state file with validation problems to load, got %v", err)
+	}
+	if _, ok := Get("stale-trip"); !ok {
+		t.Fatal("expected stale-trip to be loaded")
+	}
+	if err := os.WriteFile(path, []byte(`{"groups":[`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFromFile(path); err == nil {
+		t.Fatal("expected a truncated state file to be rejected")
+	}
+}
+
+func TestListExpenses(t *testing.T) {
+	group, err := NewGroup("list-expenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "alice", TotalMicroCents: 30 * 100 * 1000, Description: "dinner", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 10 * 100 * 1000, Description: "cab", SplitMethod: "percentage", SplitPercentages: map[string]float64{"Alice": 70, "Bob": 30}},
+		{PaidByMap: map[string]float64{"alice": 5, "Bob": 15}, TotalMicroCents: 20 * 100 * 1000, Description: "groceries", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 50 * 100 * 1000, DiscountMicroCents: 10 * 100 * 1000, Description: "museum", SplitMethod: "equal",
+			Labels: []string{"sightseeing"}, EnteredBy: "Bob"},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := group.ListExpenses()
+	if len(list) != 4 || list[0].ID != 1 || list[1].ID != 2 || list[2].ID != 3 || list[3].ID != 4 {
+		t.Fatalf("expected expenses 1 to 4 in order, got %+v", list)
+	}
+	dinner := list[0]
+	if dinner.Amount != "$30.00" || dinner.AmountMicroCents != 30*100*1000 || dinner.PaidBy != "Alice" {
+		t.Errorf("unexpected dinner view %+v", dinner)
+	}
+	if dinner.Split != nil || !maps.Equal(dinner.Shares, map[string]float64{"Alice": 15, "Bob": 15}) {
+		t.Errorf("expected no split input and $15 shares for dinner, got %v and %v", dinner.Split, dinner.Shares)
+	}
+	cab := list[1]
+	if !maps.Equal(cab.Split, map[string]float64{"Alice": 70, "Bob": 30}) || !maps.Equal(cab.Shares, map[string]float64{"Alice": 7, "Bob": 3}) {
+		t.Errorf("expected the percentages and $7/$3 shares for cab, got %v and %v", cab.Split, cab.Shares)
+	}
+	if cab.PaidByMap != nil {
+		t.Errorf("expected no payer map for a single payer, got %v", cab.PaidByMap)
+	}
+	if groceries := list[2]; !maps.Equal(groceries.PaidByMap, map[string]float64{"Alice": 5, "Bob": 15}) {
+		t.Errorf("expected both payers of the groceries listed, got %v", groceries.PaidByMap)
+	}
+	museum := list[3]
+	if museum.Amount != "$50.00" || museum.Discount != "$10.00" || museum.Net != "$40.00" || museum.EnteredBy != "Bob" ||
+		!slices.Equal(museum.Labels, []string{"sightseeing"}) || museum.CreatedAt.IsZero() {
+		t.Errorf("expected the discount, net, labels and who entered the museum, got %+v", museum)
+	}
+	if !maps.Equal(museum.Shares, map[string]float64{"Alice": 20, "Bob": 20}) {
+		t.Errorf("expected the shares to add up to the net, got %v", museum.Shares)
+	}
+	if dinner.Discount != "" || dinner.Net != "" {
+		t.Errorf("expected no discount on the dinner, got %+v", dinner)
+	}
+}
+
+func TestSimplifyDebts(t *testing.T) {
+	group, err := NewGroup("simplify-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	owe := func(debtor, payer string, dollars int64) {
+		t.Helper()
+		e := &Expense{PaidBy: payer, TotalMicroCents: dollars * 100 * 1000, Description: debtor + " share", SplitMethod: "weights", SplitWeights: map[string]float64{debtor: 1}}
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Alice owes Bob, Bob owes Charlie and Charlie owes Alice $10 each: everyone is even.
+	owe("Alice", "Bob", 10)
+	owe("Bob", "Charlie", 10)
+	owe("Charlie", "Alice", 10)
+	if got := group.SimplifyDebts(); len(got) != 0 {
+		t.Errorf("expected no payments when everyone is net zero, got %v", got)
+	}
+	if got := group.GetExpenseDetails(); len(got) != 3 {
+		t.Fatalf("expected the pairwise view to still show the cycle, got %v", got)
+	}
+
+	owe("Alice", "Bob", 30)
+	owe("Charlie", "Bob", 5)
+	want := map[string]float64{"Alice to pay Bob": 30, "Charlie to pay Bob": 5}
+	if got := group.SimplifyDebts(); !maps.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestGetBalances(t *testing.T) {
+	group, err := NewGroup("balance-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "groceries", SplitMethod: "weights", SplitWeights: map[string]float64{"Alice": 1, "Bob": 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{"Alice": 20, "Bob": -20, "Charlie": 0}
+	if got := group.GetAllBalances(); !maps.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, err := group.GetPersonBalance("bob"); err != nil || got != -20 {
+		t.Errorf("expected Bob to be down $20, got %v (err %v)", got, err)
+	}
+	_, err = group.GetPersonBalance("Dave")
+	if err == nil || !strings.Contains(err.Error(), "balance-trip") {
+		t.Errorf("expected an error naming the group for an unknown person, got %v", err)
+	}
+}
+
+func TestExpensesByMethod(t *testing.T) {
+	group, err := NewGroup("method-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, Description: "lunch", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 20 * 100 * 1000, Description: "tickets", SplitMethod: "percentage",
+			SplitPercentages: map[string]float64{"Alice": 60, "Bob": 40}},
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "hotel", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Alice": 1, "Bob": 2}},
+		{PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "museum", SplitMethod: "percentage",
+			SplitPercentages: map[string]float64{"Alice": 50, "Bob": 50}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := group.ExpensesByMethod("percentage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Description != "tickets" || got[1].Description != "museum" {
+		t.Fatalf("expected the two percentage splits by ID, got %v", got)
+	}
+	for _, e := range got {
+		if e.SplitMethod != "percentage" {
+			t.Fatalf("expected only percentage splits, got %v", e)
+		}
+	}
+
+	if got, err := group.ExpensesByMethod("duration"); err != nil || len(got) != 0 {
+		t.Fatalf("expected no duration splits, got %v (err %v)", got, err)
+	}
+	if _, err := group.ExpensesByMethod("itemized"); err == nil {
+		t.Fatal("expected an unknown split method to be rejected")
+	}
+}
+
+func TestCostPerDay(t *testing.T) {
+	group, err := NewGroup("daily-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if perDay, err := group.CostPerDay(); err != nil || perDay != 0 {
+		t.Fatalf("expected 0 per day without expenses, got %v (err %v)", perDay, err)
+	}
+
+	day := time.Date(2025, time.June, 1, 9, 0, 0, 0, time.UTC)
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "breakfast",
+		SplitMethod: "equal", CreatedAt: day}); err != nil {
+		t.Fatal(err)
+	}
+	if perDay, err := group.CostPerDay(); err != nil || perDay != 100 {
+		t.Fatalf("expected a single day to cost $100, got %v (err %v)", perDay, err)
+	}
+
+	// late on the third day
+	for _, e := range []*Expense{
+		{PaidBy: "Bob", TotalMicroCents: 150 * 100 * 1000, Description: "hotel", SplitMethod: "equal", CreatedAt: day.Add(30 * time.Hour)},
+		{PaidBy: "Alice", TotalMicroCents: 110 * 100 * 1000, Description: "dinner", SplitMethod: "equal", CreatedAt: day.Add(62 * time.Hour)},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if perDay, err := group.CostPerDay(); err != nil || perDay != 120 {
+		t.Fatalf("expected $360 over 3 days to be $120/day, got %v (err %v)", perDay, err)
+	}
+
+	// an expense without a timestamp, as an old import may have, is left out
+	undated := &Expense{PaidBy: "Bob", TotalMicroCents: 40 * 100 * 1000, Description: "snacks", SplitMethod: "equal"}
+	if err := group.AddExpense(undated); err != nil {
+		t.Fatal(err)
+	}
+	group.mu.Lock()
+	group.expenses[undated.ID].CreatedAt = time.Time{}
+	group.mu.Unlock()
+	if perDay, err := group.CostPerDay(); err != nil || perDay != 120 {
+		t.Fatalf("expected the undated expense to be left out, got %v (err %v)", perDay, err)
+	}
+}
+
+func TestPersonLedger(t *testing.T) {
+	group, err := NewGroup("person-ledger-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Bob owes Alice $30, Charlie owes Bob $10, Bob and Dave are even
+	for _, e := range []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 30 * 100 * 1000, Description: "tickets", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Bob": 1}},
+		{PaidBy: "Bob", TotalMicroCents: 10 * 100 * 1000, Description: "snacks", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Charlie": 1}},
+		{PaidBy: "Bob", TotalMicroCents: 5 * 100 * 1000, Description: "coffee", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Dave": 1}},
+		{PaidBy: "Dave", TotalMicroCents: 5 * 100 * 1000, Description: "tea", SplitMethod: "weights",
+			SplitWeights: map[string]float64{"Bob": 1}},
+	} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	owes, owedBy, err := group.PersonLedger("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owes) != 1 || owes["Alice"] != 30 {
+		t.Fatalf("expected Bob to owe only Alice $30, got %v", owes)
+	}
+	if len(owedBy) != 1 || owedBy["Charlie"] != 10 {
+		t.Fatalf("expected only Charlie to owe Bob $10, got %v", owedBy)
+	}
+
+	if _, _, err := group.PersonLedger("Zed"); err == nil {
+		t.Fatal("expected an unknown person to be rejected")
+	}
+}
+
+func TestExpenseDiscount(t *testing.T) {
+	group, err := NewGroup("coupon-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// $100 split three ways with a $10 coupon: everyone pays $30 net
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, DiscountMicroCents: 10 * 100 * 1000,
+		Description: "dinner", SplitMethod: "equal"}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.NetMicroCents() != 90*100*1000 {
+		t.Fatalf("expected a net of $90, got %d", e.NetMicroCents())
+	}
+	sum := int64(0)
+	for key, share := range e.ResolvedShares {
+		if share < 30*100*1000-1 || share > 30*100*1000+1 {
+			t.Fatalf("expected %s to pay about $30 after the discount, got %d", key, share)
+		}
+		sum += share
+	}
+	if sum != e.NetMicroCents() {
+		t.Fatalf("expected shares to add up to the net %d, got %d", e.NetMicroCents(), sum)
+	}
+	for _, name := range []string{"Bob", "Charlie"} {
+		_, net, err := group.DebtsBetween(name, "Alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if net != e.ResolvedShares[normalizeName(name)] {
+			t.Fatalf("expected %s to owe Alice their discounted share, got %d", name, net)
+		}
+	}
+
+	tooMuch := &Expense{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, DiscountMicroCents: 11 * 100 * 1000,
+		Description: "taxi", SplitMethod: "equal"}
+	if err := group.AddExpense(tooMuch); err == nil {
+		t.Fatal("expected a discount over the total to be rejected")
+	}
+}
+
+// keepStore restores the group store and the recycle bin when the test ends, for tests
+// that replace them.
+func keepStore(t *testing.T) {
+	t.Helper()
+	groupMgr.mu.Lock()
+	saved, deleted := maps.Clone(groupMgr.store), slices.Clone(groupMgr.deleted)
+	groupMgr.mu.Unlock()
+	t.Cleanup(func() {
+		groupMgr.mu.Lock()
+		defer groupMgr.mu.Unlock()
+		groupMgr.store, groupMgr.deleted = saved, deleted
+	})
+}
+
+func TestExportImportAll(t *testing.T) {
+	keepStore(t)
+	groupMgr.mu.Lock()
+	groupMgr.deleted = nil
+	groupMgr.mu.Unlock()
+	group, err := Create("backup-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "groceries", SplitMethod: "equal"}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := group.RecordPaymentWithMemo("Bob", "Alice", 5*100*1000, "partial", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Create("backup-empty"); err != nil {
+		t.Fatal(err)
+	}
+	gone, err := Create("backup-gone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gone.AddPerson("Xavier"); err != nil {
+		t.Fatal(err)
+	}
+	Delete(gone.Name)
+
+	exported, err := ExportAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range List() {
+		Delete(name)
+	}
+	n, err := ImportAll(exported, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n < 2 {
+		t.Fatalf("expected at least 2 groups to be imported, got %d", n)
+	}
+
+	restored, ok := Get("backup-trip")
+	if !ok {
+		t.Fatal("expected backup-trip to be restored")
+	}
+	_, net, err := restored.DebtsBetween("Bob", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net != 15*100*1000 {
+		t.Fatalf("expected Bob to owe Alice $15 after import, got %d", net)
+	}
+	reexported, err := ExportAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reexported) != string(exported) {
+		t.Fatal("expected the re-exported backup to match the original")
+	}
+	if got := PeopleInDeletedGroups(); !slices.Equal(got, []string{"Xavier"}) {
+		t.Fatalf("expected the recycle bin to be imported, got %v", got)
+	}
+
+	if _, err := ImportAll([]byte(`{"groups":[{"name":"dup"},{"name":"DUP"}]}`), false); err == nil {
+		t.Fatal("expected duplicate group names to be rejected")
+	}
+	if _, ok := Get("backup-trip"); !ok {
+		t.Fatal("expected a failed import to leave the store unchanged")
+	}
+
+	if _, err := ImportAll([]byte(`{"groups":[{"name":"  Beach-Trip "}]}`), false); err != nil {
+		t.Fatal(err)
+	}
+	beach, ok := Get("beach-trip")
+	if !ok || beach.Name != "Beach-Trip" {
+		t.Fatalf("expected the group name trimmed like Create does, got %v", beach)
+	}
+	if _, err := ImportAll([]byte(`{"groups":[{"name":"beach"},{"name":" Beach "}]}`), false); err == nil {
+		t.Fatal("expected names that only differ in spacing to be rejected as duplicates")
+	}
+}
+
+func TestImportAllValidation(t *testing.T) {
+	keepStore(t)
+	if _, err := Create("validation-keep"); err != nil {
+		t.Fatal(err)
+	}
+	// expense 1 has a blank description and a negative share offset by a larger one;
+	// expense 2 says Alice paid $30 of a $40 bill
+	blob := `{"groups":[{"name":"bad-import","people":[{"name":"Alice"},{"name":"Bob"}],
+		"expenses":[
+			{"id":1,"paid_by":"Alice","total_micro_cents":4000000,"description":" ","split_type":"equal",
+			 "resolved_shares":{"alice":5000000,"bob":-1000000}},
+			{"id":2,"paid_by_map":{"Alice":30},"paid_by":"Alice","total_micro_cents":4000000,"description":"dinner","split_type":"bogus",
+			 "resolved_shares":{"alice":2000000,"bob":2000000}}],
+		"expense_id_counter":2}]}`
+
+	_, err := ImportAll([]byte(blob), false)
+	if err == nil {
+		t.Fatal("expected the inconsistent backup to be rejected")
+	}
+	for _, want := range []string{
+		"expense(1): expense description cannot be empty",
+		"expense(1): participant(bob) has a negative share -1000000",
+		"expense(2): payers paid 3000000, not the total 4000000",
+		"expense(2): split method must be one of",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if _, ok := Get("validation-keep"); !ok {
+		t.Fatal("expected a rejected import to leave the store unchanged")
+	}
+	if _, ok := Get("bad-import"); ok {
+		t.Fatal("expected the invalid group not to be registered")
+	}
+
+	if _, err := ImportAll([]byte(blob), true); err != nil {
+		t.Fatalf("expected a forced import to succeed, got %v", err)
+	}
+	if _, ok := Get("bad-import"); !ok {
+		t.Fatal("expected the forced import to register the group")
+	}
+}
+
+func TestPeopleInDeletedGroups(t *testing.T) {
+	keepStore(t)
+	old, err := Create("deleted-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Zelda", "Yuri"} {
+		if err := old.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	active, err := Create("active-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"yuri", "Xavier"} {
+		if err := active.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !Delete("deleted-trip") {
+		t.Fatal("expected deleted-trip to be deleted")
+	}
+
+	got := PeopleInDeletedGroups()
+	for _, name := range []string{"Yuri", "Zelda"} {
+		if !slices.Contains(got, name) {
+			t.Fatalf("expected %s from the deleted group to be listed, got %v", name, got)
+		}
+	}
+	if slices.Contains(got, "Xavier") {
+		t.Fatalf("expected a member of only an active group not to be listed, got %v", got)
+	}
+	if slices.Contains(got, "yuri") {
+		t.Fatalf("expected Yuri to be listed once, got %v", got)
+	}
+}
+
+func TestRecycleBinIsCapped(t *testing.T) {
+	keepStore(t)
+	for i := range maxDeletedGroups + 2 {
+		group, err := Create(fmt.Sprintf("bin-trip-%c%c", 'a'+i/26, 'a'+i%26))
+		if err != nil {
+			t.Fatal(err)
+		}
+		Delete(group.Name)
+	}
+
+	groupMgr.mu.Lock()
+	defer groupMgr.mu.Unlock()
+	if len(groupMgr.deleted) != maxDeletedGroups {
+		t.Fatalf("expected the bin to hold %d groups, got %d", maxDeletedGroups, len(groupMgr.deleted))
+	}
+	if first := groupMgr.deleted[0].Name; first != "bin-trip-ac" {
+		t.Fatalf("expected the two oldest groups dropped, got %s first", first)
+	}
+}
+
+func TestReportInHomeCurrency(t *testing.T) {
+	group, err := NewGroup("euro-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if group.HomeCurrency() != "USD" {
+		t.Fatalf("expected the default home currency to be USD, got %s", group.HomeCurrency())
+	}
+	expenses := []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Currency: "eur", ExchangeRate: 1.1, Description: "hotel", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 50 * 100 * 1000, Currency: "GBP", ExchangeRate: 1.3, Description: "train", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 10 * 100 * 1000, Description: "snacks", SplitMethod: "equal"},
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 100 * 1000, Currency: "EUR", Description: "coffee", SplitMethod: "equal"}); err == nil {
+		t.Fatal("expected an expense in EUR without an exchange rate to be rejected")
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// balances are in USD: Alice paid $110 and Bob $65 + $10
+	if got := group.GetExpenseDetails(); !maps.Equal(got, map[string]float64{"Bob to pay Alice": 17.5}) {
+		t.Errorf("expected Bob to owe Alice $17.50, got %v", got)
+	}
+	if err := group.SetHomeCurrency("EUR"); err == nil {
+		t.Error("expected the home currency to be fixed once the group has expenses")
+	}
+
+	report := group.ReportInHomeCurrency()
+	for _, want := range []string{
+		"#1 hotel, paid by Alice: 110.00 USD (100.00 EUR at 1.1)",
+		"#2 train, paid by Bob: 65.00 USD (50.00 GBP at 1.3)",
+		"#3 snacks, paid by Bob: 10.00 USD",
+		"Total spent: 185.00 USD",
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+
+	bad := &Expense{PaidBy: "Alice", TotalMicroCents: 100 * 1000, Currency: "euro", Description: "coffee", SplitMethod: "equal"}
+	if err := group.AddExpense(bad); err == nil {
+		t.Fatal("expected an invalid currency code to be rejected")
+	}
+}
+
+func TestForeignCurrencyConvertedAtEntry(t *testing.T) {
+	group, err := NewGroup("yen-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 1000 JPY at 0.0067 USD: the exact amounts still add up after conversion
+	exact := &Expense{PaidBy: "Alice", TotalMicroCents: 1000 * 100 * 1000, Currency: "JPY", ExchangeRate: 0.0067, Description: "ramen", SplitMethod: "exact",
+		SplitExactMicroCents: map[string]int64{"Alice": 333 * 100 * 1000, "Bob": 333 * 100 * 1000, "Carol": 334 * 100 * 1000}}
+	if err := group.AddExpense(exact); err != nil {
+		t.Fatal(err)
+	}
+	if exact.TotalMicroCents != 670*1000 || exact.OriginalMicroCents != 1000*100*1000 {
+		t.Errorf("expected $6.70 converted from 1000 JPY, got %d from %d", exact.TotalMicroCents, exact.OriginalMicroCents)
+	}
+
+	// several payers in EUR
+	shared := &Expense{PaidByMap: map[string]float64{"Bob": 20, "Carol": 10}, TotalMicroCents: 30 * 100 * 1000, Currency: "EUR", ExchangeRate: 1.07,
+		Description: "museum", SplitMethod: "equal"}
+	if err := group.AddExpense(shared); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(shared.PaidByMap, map[string]float64{"bob": 21.4, "carol": 10.7}) {
+		t.Errorf("expected the payers' amounts converted to $21.40 and $10.70, got %v", shared.PaidByMap)
+	}
+
+	for _, e := range []*Expense{exact, shared} {
+		sum := int64(0)
+		for _, share := range e.ResolvedShares {
+			sum += share
+		}
+		if sum != e.NetMicroCents() {
+			t.Errorf("expense %d: expected the shares to add up to %d, got %d", e.ID, e.NetMicroCents(), sum)
+		}
+	}
+
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 100 * 1000, ExchangeRate: 1.2, Description: "tea", SplitMethod: "equal"}); err == nil {
+		t.Error("expected an exchange rate on a home-currency expense to be rejected")
+	}
+}
+
+func TestExpenseImpact(t *testing.T) {
+	group, err := NewGroup("impact-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "dinner", SplitMethod: "weights",
+		SplitWeights: map[string]float64{"Alice": 1, "Bob": 2, "Charlie": 1}}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+
+	impact, err := group.ExpenseImpact(e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := int64(0)
+	for _, delta := range impact {
+		sum += delta
+	}
+	if sum != 0 {
+		t.Fatalf("expected deltas to sum to zero, got %d", sum)
+	}
+	for _, name := range []string{"Bob", "Charlie"} {
+		if impact[name] != -e.ResolvedShares[normalizeName(name)] {
+			t.Fatalf("expected %s's delta to be minus their share %d, got %d", name, e.ResolvedShares[normalizeName(name)], impact[name])
+		}
+	}
+	if want := e.TotalMicroCents - e.ResolvedShares["alice"]; impact["Alice"] != want {
+		t.Fatalf("expected Alice's delta to be %d, got %d", want, impact["Alice"])
+	}
+
+	if _, err := group.ExpenseImpact(42); err == nil {
+		t.Fatal("expected an unknown expense to be rejected")
+	}
+}
+
+func TestExpensesOwedBy(t *testing.T) {
+	group, err := NewGroup("owed-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "dinner", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 20 * 100 * 1000, Description: "taxi", SplitMethod: "equal"},
+		{PaidBy: "Charlie", TotalMicroCents: 10 * 100 * 1000, Description: "snacks", SplitMethod: "equal"},
+		{PaidBy: "Charlie", TotalMicroCents: 10 * 100 * 1000, Description: "beer", SplitMethod: "equal", Excluded: []string{"Bob"}},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	owed, err := group.ExpensesOwedBy("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owed) != 2 || owed[0].ID != 2 || owed[1].ID != 3 {
+		t.Fatalf("expected Bob to owe for expenses 2 and 3, got %+v", owed)
+	}
+
+	if _, err := group.ExpensesOwedBy("Dave"); err == nil {
+		t.Fatal("expected a non-member to be rejected")
+	}
+}
+
+func TestParticipantNotes(t *testing.T) {
+	group, err := NewGroup("notes-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 60 * 100 * 1000, Description: "dinner", SplitMethod: "weights",
+		SplitWeights:     map[string]float64{"Alice": 1, "Bob": 2},
+		ParticipantNotes: map[string]string{"bob": "had the steak"}}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.SetParticipantNote(e.ID, "Alice", " salad only "); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := group.GetExpense(e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Alice": "salad only", "Bob": "had the steak"}
+	if !maps.Equal(got.ParticipantNotes, want) {
+		t.Fatalf("expected notes %v, got %v", want, got.ParticipantNotes)
+	}
+
+	if err := group.SetParticipantNote(e.ID, "Charlie", "wasn't there"); err == nil {
+		t.Fatal("expected a note for a non-participant to be rejected")
+	}
+	if err := group.SetParticipantNote(e.ID, "Bob", strings.Repeat("x", maxParticipantNoteLength+1)); err == nil {
+		t.Fatal("expected an overlong note to be rejected")
+	}
+	if err := group.SetParticipantNote(e.ID, "Alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	got, err = group.GetExpense(e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.ParticipantNotes["Alice"]; ok || got.ParticipantNotes["Bob"] != "had the steak" {
+		t.Fatalf("expected only Bob's note to remain, got %v", got.ParticipantNotes)
+	}
+
+	exported, err := group.ExportSplitwise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(exported), `"details": "Bob: had the steak"`) {
+		t.Fatalf("expected the export to carry the notes, got %s", exported)
+	}
+}
+
+func TestSpendingInequality(t *testing.T) {
+	newTrip := func(name string) *Group {
+		group, err := NewGroup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, person := range []string{"Alice", "Bob", "Charlie"} {
+			if err := group.AddPerson(person); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return group
+	}
+
+	even := newTrip("even-trip")
+	if got := even.SpendingInequality(); got != 0 {
+		t.Fatalf("expected 0 without expenses, got %v", got)
+	}
+	for _, payer := range []string{"Alice", "Bob"} {
+		e := &Expense{PaidBy: payer, TotalMicroCents: 100 * 100 * 1000, Description: "dinner", SplitMethod: "equal"}
+		if err := even.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := even.SpendingInequality(); got != 0 {
+		t.Fatalf("expected 0 for equal splits, got %v", got)
+	}
+
+	skewed := newTrip("skewed-trip")
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "hotel", SplitMethod: "percentage",
+		SplitPercentages: map[string]float64{"Alice": 80, "Bob": 20}}
+	if err := skewed.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if got := skewed.SpendingInequality(); got <= 0 {
+		t.Fatalf("expected a skewed split to be unequal, got %v", got)
+	}
+}
+
+func TestRenamePerson(t *testing.T) {
+	group, err := NewGroup("rename-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pct := &Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "hotel", SplitMethod: "percentage",
+		SplitPercentages: map[string]float64{"Alice": 50, "bob": 30, "Charlie": 20},
+		ParticipantNotes: map[string]string{"Bob": "bigger room"}}
+	weights := &Expense{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "taxi", SplitMethod: "weights",
+		SplitWeights: map[string]float64{"Bob": 1, "Charlie": 2}}
+	for _, e := range []*Expense{pct, weights} {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := group.RecordPaymentWithMemo("Bob", "Alice", 10*100*1000, "cash", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := group.RenamePerson("Bob", "Charlie"); err == nil {
+		t.Fatal("expected renaming onto an existing member to be rejected")
+	}
+	if err := group.RenamePerson("Bob", "Robert"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, net, err := group.DebtsBetween("Robert", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net != 20*100*1000 {
+		t.Fatalf("expected Robert to owe Alice $20 after the rename, got %d", net)
+	}
+
+	// every renamed expense must still resolve against the current members
+	for _, id := range []int{pct.ID, weights.ID} {
+		stored, err := group.GetExpense(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := stored.ResolvedShares["bob"]; ok {
+			t.Fatalf("expected expense(%d) to drop Bob's old share key, got %v", id, stored.ResolvedShares)
+		}
+		resolved := copyExpense(&stored)
+		group.mu.Lock()
+		err = group.resolveExpense(&resolved)
+		group.mu.Unlock()
+		if err != nil {
+			t.Fatalf("expected expense(%d) to resolve after the rename: %v", id, err)
+		}
+		if !maps.Equal(resolved.ResolvedShares, stored.ResolvedShares) {
+			t.Fatalf("expected expense(%d) shares %v to match the stored %v", id, resolved.ResolvedShares, stored.ResolvedShares)
+		}
+	}
+	stored, err := group.GetExpense(pct.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.SplitPercentages["Robert"] != 30 || stored.ParticipantNotes["Robert"] != "bigger room" {
+		t.Fatalf("expected split map and notes to follow the rename, got %v and %v", stored.SplitPercentages, stored.ParticipantNotes)
+	}
+	stored, err = group.GetExpense(weights.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PaidBy != "Robert" || stored.SplitWeights["Robert"] != 1 {
+		t.Fatalf("expected payer and weights to follow the rename, got %s and %v", stored.PaidBy, stored.SplitWeights)
+	}
+}
+
+func TestCompletedPayments(t *testing.T) {
+	group, err := NewGroup("history-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "cabin", SplitMethod: "equal"}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	if len(group.CompletedPayments()) != 0 {
+		t.Fatal("expected no payments before any were recorded")
+	}
+	if _, err := group.RecordPaymentWithMemo("bob", "Alice", 30*100*1000, "venmo", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.RecordPayment("Charlie", "Alice", 10*100*1000); err != nil {
+		t.Fatal(err)
+	}
+
+	history := group.CompletedPayments()
+	if len(history) != 2 {
+		t.Fatalf("expected 2 payments, got %+v", history)
+	}
+	if history[0].From != "Bob" || history[0].To != "Alice" || history[0].AmountMicroCents != 30*100*1000 || history[0].Memo != "venmo" {
+		t.Fatalf("unexpected first payment %+v", history[0])
+	}
+	if history[1].From != "Charlie" || history[1].AmountMicroCents != 10*100*1000 {
+		t.Fatalf("unexpected second payment %+v", history[1])
+	}
+	if history[1].CreatedAt.Before(history[0].CreatedAt) {
+		t.Fatal("expected payments oldest first")
+	}
+}
+
+func TestExpenseSplitByHeadcount(t *testing.T) {
+	group, err := NewGroup("family-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Alice brought her two kids: 3 of a total headcount of 5
+	e := &Expense{PaidBy: "Bob", TotalMicroCents: 100 * 100 * 1000, Description: "groceries", SplitMethod: "headcount",
+		SplitHeadcount: map[string]int{"alice": 3, "Bob": 1, "Charlie": 1}}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"alice": 60 * 100 * 1000, "bob": 20 * 100 * 1000, "charlie": 20 * 100 * 1000}
+	if !maps.Equal(e.ResolvedShares, want) {
+		t.Fatalf("expected shares %v, got %v", want, e.ResolvedShares)
+	}
+
+	for _, counts := range []map[string]int{
+		{"Alice": 0, "Bob": 1},
+		{"Alice": -2, "Bob": 1},
+		{"Alice": 1, "Dave": 1},
+		{},
+	} {
+		bad := &Expense{PaidBy: "Bob", TotalMicroCents: 100 * 1000, Description: "snacks", SplitMethod: "headcount", SplitHeadcount: counts}
+		if err := group.AddExpense(bad); err == nil {
+			t.Fatalf("expected headcount %v to be rejected", counts)
+		}
+	}
+	equal := &Expense{PaidBy: "Bob", TotalMicroCents: 100 * 1000, Description: "snacks", SplitMethod: "equal",
+		SplitHeadcount: map[string]int{"Alice": 3}}
+	if err := group.AddExpense(equal); err == nil {
+		t.Fatal("expected a headcount on an equal split to be rejected")
+	}
+}
+
+func TestDissolutionPlan(t *testing.T) {
+	group, err := NewGroup("wind-down-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dana"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 120 * 100 * 1000, Description: "cabin", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 40 * 100 * 1000, Description: "gas", SplitMethod: "equal"},
+		{PaidBy: "Charlie", TotalMicroCents: 10 * 100 * 1000, Description: "snacks", SplitMethod: "equal"},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := group.RecordPayment("Dana", "Alice", 5*100*1000); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := group.DissolutionPlan()
+	if len(plan) == 0 {
+		t.Fatal("expected transfers to wind the group down")
+	}
+	net := map[string]int64{}
+	for _, s := range plan {
+		net[s.From] -= s.AmountMicroCents
+		net[s.To] += s.AmountMicroCents
+	}
+	if net["Alice"] <= 0 || net["Dana"] >= 0 {
+		t.Fatalf("expected Alice to receive and Dana to pay, got %v", net)
+	}
+	received, paid := int64(0), int64(0)
+	for _, amount := range net {
+		if amount > 0 {
+			received += amount
+		} else {
+			paid -= amount
+		}
+	}
+	if received != paid {
+		t.Fatalf("expected the sum received %d to equal the sum paid %d", received, paid)
+	}
+
+	// after the plan everyone is at zero
+	for _, s := range plan {
+		if _, err := group.RecordPaymentWithMemo(s.From, s.To, s.AmountMicroCents, "", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rest := group.DissolutionPlan(); len(rest) != 0 {
+		t.Fatalf("expected nothing left to settle, got %+v", rest)
+	}
+}
+
+func TestPaidVsOwed(t *testing.T) {
+	group, err := NewGroup("paid-owed-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, Description: "cabin", SplitMethod: "equal"},
+		{PaidByMap: map[string]float64{"Bob": 30, "Charlie": 10}, TotalMicroCents: 40 * 100 * 1000, Description: "gas",
+			SplitMethod: "weights", SplitWeights: map[string]float64{"Alice": 1, "Bob": 1, "Charlie": 2}},
+		{PaidBy: "Charlie", TotalMicroCents: 30 * 100 * 1000, Description: "lunch", SplitMethod: "equal",
+			SettledParticipants: []string{"Bob"}},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	totals := group.PaidVsOwed()
+	if totals["Alice"][0] != 100*100*1000 {
+		t.Fatalf("expected Alice to have paid $100, got %d", totals["Alice"][0])
+	}
+	for _, m := range group.MembersByBalance() {
+		paidMinusOwed := totals[m.Name][0] - totals[m.Name][1]
+		if paidMinusOwed != m.BalanceMicroCents {
+			t.Fatalf("expected %s's paid minus owed %d to equal their balance %d", m.Name, paidMinusOwed, m.BalanceMicroCents)
+		}
+	}
+}
+
+func TestExpensePayerPersonalPortion(t *testing.T) {
+	group, err := NewGroup("personal-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dana"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Alice paid $100, $20 of it was her own item: the other $80 splits four ways
+	e := &Expense{PaidBy: "Alice", TotalMicroCents: 100 * 100 * 1000, PayerPersonalMicroCents: 20 * 100 * 1000,
+		Description: "market", SplitMethod: "equal"}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"alice": 40 * 100 * 1000, "bob": 20 * 100 * 1000, "charlie": 20 * 100 * 1000, "dana": 20 * 100 * 1000}
+	if !maps.Equal(e.ResolvedShares, want) {
+		t.Fatalf("expected shares %v, got %v", want, e.ResolvedShares)
+	}
+	for _, name := range []string{"Bob", "Charlie", "Dana"} {
+		_, net, err := group.DebtsBetween(name, "Alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if net != 20*100*1000 {
+			t.Fatalf("expected %s to owe Alice $20, got %d", name, net)
+		}
+	}
+
+	tooMuch := &Expense{PaidBy: "Alice", TotalMicroCents: 10 * 100 * 1000, PayerPersonalMicroCents: 11 * 100 * 1000,
+		Description: "taxi", SplitMethod: "equal"}
+	if err := group.AddExpense(tooMuch); err == nil {
+		t.Fatal("expected a personal portion over the total to be rejected")
+	}
+}
+
+func TestExtremaExpenses(t *testing.T) {
+	group, err := NewGroup("extrema-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if most, least := group.ExtremaExpenses(); most != nil || least != nil {
+		t.Fatal("expected no extrema without expenses")
+	}
+
+	expenses := []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "gas", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 5 * 100 * 1000, Description: "coffee", SplitMethod: "equal"},
+		{PaidBy: "Alice", TotalMicroCents: 300 * 100 * 1000, Description: "hotel", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 5 * 100 * 1000, Description: "tea", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 320 * 100 * 1000, DiscountMicroCents: 40 * 100 * 1000, Description: "show", SplitMethod: "equal"},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	most, least := group.ExtremaExpenses()
+	if most == nil || most.Description != "hotel" {
+		t.Fatalf("expected the hotel to be the most expensive, got %+v", most)
+	}
+	if least == nil || least.Description != "coffee" {
+		t.Fatalf("expected the coffee to win the tie for least expensive, got %+v", least)
+	}
+	most.Description = "changed"
+	if again, _ := group.ExtremaExpenses(); again.Description != "hotel" {
+		t.Fatal("expected a copy, not the stored expense")
+	}
+}
+
+func TestSettleAllAndArchive(t *testing.T) {
+	group, err := NewGroup("close-out-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 90 * 100 * 1000, Description: "cabin", SplitMethod: "equal"},
+		{PaidBy: "Bob", TotalMicroCents: 30 * 100 * 1000, Description: "gas", SplitMethod: "equal"},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Charlie owes Alice $30; $5 of it moves to Bob
+	if err := group.TransferDebt("Charlie", "Alice", "Bob", 5*100*1000); err != nil {
+		t.Fatal(err)
+	}
+	transfers := group.Snapshot().Transfers
+
+	payments, archived, err := group.SettleAllAndArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) == 0 {
+		t.Fatal("expected payments to settle the group")
+	}
+	for _, p := range payments {
+		if p.To != "Alice" || p.Memo != closeOutMemo {
+			t.Fatalf("expected everyone to pay Alice, got %+v", p)
+		}
+	}
+	if len(archived) != 2 || archived[0].Description != "cabin" || archived[1].Description != "gas" {
+		t.Fatalf("expected both expenses archived in order, got %+v", archived)
+	}
+
+	for _, m := range group.MembersByBalance() {
+		if m.BalanceMicroCents != 0 {
+			t.Fatalf("expected %s to be settled, got %d", m.Name, m.BalanceMicroCents)
+		}
+	}
+	if stats := group.GraphStats(); stats.Edges != 0 {
+		t.Fatalf("expected an empty debt graph, got %d edges", stats.Edges)
+	}
+	if got := group.ArchivedExpenses(); len(got) != 2 {
+		t.Fatalf("expected 2 archived expenses, got %d", len(got))
+	}
+	if len(group.CompletedPayments()) != 0 {
+		t.Fatal("expected the payments to be archived too")
+	}
+	if len(transfers) == 0 || !slices.EqualFunc(group.ArchivedTransfers(), transfers, func(a, b LedgerEntry) bool {
+		return a.From == b.From && a.To == b.To && a.AmountMicroCents == b.AmountMicroCents
+	}) {
+		t.Fatalf("expected the transfers %v to be archived, got %v", transfers, group.ArchivedTransfers())
+	}
+	if got := group.Snapshot().ArchivedTransfers; len(got) != len(transfers) {
+		t.Fatalf("expected the archived transfers in the snapshot, got %v", got)
+	}
+
+	// the group carries on with new IDs
+	next := &Expense{PaidBy: "Charlie", TotalMicroCents: 9 * 100 * 1000, Description: "breakfast", SplitMethod: "equal"}
+	if err := group.AddExpense(next); err != nil {
+		t.Fatal(err)
+	}
+	if next.ID != 3 {
+		t.Fatalf("expected expense IDs to keep counting, got %d", next.ID)
+	}
+
+	// compacting doesn't hand out archived IDs again
+	if err := group.DeleteExpense(3); err != nil {
+		t.Fatal(err)
+	}
+	group.CompactExpenseIDs()
+	after := &Expense{PaidBy: "Charlie", TotalMicroCents: 9 * 100 * 1000, Description: "lunch", SplitMethod: "equal"}
+	if err := group.AddExpense(after); err != nil {
+		t.Fatal(err)
+	}
+	if after.ID != 3 {
+		t.Fatalf("expected the ID after the archived ones, got %d", after.ID)
+	}
+	if err := group.AddExpense(&Expense{PaidBy: "Alice", TotalMicroCents: 6 * 100 * 1000, Description: "snacks", SplitMethod: "equal"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.DeleteExpense(after.ID); err != nil {
+		t.Fatal(err)
+	}
+	if mapping := group.CompactExpenseIDs(); len(mapping) != 1 || mapping[4] != 3 {
+		t.Fatalf("expected the live expenses renumbered from 3, got %v", mapping)
+	}
+
+	bad := group.Snapshot()
+	bad.Expenses[0].ID = 1
+	if err := group.RestoreSnapshot(bad); err == nil {
+		t.Fatal("expected a live expense reusing an archived ID to be rejected")
+	}
+	bad = group.Snapshot()
+	bad.Expenses, bad.ExpenseIDCounter = nil, 1
+	if err := group.RestoreSnapshot(bad); err == nil {
+		t.Fatal("expected a counter below the archived IDs to be rejected")
+	}
+}
+
+func TestNonPayers(t *testing.T) {
+	group, err := NewGroup("nudge-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dana"} {
+		if err := group.AddPerson(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expenses := []*Expense{
+		{PaidBy: "Alice", TotalMicroCents: 40 * 100 * 1000, Description: "cabin", SplitMethod: "equal"},
+		{PaidByMap: map[string]float64{"Alice": 10, "Bob": 10}, TotalMicroCents: 20 * 100 * 1000, Description: "gas", SplitMethod: "equal"},
+	}
+	for _, e := range expenses {
+		if err := group.AddExpense(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Charlie and Dana only ever owed
+	got := group.NonPayers()
+	if want := []string{"Charlie", "Dana"}; !slices.Equal(got, want) {
+		t.Fatalf("expected non-payers %v, got %v", want, got)
+	}
+}
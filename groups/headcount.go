@@ -0,0 +1,49 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// normalizeHeadcount validates a person->headcount map against the group's members and
+// returns it keyed by normalized name. Every count must be a positive number of people.
+// Caller must hold the group lock.
+func (g *Group) normalizeHeadcount(counts map[string]int) (map[string]int, error) {
+	if len(counts) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]int, len(counts))
+	for name, n := range counts {
+		key := normalizeName(name)
+		if _, exists := g.people[key]; !exists {
+			slog.Error("expense split_headcount validation failed, name not in the group", "name", name, "group", g.Name)
+			return nil, fmt.Errorf("expense split_headcount validation failed, name(%s) not in the group(%s)", name, g.Name)
+		}
+		if _, exists := out[key]; exists {
+			return nil, fmt.Errorf("duplicate name in split_headcount after normalization: %q", name)
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("split_headcount for %s must be a positive number of people, got %d", name, n)
+		}
+		out[key] = n
+	}
+	return out, nil
+}
+
+// splitByHeadcount splits the total per head: a person who counts as 3 (say, with two
+// kids) pays three times what a person who counts as 1 pays.
+func splitByHeadcount(totalMicroCents int64, counts map[string]int) (map[string]int64, error) {
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("split_headcount is required for headcount split")
+	}
+	return splitByWeights(totalMicroCents, headcountWeights(counts))
+}
+
+// headcountWeights returns counts as split weights.
+func headcountWeights(counts map[string]int) map[string]float64 {
+	weights := make(map[string]float64, len(counts))
+	for key, n := range counts {
+		weights[key] = float64(n)
+	}
+	return weights
+}
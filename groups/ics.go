@@ -0,0 +1,69 @@
+package groups
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsEscaper escapes TEXT values as RFC 5545 requires.
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// SettlementReminderICS returns an iCalendar file with a single all-day event on due
+// reminding the group to settle up. The description lists the transfers of
+// DissolutionPlan. The UID is derived from the group name, so a calendar app updates the
+// reminder instead of adding a second one when it is exported again.
+func (g *Group) SettlementReminderICS(due time.Time) (string, error) {
+	if due.IsZero() {
+		return "", fmt.Errorf("settlement reminder needs a due date")
+	}
+
+	lines := []string{}
+	for _, s := range g.DissolutionPlan() {
+		lines = append(lines, fmt.Sprintf("%s pays %s $%s", s.From, s.To, formatAmount(s.AmountMicroCents)))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "Everyone is already even.")
+	}
+
+	var b strings.Builder
+	for _, line := range []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//expense-splitter//settlement reminder//EN",
+		"BEGIN:VEVENT",
+		"UID:settle-up-" + normalizeName(g.Name) + "@expense-splitter",
+		"DTSTAMP:" + time.Now().UTC().Format("20060102T150405Z"),
+		"DTSTART;VALUE=DATE:" + due.Format("20060102"),
+		"DTEND;VALUE=DATE:" + due.AddDate(0, 0, 1).Format("20060102"),
+		"SUMMARY:" + icsEscaper.Replace("Settle up: "+g.Name),
+		"DESCRIPTION:" + icsEscaper.Replace(strings.Join(lines, "\n")),
+		"END:VEVENT",
+		"END:VCALENDAR",
+	} {
+		b.WriteString(foldICSLine(line))
+	}
+	return b.String(), nil
+}
+
+// foldICSLine ends line with CRLF, folding it so no line exceeds 75 octets. Folds never
+// split a multi-byte character.
+func foldICSLine(line string) string {
+	var b strings.Builder
+	width := 75
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space, which counts towards the limit
+		width = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"strings"
+)
+
+// defaultIncomeBrackets are the income brackets a group starts with, bracket -> weight.
+var defaultIncomeBrackets = map[string]float64{"low": 1, "mid": 2, "high": 3}
+
+// incomeBrackets returns the group's bracket definitions.
+// Caller must hold the group lock.
+func (g *Group) incomeBrackets() map[string]float64 {
+	if g.brackets == nil {
+		return defaultIncomeBrackets
+	}
+	return g.brackets
+}
+
+// IncomeBrackets returns the defined income brackets, bracket -> weight.
+func (g *Group) IncomeBrackets() map[string]float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return maps.Clone(g.incomeBrackets())
+}
+
+// DefineIncomeBracket adds an income bracket or changes its weight. Bracket names follow
+// the label format, e.g. "student". Expenses already split by income keep their shares.
+func (g *Group) DefineIncomeBracket(bracket string, weight float64) error {
+	bracket = strings.ToLower(strings.TrimSpace(bracket))
+	if !labelPattern.MatchString(bracket) {
+		return fmt.Errorf("income bracket(%s) must match %q", bracket, labelPattern.String())
+	}
+	if math.IsNaN(weight) || math.IsInf(weight, 0) || weight <= 0 {
+		return fmt.Errorf("income bracket(%s) weight must be positive, got %v", bracket, weight)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	brackets := maps.Clone(g.incomeBrackets())
+	brackets[bracket] = weight
+	g.brackets = brackets
+	slog.Debug("DefineIncomeBracket", "group", g.Name, "bracket", bracket, "weight", weight)
+	return nil
+}
+
+// SetIncomeBracket puts person in an income bracket for "income" splits. An empty bracket
+// takes them out of it.
+func (g *Group) SetIncomeBracket(person, bracket string) error {
+	bracket = strings.ToLower(strings.TrimSpace(bracket))
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(person)
+	if _, exists := g.people[key]; !exists {
+		return fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(person), g.Name)
+	}
+	if bracket == "" {
+		delete(g.memberBrackets, key)
+		return nil
+	}
+	if _, defined := g.incomeBrackets()[bracket]; !defined {
+		return fmt.Errorf("income bracket(%s) is not defined in group(%s)", bracket, g.Name)
+	}
+	if g.memberBrackets == nil {
+		g.memberBrackets = map[string]string{}
+	}
+	g.memberBrackets[key] = bracket
+	return nil
+}
+
+// incomeWeights returns the weight of every member not in excluded for an "income" split,
+// from their bracket. Every such member must be in a bracket.
+// Caller must hold the group lock.
+func (g *Group) incomeWeights(excluded map[string]bool) (map[string]float64, error) {
+	weights := map[string]float64{}
+	for key := range g.people {
+		if excluded[key] {
+			continue
+		}
+		bracket, ok := g.memberBrackets[key]
+		if !ok {
+			return nil, fmt.Errorf("person(%s) has no income bracket; set one or exclude them", g.displayName(key))
+		}
+		weights[key] = g.incomeBrackets()[bracket]
+	}
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("income split needs at least one participant")
+	}
+	return weights, nil
+}
+
+// restoreIncomeBrackets validates the income brackets of a snapshot against its people and
+// returns the bracket definitions (nil for the defaults) and the brackets keyed by
+// normalized name.
+func restoreIncomeBrackets(s GroupSnapshot, people map[string]*Person) (map[string]float64, map[string]string, error) {
+	defined := defaultIncomeBrackets
+	if s.IncomeBrackets != nil {
+		defined = s.IncomeBrackets
+		for bracket, weight := range defined {
+			if !labelPattern.MatchString(bracket) || math.IsNaN(weight) || math.IsInf(weight, 0) || weight <= 0 {
+				return nil, nil, fmt.Errorf("snapshot income bracket(%s) with weight %v is invalid", bracket, weight)
+			}
+		}
+	}
+	members := make(map[string]string, len(s.MemberBrackets))
+	for name, bracket := range s.MemberBrackets {
+		key := normalizeName(name)
+		if _, exists := people[key]; !exists {
+			return nil, nil, fmt.Errorf("snapshot income bracket for person(%s), who is not a member", name)
+		}
+		if _, ok := defined[bracket]; !ok {
+			return nil, nil, fmt.Errorf("snapshot income bracket(%s) of person(%s) is not defined", bracket, name)
+		}
+		members[key] = bracket
+	}
+	return maps.Clone(s.IncomeBrackets), members, nil
+}
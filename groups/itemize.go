@@ -0,0 +1,134 @@
+package groups
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+)
+
+// ItemSpec is one line of an itemized expense: AmountMicroCents of the original total,
+// split equally among Participants. Without participants, the item is split among the
+// original expense's participants. Under the strict participation policy, members left
+// out of an item must be listed in Excluded, or in the original expense's.
+type ItemSpec struct {
+	Description      string   `json:"description"`
+	AmountMicroCents int64    `json:"amount_micro_cents"`
+	Participants     []string `json:"participants,omitempty"`
+	Excluded         []string `json:"excluded,omitempty"`
+}
+
+// SplitExpenseIntoItems replaces expense id with one expense per item, paid by the same
+// person, e.g. "that $100 was $60 food for everyone and $40 drinks for Alice and Bob". The
+// items must add up to the original total. The new expenses keep the original's currency,
+// labels, event and date; the original and its debt edges are removed. It returns the IDs
+// of the new expenses in item order.
+//
+// Expenses with several payers, a discount, a payer's personal portion, an outside
+// coverage, a tip or settled participants can't be itemized. Each item is checked against
+// the group's participation policy like a new expense.
+func (g *Group) SplitExpenseIntoItems(id int, items []ItemSpec) ([]int, error) {
+	if len(items) < 2 {
+		return nil, errors.New("at least 2 items are required to itemize an expense")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	original, exists := g.expenses[id]
+	if !exists {
+		return nil, fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+	}
+	if len(original.PaidByMap) > 0 || original.DiscountMicroCents > 0 || original.PayerPersonalMicroCents > 0 ||
+		original.CoveredPercent > 0 || original.TipMicroCents > 0 || len(original.SettledParticipants) > 0 {
+		return nil, fmt.Errorf("expense(%d) can't be itemized: only expenses with a single payer and no discount, personal portion, coverage, tip or settled participants can", id)
+	}
+
+	sum := int64(0)
+	for _, item := range items {
+		sum += item.AmountMicroCents
+	}
+	if sum != original.TotalMicroCents {
+		slog.Error("itemized amounts don't add up", "group", g.Name, "expense_id", id, "sum", sum, "total", original.TotalMicroCents)
+		return nil, fmt.Errorf("items must add up to the expense total %s, got %s",
+			formatMicroCentsAsDollars(original.TotalMicroCents), formatMicroCentsAsDollars(sum))
+	}
+
+	var defaultParticipants []string
+	for key := range original.ResolvedShares {
+		defaultParticipants = append(defaultParticipants, g.displayName(key))
+	}
+
+	resolved := make([]Expense, 0, len(items))
+	for i, item := range items {
+		participants := item.Participants
+		if len(participants) == 0 {
+			participants = defaultParticipants
+		}
+		included := map[string]bool{}
+		for _, name := range participants {
+			key := normalizeName(name)
+			if _, exists := g.people[key]; !exists {
+				return nil, fmt.Errorf("item %d: person(%s) not found in group(%s)", i+1, name, g.Name)
+			}
+			included[key] = true
+		}
+		for _, name := range item.Excluded {
+			if _, exists := g.people[normalizeName(name)]; !exists {
+				return nil, fmt.Errorf("item %d: excluded person(%s) not found in group(%s)", i+1, name, g.Name)
+			}
+		}
+		e := Expense{
+			TotalMicroCents: item.AmountMicroCents,
+			PaidBy:          original.PaidBy,
+			Currency:        original.Currency,
+			ExchangeRate:    original.ExchangeRate,
+			Description:     item.Description,
+			SplitMethod:     "equal",
+			Labels:          slices.Clone(original.Labels),
+			Event:           original.Event,
+			EnteredBy:       original.EnteredBy,
+			CreatedAt:       original.CreatedAt,
+		}
+		if original.ExchangeRate > 0 {
+			// item amounts are in the home currency, like the original's total
+			e.OriginalMicroCents = int64(math.Round(float64(item.AmountMicroCents) / original.ExchangeRate))
+		}
+		for key, p := range g.people {
+			if !included[key] {
+				e.Excluded = append(e.Excluded, p.Name)
+			}
+		}
+		slices.Sort(e.Excluded)
+		if err := validateExpenseFields(&e); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i+1, err)
+		}
+		if err := g.resolveExpense(&e); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i+1, err)
+		}
+		// the members left out above count as excluded only when someone said so
+		probe := e
+		probe.Excluded = nil
+		for _, name := range slices.Concat(item.Excluded, original.Excluded) {
+			probe.Excluded = append(probe.Excluded, g.displayName(normalizeName(name)))
+		}
+		if err := g.checkAllParticipate(&probe); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i+1, err)
+		}
+		resolved = append(resolved, e)
+	}
+
+	delete(g.expenses, id)
+	g.removeExpenseEdges(original)
+	ids := make([]int, 0, len(resolved))
+	for i := range resolved {
+		e := &resolved[i]
+		if err := g.storeExpense(e); err != nil {
+			return nil, err
+		}
+		ids = append(ids, e.ID)
+	}
+	slog.Debug("SplitExpenseIntoItems", "group", g.Name, "expense_id", id, "items", len(ids))
+	return ids, nil
+}
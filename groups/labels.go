@@ -0,0 +1,88 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxExpenseLabels is how many labels one expense can carry.
+const maxExpenseLabels = 10
+
+var labelPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// normalizeLabels lowercases and trims labels, drops duplicates and checks them against
+// labelPattern and maxExpenseLabels. The first occurrence of each label keeps its place.
+func normalizeLabels(labels []string) ([]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if !labelPattern.MatchString(label) {
+			slog.Error("expense label validation failed", "label", label)
+			return nil, fmt.Errorf("expense label(%s) must match %q", label, labelPattern.String())
+		}
+		if !slices.Contains(normalized, label) {
+			normalized = append(normalized, label)
+		}
+	}
+	if len(normalized) > maxExpenseLabels {
+		return nil, fmt.Errorf("an expense can have at most %d labels, got %d", maxExpenseLabels, len(normalized))
+	}
+	return normalized, nil
+}
+
+// ExpensesWithLabel returns copies of the expenses carrying label, in ID order.
+// Labels are matched case-insensitively.
+func (g *Group) ExpensesWithLabel(label string) []Expense {
+	label = strings.ToLower(strings.TrimSpace(label))
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	list := []Expense{}
+	for _, e := range g.sortedExpenses() {
+		if slices.Contains(e.Labels, label) {
+			list = append(list, copyExpense(e))
+		}
+	}
+	return list
+}
+
+// PersonCategoryShare returns how much of the spending in category person is responsible
+// for, in micro cents: the sum of their resolved shares of every expense carrying the
+// category label. It is 0 when person took part in none of them, and an error when no
+// expense carries the label at all.
+func (g *Group) PersonCategoryShare(name, category string) (int64, error) {
+	labels, err := normalizeLabels([]string{category})
+	if err != nil {
+		return 0, err
+	}
+	label := labels[0]
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		return 0, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(name), g.Name)
+	}
+
+	found := false
+	share := int64(0)
+	for _, e := range g.expenses {
+		if !slices.Contains(e.Labels, label) {
+			continue
+		}
+		found = true
+		share += e.ResolvedShares[key]
+	}
+	if !found {
+		return 0, fmt.Errorf("no expense in group(%s) is labelled %s", g.Name, label)
+	}
+	return share, nil
+}
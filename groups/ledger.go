@@ -0,0 +1,151 @@
+package groups
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LedgerEntry is one edge of the debt graph in readable form.
+// For Kind "expense", From owes To the amount because of ExpenseID.
+// For Kind "payment", From paid To the amount; ExpenseID is PaymentExpenseID.
+// For Kind "transfer", From owes To the amount because of TransferDebt or ForgiveAllDebts;
+// ExpenseID is TransferExpenseID.
+type LedgerEntry struct {
+	Kind             string    `json:"kind"`
+	From             string    `json:"from"`
+	To               string    `json:"to"`
+	AmountMicroCents int64     `json:"amount_micro_cents"`
+	ExpenseID        int       `json:"expense_id"`
+	ExpenseIDs       []int     `json:"expense_ids,omitempty"` // set for coalesced edges
+	Memo             string    `json:"memo,omitempty"`
+	EnteredBy        string    `json:"entered_by,omitempty"` // who added the expense, for Kind "expense"
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Ledger returns every expense share and payment in the group, oldest first.
+func (g *Group) Ledger() []LedgerEntry {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.ledger(nil)
+}
+
+// DebtsBetween returns the ledger entries between two people, oldest first,
+// and the net amount a owes b in micro cents (negative when b owes a).
+func (g *Group) DebtsBetween(a, b string) ([]LedgerEntry, int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	aKey := normalizeName(a)
+	bKey := normalizeName(b)
+	if _, exists := g.people[aKey]; !exists {
+		return nil, 0, fmt.Errorf("person(%s) not found in group(%s)", a, g.Name)
+	}
+	if _, exists := g.people[bKey]; !exists {
+		return nil, 0, fmt.Errorf("person(%s) not found in group(%s)", b, g.Name)
+	}
+
+	entries := g.ledger(func(from, to string) bool {
+		return (from == aKey && to == bKey) || (from == bKey && to == aKey)
+	})
+	return entries, g.netOwed(aKey, bKey), nil
+}
+
+// netOwed returns the net amount a owes b in micro cents (negative when b owes a).
+// Caller must hold the group lock.
+func (g *Group) netOwed(aKey, bKey string) int64 {
+	net := int64(0)
+	for _, edge := range g.graph.nodes[aKey] {
+		if edgeInfo, ok := edgeMetadata(edge); ok && edge.To == bKey {
+			net += edgeInfo.AmountInMicroCents
+		}
+	}
+	for _, edge := range g.graph.nodes[bKey] {
+		if edgeInfo, ok := edgeMetadata(edge); ok && edge.To == aKey {
+			net -= edgeInfo.AmountInMicroCents
+		}
+	}
+	return net
+}
+
+// ledger lists the edges accepted by include (nil accepts all) as ledger entries.
+// Caller must hold the group lock.
+func (g *Group) ledger(include func(from, to string) bool) []LedgerEntry {
+	entries := []LedgerEntry{}
+	for from, edges := range g.graph.nodes {
+		for _, edge := range edges {
+			if include != nil && !include(from, edge.To) {
+				continue
+			}
+			edgeInfo, ok := edgeMetadata(edge)
+			if !ok {
+				continue
+			}
+			entry := LedgerEntry{
+				Kind:             "expense",
+				From:             g.displayName(from),
+				To:               g.displayName(edge.To),
+				AmountMicroCents: edgeInfo.AmountInMicroCents,
+				ExpenseID:        edgeInfo.ExpenseID,
+				ExpenseIDs:       edgeInfo.ExpenseIDs,
+				Memo:             edgeInfo.Memo,
+				CreatedAt:        edge.CreatedAt,
+			}
+			switch edgeInfo.ExpenseID {
+			case PaymentExpenseID:
+				// payment edges point from the receiver to the payer
+				entry.Kind = "payment"
+				entry.From, entry.To = entry.To, entry.From
+			case TransferExpenseID:
+				entry.Kind = "transfer"
+			default:
+				if e, exists := g.expenses[edgeInfo.ExpenseID]; exists {
+					entry.EnteredBy = e.EnteredBy
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+		}
+		if entries[i].ExpenseID != entries[j].ExpenseID {
+			return entries[i].ExpenseID < entries[j].ExpenseID
+		}
+		if entries[i].From != entries[j].From {
+			return entries[i].From < entries[j].From
+		}
+		return entries[i].To < entries[j].To
+	})
+	return entries
+}
+
+// PersonLedger returns, for one person, whom they owe and who owes them, in dollars keyed by
+// display name. Amounts are pairwise net debts; settled pairs are left out.
+func (g *Group) PersonLedger(name string) (owes map[string]float64, owedBy map[string]float64, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		return nil, nil, fmt.Errorf("person(%s) not found in group(%s)", name, g.Name)
+	}
+
+	owes = map[string]float64{}
+	owedBy = map[string]float64{}
+	for other := range g.people {
+		if other == key {
+			continue
+		}
+		net := g.netOwed(key, other)
+		switch {
+		case net >= settleThresholdMicroCents:
+			owes[g.displayName(other)] = microCentsToDollars(net)
+		case net <= -settleThresholdMicroCents:
+			owedBy[g.displayName(other)] = microCentsToDollars(-net)
+		}
+	}
+	return owes, owedBy, nil
+}
@@ -0,0 +1,39 @@
+package groups
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// AddMileageExpense adds a carpool expense of total micro cents paid by payer and split in
+// proportion to the miles each person rode, e.g. gas for a trip where people got in and
+// out at different stops. It is a weights split with the miles as weights; members left
+// out of miles, or with 0 miles, owe nothing. It returns the stored expense.
+func (g *Group) AddMileageExpense(payer string, total int64, miles map[string]float64, description string) (Expense, error) {
+	sum := 0.0
+	for name, m := range miles {
+		if math.IsNaN(m) || math.IsInf(m, 0) || m < 0 {
+			return Expense{}, fmt.Errorf("miles for %s must be >= 0, got %v", strings.TrimSpace(name), m)
+		}
+		sum += m
+	}
+	if sum <= 0 {
+		return Expense{}, fmt.Errorf("miles must add up to more than 0")
+	}
+
+	e := &Expense{
+		PaidBy:          payer,
+		TotalMicroCents: total,
+		Description:     description,
+		SplitMethod:     "weights",
+		SplitWeights:    make(map[string]float64, len(miles)),
+	}
+	for name, m := range miles {
+		e.SplitWeights[name] = m
+	}
+	if err := g.AddExpense(e); err != nil {
+		return Expense{}, err
+	}
+	return g.GetExpense(e.ID)
+}
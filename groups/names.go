@@ -1,7 +1,171 @@
 package groups
 
-import "strings"
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
 
 func normalizeName(name string) string {
 	return strings.ToLower(strings.TrimSpace(name))
 }
+
+// disambiguatorPattern matches the part in parentheses of a name such as "Alex (work)".
+var disambiguatorPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_ -]{0,15}$`)
+
+// NameCollisionPolicy decides what happens when a person is added under a name that
+// is already taken in the group (names are compared after normalization).
+type NameCollisionPolicy string
+
+const (
+	// NameCollisionReject refuses the duplicate. This is the default.
+	NameCollisionReject NameCollisionPolicy = "reject"
+	// NameCollisionAutoSuffix adds the duplicate with the first free numeric suffix,
+	// e.g. "Alex (2)".
+	NameCollisionAutoSuffix NameCollisionPolicy = "auto_suffix"
+	// NameCollisionDisambiguate requires the duplicate to be added with an explicit
+	// disambiguator, e.g. "Alex (work)".
+	NameCollisionDisambiguate NameCollisionPolicy = "disambiguate"
+)
+
+var nameCollisionPolicies = []NameCollisionPolicy{NameCollisionReject, NameCollisionAutoSuffix, NameCollisionDisambiguate}
+
+// SetNameCollisionPolicy sets how AddPerson treats names that are already taken.
+// It only affects people added from now on.
+func (g *Group) SetNameCollisionPolicy(policy NameCollisionPolicy) error {
+	for _, p := range nameCollisionPolicies {
+		if p == policy {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+
+			g.nameCollisionPolicy = policy
+			return nil
+		}
+	}
+	return fmt.Errorf("name collision policy must be one of %s|%s|%s", NameCollisionReject, NameCollisionAutoSuffix, NameCollisionDisambiguate)
+}
+
+// NameCollisionPolicy returns the group's name collision policy.
+func (g *Group) NameCollisionPolicy() NameCollisionPolicy {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.nameCollisionPolicy == "" {
+		return NameCollisionReject
+	}
+	return g.nameCollisionPolicy
+}
+
+// resolveNameCollision returns the display name a new person is added under, applying
+// the group's policy when name is taken. The returned name's key is always unused.
+// Caller must hold the group lock.
+func (g *Group) resolveNameCollision(name, base, disambiguator string) (string, error) {
+	existing, exists := g.people[normalizeName(name)]
+	if !exists {
+		if g.nameCollisionPolicy == NameCollisionDisambiguate && disambiguator == "" {
+			// a plain "Alex" must not slip in next to "Alex (work)" either
+			for _, p := range g.people {
+				if b, _ := splitDisambiguator(p.Name); normalizeName(b) == normalizeName(base) {
+					return name, fmt.Errorf("person(%s) already exists in group(%s); add them as %q", p.Name, g.Name, base+" (<disambiguator>)")
+				}
+			}
+		}
+		return name, nil
+	}
+
+	switch g.nameCollisionPolicy {
+	case NameCollisionAutoSuffix:
+		for n := 2; ; n++ {
+			candidate := fmt.Sprintf("%s (%d)", base, n)
+			if _, taken := g.people[normalizeName(candidate)]; !taken {
+				return candidate, nil
+			}
+		}
+	case NameCollisionDisambiguate:
+		return name, fmt.Errorf("person(%s) already exists in group(%s); add them as %q", existing.Name, g.Name, base+" (<disambiguator>)")
+	default:
+		return name, fmt.Errorf("person(%s) already exists in group(%s)", existing.Name, g.Name)
+	}
+}
+
+// splitDisambiguator splits "Alex (work)" into "Alex" and "work".
+// Names without a trailing parenthesized part are returned as is.
+func splitDisambiguator(name string) (string, string) {
+	if !strings.HasSuffix(name, ")") {
+		return name, ""
+	}
+	i := strings.LastIndex(name, " (")
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+2 : len(name)-1]
+}
+
+// validPersonName reports whether name is a valid member name, including the
+// suffixed forms produced by the name collision policies.
+func validPersonName(name string) bool {
+	base, disambiguator := splitDisambiguator(name)
+	if !personNamePattern.MatchString(base) {
+		return false
+	}
+	return disambiguator == "" || disambiguatorPattern.MatchString(disambiguator)
+}
+
+// maxNameDistance is the largest edit distance at which ResolveName still suggests a
+// member, enough for a typo or two.
+const maxNameDistance = 2
+
+// ResolveName looks up a name typed by a user. An exact match (after normalization) returns
+// the member's display name. Otherwise candidates lists the members within a couple of
+// typos, closest first, and err says "did you mean ...?" when there are any.
+func (g *Group) ResolveName(input string) (resolved string, candidates []string, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(input)
+	if p, exists := g.people[key]; exists {
+		return p.Name, nil, nil
+	}
+
+	distances := map[string]int{}
+	for k, p := range g.people {
+		if d := editDistance(key, k); d <= maxNameDistance {
+			candidates = append(candidates, p.Name)
+			distances[p.Name] = d
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if distances[candidates[i]] != distances[candidates[j]] {
+			return distances[candidates[i]] < distances[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) == 0 {
+		return "", candidates, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(input), g.Name)
+	}
+	return "", candidates, fmt.Errorf("person(%s) not found in group(%s); did you mean %s?",
+		strings.TrimSpace(input), g.Name, strings.Join(candidates, " or "))
+}
+
+// editDistance returns the Levenshtein distance between a and b, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
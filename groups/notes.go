@@ -0,0 +1,73 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxParticipantNoteLength is the longest participant note, in characters.
+const maxParticipantNoteLength = 140
+
+// resolveParticipantNotes validates notes against the expense's participants and returns
+// them keyed by display name, without blank notes.
+// Caller must hold the group lock.
+func (g *Group) resolveParticipantNotes(notes map[string]string, shares map[string]int64) (map[string]string, error) {
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	resolved := make(map[string]string, len(notes))
+	for name, note := range notes {
+		key := normalizeName(name)
+		if _, ok := shares[key]; !ok {
+			slog.Error("participant note validation failed, name is not a participant", "name", name, "group", g.Name)
+			return nil, fmt.Errorf("participant note for person(%s), who is not a participant of the expense", name)
+		}
+		note = strings.TrimSpace(note)
+		if utf8.RuneCountInString(note) > maxParticipantNoteLength {
+			return nil, fmt.Errorf("participant note for person(%s) is longer than %d characters", name, maxParticipantNoteLength)
+		}
+		if note != "" {
+			resolved[g.displayName(key)] = note
+		}
+	}
+	return resolved, nil
+}
+
+// SetParticipantNote attaches a note about one participant to an expense, such as
+// "had the steak", replacing any earlier one. An empty note removes it. Notes are only
+// context; they never change the split.
+func (g *Group) SetParticipantNote(id int, name, note string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e, exists := g.expenses[id]
+	if !exists {
+		return fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+	}
+	notes := maps.Clone(e.ParticipantNotes)
+	if notes == nil {
+		notes = map[string]string{}
+	}
+	// drop the old note, stored under the display name
+	maps.DeleteFunc(notes, func(k, _ string) bool { return normalizeName(k) == normalizeName(name) })
+	notes[name] = note
+	resolved, err := g.resolveParticipantNotes(notes, e.ResolvedShares)
+	if err != nil {
+		return err
+	}
+	e.ParticipantNotes = resolved
+	return nil
+}
+
+// participantNotesText joins notes into one line in name order, e.g. "Bob: had the steak".
+func participantNotesText(notes map[string]string) string {
+	lines := make([]string, 0, len(notes))
+	for _, name := range slices.Sorted(maps.Keys(notes)) {
+		lines = append(lines, name+": "+notes[name])
+	}
+	return strings.Join(lines, "; ")
+}
@@ -0,0 +1,42 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// SetRequireAllParticipate turns the strict participation policy on or off. When it is on,
+// AddExpense rejects an expense that leaves a member without a share unless the member is
+// listed in Excluded, so nobody is left out of a percentage, weights or headcount split by
+// accident. Equal, duration and income splits already include every member who isn't
+// excluded. It is off by default.
+func (g *Group) SetRequireAllParticipate(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.requireAllParticipate = on
+}
+
+// checkAllParticipate enforces the strict participation policy for the resolved expense e.
+// Caller must hold the group lock.
+func (g *Group) checkAllParticipate(e *Expense) error {
+	if !g.requireAllParticipate {
+		return nil
+	}
+	missing := []string{}
+	for key, p := range g.people {
+		if e.ResolvedShares[key] > 0 || slices.Contains(e.Excluded, p.Name) {
+			continue
+		}
+		missing = append(missing, p.Name)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	slices.Sort(missing)
+	slog.Error("expense leaves members out under the strict participation policy", "group", g.Name, "missing", len(missing))
+	return fmt.Errorf("group(%s) requires every member to take part in each expense; give a share to %s or list them in excluded",
+		g.Name, strings.Join(missing, ", "))
+}
@@ -0,0 +1,136 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// PaymentExpenseID is the sentinel EdgeMetadata.ExpenseID of edges created by RecordPayment,
+// so settlements are distinguishable from expenses.
+const PaymentExpenseID = -1
+
+// maxMemoLength bounds the free text attached to a payment.
+const maxMemoLength = 100
+
+// validateMemo checks the length of a payment memo in characters, not bytes.
+func validateMemo(memo string) error {
+	if n := utf8.RuneCountInString(memo); n > maxMemoLength {
+		return fmt.Errorf("memo must be at most %d characters, got %d", maxMemoLength, n)
+	}
+	return nil
+}
+
+// RecordPayment records that "from" paid "to" microCents to settle a debt. A payment
+// larger than what "from" owes "to" is rejected; see RecordPaymentWithMemo.
+func (g *Group) RecordPayment(from, to string, microCents int64) error {
+	_, err := g.RecordPaymentWithMemo(from, to, microCents, "", false)
+	return err
+}
+
+// RecordPaymentWithMemo is like RecordPayment but attaches a free-text memo (e.g. "Venmo on
+// 3/5") surfaced by Ledger and DebtsBetween, and records a payment larger than the
+// outstanding debt when allowOverpay is set. It returns what "from" still owes "to"
+// afterwards in micro cents (negative when "to" now owes "from").
+//
+// The payment is stored as a reverse edge to->from, so the net amount "from" owes "to" shrinks.
+func (g *Group) RecordPaymentWithMemo(from, to string, microCents int64, memo string, allowOverpay bool) (int64, error) {
+	if microCents <= 0 {
+		slog.Error("payment amount must be positive", "amount_micro_cents", microCents)
+		return 0, fmt.Errorf("payment amount(%d) must be positive", microCents)
+	}
+	if err := validateMemo(memo); err != nil {
+		return 0, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fromKey := normalizeName(from)
+	toKey := normalizeName(to)
+	if _, exists := g.people[fromKey]; !exists {
+		return 0, fmt.Errorf("person(%s) not found in group(%s)", from, g.Name)
+	}
+	if _, exists := g.people[toKey]; !exists {
+		return 0, fmt.Errorf("person(%s) not found in group(%s)", to, g.Name)
+	}
+	if fromKey == toKey {
+		return 0, fmt.Errorf("a person cannot pay themselves")
+	}
+
+	owed := g.netOwed(fromKey, toKey)
+	if microCents > owed && !allowOverpay {
+		return 0, fmt.Errorf("%s owes %s only %s, less than the payment of %s; set allow_overpay to record it anyway",
+			g.displayName(fromKey), g.displayName(toKey), formatMicroCentsAsDollars(max(owed, 0)), formatMicroCentsAsDollars(microCents))
+	}
+	if err := g.addPayment(fromKey, toKey, microCents, memo, time.Now()); err != nil {
+		return 0, err
+	}
+	slog.Debug("RecordPaymentWithMemo", "group", g.Name, "from", from, "to", to, "amount_micro_cents", microCents)
+	return owed - microCents, nil
+}
+
+// addPayment adds the edge of a payment from fromKey to toKey, both members, made at at.
+// Caller must hold the group lock.
+func (g *Group) addPayment(fromKey, toKey string, microCents int64, memo string, at time.Time) error {
+	metadata := EdgeMetadata{
+		AmountInMicroCents: microCents,
+		ExpenseID:          PaymentExpenseID,
+		Memo:               memo,
+	}
+	// the payment is a reverse edge, so the net amount "from" owes "to" shrinks
+	return g.graph.addEdgeAt(toKey, fromKey, metadata, at)
+}
+
+// Payment is money that actually moved between two people: From paid To.
+// From and To are display names.
+type Payment struct {
+	From             string    `json:"from"`
+	To               string    `json:"to"`
+	AmountMicroCents int64     `json:"amount_micro_cents"`
+	Memo             string    `json:"memo,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// payments returns the payments recorded in the graph, oldest first.
+// Caller must hold the group lock.
+func (g *Group) payments() []Payment {
+	list := []Payment{}
+	for from, edges := range g.graph.nodes {
+		for _, e := range edges {
+			edgeInfo, ok := edgeMetadata(e)
+			if !ok || edgeInfo.ExpenseID != PaymentExpenseID {
+				continue
+			}
+			// payment edges point from the receiver to the payer
+			list = append(list, Payment{
+				From:             g.displayName(e.To),
+				To:               g.displayName(from),
+				AmountMicroCents: edgeInfo.AmountInMicroCents,
+				Memo:             edgeInfo.Memo,
+				CreatedAt:        e.CreatedAt,
+			})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		if list[i].From != list[j].From {
+			return list[i].From < list[j].From
+		}
+		return list[i].To < list[j].To
+	})
+	return list
+}
+
+// CompletedPayments returns the payments recorded so far, oldest first. Unlike the debt
+// views, this is the money that actually moved.
+func (g *Group) CompletedPayments() []Payment {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.payments()
+}
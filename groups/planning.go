@@ -0,0 +1,216 @@
+package groups
+
+import (
+	"maps"
+	"slices"
+	"sort"
+)
+
+// ContributionToEqualize returns how much each person should put towards a planned shared
+// purchase of targetTotal micro cents (split equally), taking current balances into account
+// so that afterwards everyone's balance is as equal as possible. People who have overpaid so
+// far contribute less, possibly nothing. The contributions add up to targetTotal.
+// It is read-only planning; nothing is recorded.
+func (g *Group) ContributionToEqualize(targetTotal int64) map[string]int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	contributions := map[string]int64{}
+	if targetTotal <= 0 || len(g.people) == 0 {
+		return contributions
+	}
+	for key, amount := range levelUp(g.netBalances(nil), targetTotal) {
+		contributions[g.displayName(key)] = amount
+	}
+	return contributions
+}
+
+// SuggestPoolContributions returns how much each person should put into a shared pool
+// where everyone is meant to chip in targetMicroCents (e.g. $50 each for snacks), netted
+// against current balances: people who are owed money put in their share minus what they
+// are owed (never below zero), and people who owe put in their share plus what they owe.
+// Unlike ContributionToEqualize, the target is a fixed amount per person rather than a
+// total. It is read-only planning; nothing is recorded.
+func (g *Group) SuggestPoolContributions(targetMicroCents int64) map[string]int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	contributions := map[string]int64{}
+	if targetMicroCents <= 0 {
+		return contributions
+	}
+	for key, balance := range g.netBalances(nil) {
+		contributions[g.displayName(key)] = max(targetMicroCents-balance, 0)
+	}
+	return contributions
+}
+
+// SuggestBuffer returns how much each person should pre-pay into a shared pot that will
+// cover anticipatedMicroCents of upcoming costs, split equally, so that once the pot is
+// spent everyone is even: each person's equal share minus their current balance. Someone
+// who is owed more than their share gets a negative amount, to be paid out of the pot,
+// so unlike ContributionToEqualize it also settles what is owed today. The amounts add up
+// to anticipatedMicroCents. It is read-only planning; nothing is recorded.
+func (g *Group) SuggestBuffer(anticipatedMicroCents int64) map[string]int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	buffer := map[string]int64{}
+	if anticipatedMicroCents <= 0 || len(g.people) == 0 {
+		return buffer
+	}
+	balances := g.netBalances(nil)
+	keys := slices.Sorted(maps.Keys(g.people))
+	share := anticipatedMicroCents / int64(len(keys))
+	rem := anticipatedMicroCents % int64(len(keys))
+	for i, key := range keys {
+		amount := share - balances[key]
+		if int64(i) < rem {
+			amount++
+		}
+		buffer[g.displayName(key)] = amount
+	}
+	return buffer
+}
+
+// PayerRotation suggests who should pay each of the next upcomingExpenses expenses so that
+// balances move towards equal: it simulates equal-sized expenses split equally among all
+// members, and each one is paid by whoever is the biggest debtor at that point. The size is
+// the group's average expense, so the schedule follows its usual spending. Ties go to the
+// name that sorts first. It is read-only planning; nothing is recorded.
+func (g *Group) PayerRotation(upcomingExpenses int) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	rotation := []string{}
+	if upcomingExpenses <= 0 || len(g.people) == 0 {
+		return rotation
+	}
+
+	size := int64(100 * 1000)
+	total, count := int64(0), int64(0)
+	for _, e := range g.expenses {
+		if !e.isGroupCredit() {
+			total += e.NetMicroCents()
+			count++
+		}
+	}
+	if count > 0 {
+		size = max(total/count, 1)
+	}
+
+	balances := g.netBalances(nil)
+	keys := slices.Sorted(maps.Keys(g.people))
+	share := size / int64(len(keys))
+	for range upcomingExpenses {
+		payer := keys[0]
+		for _, key := range keys[1:] {
+			if balances[key] < balances[payer] {
+				payer = key
+			}
+		}
+		for _, key := range keys {
+			balances[key] -= share
+		}
+		balances[payer] += size
+		rotation = append(rotation, g.displayName(payer))
+	}
+	return rotation
+}
+
+// BestPayerFor returns which member should pay an upcoming expense of amountMicroCents,
+// split equally among all members, to leave the group closest to balanced: the payer whose
+// payment leaves the least owed in total. When several payers leave the same amount owed,
+// the one who ends up nearest even themselves wins, so a small debtor is picked over a big
+// one when the expense would push the big one far into credit; remaining ties go to the
+// name that sorts first. It is empty for a group without members or a non-positive
+// amount. It is read-only planning; nothing is recorded.
+func (g *Group) BestPayerFor(amountMicroCents int64) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if amountMicroCents <= 0 || len(g.people) == 0 {
+		return ""
+	}
+	balances := g.netBalances(nil)
+	keys := slices.Sorted(maps.Keys(g.people))
+	share := amountMicroCents / int64(len(keys))
+
+	best := ""
+	bestOwed, bestOwn := int64(0), int64(0)
+	for _, payer := range keys {
+		owed := int64(0)
+		own := int64(0)
+		for _, key := range keys {
+			balance := balances[key] - share
+			if key == payer {
+				balance += amountMicroCents
+				own = absMicroCents(balance)
+			}
+			owed += absMicroCents(balance)
+		}
+		if best == "" || owed < bestOwed || (owed == bestOwed && own < bestOwn) {
+			best, bestOwed, bestOwn = payer, owed, own
+		}
+	}
+	return g.displayName(best)
+}
+
+// levelUp spreads total across people so their balances end up as level as possible:
+// the lowest balances are raised first ("water filling"). Every key of balances is in
+// the result, and the amounts add up to total.
+func levelUp(balances map[string]int64, total int64) map[string]int64 {
+	type person struct {
+		key     string
+		balance int64
+	}
+
+	people := make([]person, 0, len(balances))
+	for key, balance := range balances {
+		people = append(people, person{key: key, balance: balance})
+	}
+	sort.Slice(people, func(i, j int) bool {
+		if people[i].balance == people[j].balance {
+			return people[i].key < people[j].key
+		}
+		return people[i].balance < people[j].balance
+	})
+
+	// find how many of the lowest balances get raised, and to which level
+	k := len(people)
+	sum := int64(0)
+	for i, p := range people {
+		sum += p.balance
+		level, _ := floorDivMod(total+sum, int64(i+1))
+		if i+1 == len(people) || level <= people[i+1].balance {
+			k = i + 1
+			break
+		}
+	}
+
+	// the level rounds down, so the remainder to hand out is never negative
+	level, rem := floorDivMod(total+sum, int64(k))
+	out := make(map[string]int64, len(people))
+	for i, p := range people {
+		if i >= k {
+			out[p.key] = 0
+			continue
+		}
+		out[p.key] = level - p.balance
+		if int64(i) < rem {
+			out[p.key]++
+		}
+	}
+	return out
+}
+
+// floorDivMod divides a by b > 0 rounding towards negative infinity, unlike Go's /, and
+// returns the quotient and the remainder, which is in [0, b).
+func floorDivMod(a, b int64) (int64, int64) {
+	q, r := a/b, a%b
+	if r < 0 {
+		q--
+		r += b
+	}
+	return q, r
+}
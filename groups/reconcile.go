@@ -0,0 +1,115 @@
+package groups
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// reconcileDateWindow is how far apart a statement entry and an expense may be dated
+	// and still match; card charges often post a day or two after the purchase.
+	reconcileDateWindow = 3 * 24 * time.Hour
+	// reconcileAmountTolerance is the fraction of a statement amount an expense may differ
+	// by and still match, e.g. for a tip added after the card was authorized.
+	reconcileAmountTolerance = 0.01
+)
+
+// StatementEntry is one charge on a bank or card statement.
+type StatementEntry struct {
+	Date             time.Time `json:"date"`
+	AmountMicroCents int64     `json:"amount_micro_cents"`
+	Description      string    `json:"description"`
+}
+
+// ReconcileMatch pairs a statement entry with the expense it was matched to.
+type ReconcileMatch struct {
+	Entry     StatementEntry `json:"entry"`
+	ExpenseID int            `json:"expense_id"`
+}
+
+// ReconcileReport is the result of Reconcile. UnmatchedExpenses holds the IDs of expenses no
+// statement entry matched; UnmatchedEntries are charges that were never logged.
+type ReconcileReport struct {
+	Matched           []ReconcileMatch `json:"matched"`
+	UnmatchedExpenses []int            `json:"unmatched_expenses"`
+	UnmatchedEntries  []StatementEntry `json:"unmatched_entries"`
+}
+
+// Reconcile matches the group's expenses against the entries of a bank statement, to check
+// that every real charge was logged. An entry matches an expense when the expense's amount
+// after discount is within 1% (at least a cent) of the entry and the expense was added
+// within 3 days of the entry's date; descriptions are not compared. Entries are matched in
+// date order, each to the closest unmatched expense by amount, then date. An expense is
+// matched at most once.
+func (g *Group) Reconcile(statementEntries []StatementEntry) (ReconcileReport, error) {
+	for i, entry := range statementEntries {
+		if entry.AmountMicroCents <= 0 {
+			return ReconcileReport{}, fmt.Errorf("statement entry %d: amount must be > 0", i+1)
+		}
+		if entry.Date.IsZero() {
+			return ReconcileReport{}, fmt.Errorf("statement entry %d: date is required", i+1)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	entries := make([]StatementEntry, len(statementEntries))
+	copy(entries, statementEntries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	expenses := g.sortedExpenses()
+	matched := make(map[int]bool, len(expenses))
+	report := ReconcileReport{
+		Matched:           []ReconcileMatch{},
+		UnmatchedExpenses: []int{},
+		UnmatchedEntries:  []StatementEntry{},
+	}
+	for _, entry := range entries {
+		tolerance := max(int64(float64(entry.AmountMicroCents)*reconcileAmountTolerance), settleThresholdMicroCents)
+		var best *Expense
+		var bestAmount int64
+		var bestDate time.Duration
+		for _, e := range expenses {
+			if matched[e.ID] {
+				continue
+			}
+			amountDiff := absMicroCents(e.NetMicroCents() - entry.AmountMicroCents)
+			dateDiff := entry.Date.Sub(startOfDay(e.CreatedAt)).Abs()
+			if amountDiff > tolerance || dateDiff > reconcileDateWindow {
+				continue
+			}
+			if best == nil || amountDiff < bestAmount || (amountDiff == bestAmount && dateDiff < bestDate) {
+				best, bestAmount, bestDate = e, amountDiff, dateDiff
+			}
+		}
+		if best == nil {
+			report.UnmatchedEntries = append(report.UnmatchedEntries, entry)
+			continue
+		}
+		matched[best.ID] = true
+		report.Matched = append(report.Matched, ReconcileMatch{Entry: entry, ExpenseID: best.ID})
+	}
+	for _, e := range expenses {
+		if !matched[e.ID] {
+			report.UnmatchedExpenses = append(report.UnmatchedExpenses, e.ID)
+		}
+	}
+	return report, nil
+}
+
+// startOfDay returns midnight of t's day in t's location.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func absMicroCents(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
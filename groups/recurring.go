@@ -0,0 +1,154 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// minRecurringInterval is the shortest interval a recurring expense may repeat at.
+const minRecurringInterval = 24 * time.Hour
+
+// MaxRecurringIntervalDays is the longest interval, in days, a recurring expense may repeat at.
+const MaxRecurringIntervalDays = 366
+
+// maxRecurringInterval is MaxRecurringIntervalDays as a duration.
+const maxRecurringInterval = MaxRecurringIntervalDays * 24 * time.Hour
+
+// MaxOccurrencesPerMaterialize bounds how many expenses one MaterializeRecurring call adds,
+// so a start date far in the past can't add years of daily expenses under the group lock.
+const MaxOccurrencesPerMaterialize = 500
+
+// validateRecurringInterval checks that interval is within the bounds above.
+func validateRecurringInterval(interval time.Duration) error {
+	if interval < minRecurringInterval || interval > maxRecurringInterval {
+		return fmt.Errorf("recurring interval(%s) must be between %s and %d days", interval, minRecurringInterval, MaxRecurringIntervalDays)
+	}
+	return nil
+}
+
+// RecurringExpense is an expense that repeats every Interval, such as rent or utilities.
+// Next is when the next occurrence is due; MaterializeRecurring turns due occurrences
+// into real expenses.
+type RecurringExpense struct {
+	ID       int           `json:"id"`
+	Template Expense       `json:"template"`
+	Interval time.Duration `json:"interval"`
+	Next     time.Time     `json:"next"`
+}
+
+// AddRecurring registers a recurring expense and returns its ID. The first occurrence
+// is due at template.CreatedAt, or now when it is zero. The template is validated like
+// an expense passed to AddExpense but nothing is added until MaterializeRecurring.
+//
+// For the "duration" split method each occurrence covers [due, due+interval).
+func (g *Group) AddRecurring(template Expense, interval time.Duration) (int, error) {
+	if err := validateRecurringInterval(interval); err != nil {
+		return 0, err
+	}
+	template = copyExpense(&template)
+	if err := validateExpenseFields(&template); err != nil {
+		return 0, err
+	}
+	next := template.CreatedAt
+	if next.IsZero() {
+		next = time.Now()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// validate on a throwaway copy so the template keeps the caller's split maps
+	probe := g.occurrence(template, interval, next)
+	if err := g.prepareNewExpense(&probe); err != nil {
+		slog.Error("recurring expense validation failed", "group", g.Name, "error", err.Error())
+		return 0, err
+	}
+
+	if g.recurring == nil {
+		g.recurring = make(map[int]*RecurringExpense)
+	}
+	g.recurringIdCounter++
+	r := &RecurringExpense{
+		ID:       g.recurringIdCounter,
+		Template: template,
+		Interval: interval,
+		Next:     next,
+	}
+	g.recurring[r.ID] = r
+	slog.Debug("AddRecurring", "group", g.Name, "recurring_id", r.ID, "interval", interval, "next", next)
+	return r.ID, nil
+}
+
+// MaterializeRecurring adds every occurrence of the group's recurring expenses that is
+// due at or before upTo as a real expense, and returns the new expense IDs in the order
+// they were added. Each occurrence goes through the checks of AddExpense, currency
+// conversion included. A recurring expense whose occurrence no longer validates (e.g. a
+// participant left the group) is skipped and logged; it stays due.
+//
+// At most MaxOccurrencesPerMaterialize expenses are added per call; the occurrences past
+// the cap stay due for the next call.
+func (g *Group) MaterializeRecurring(upTo time.Time) []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := []int{}
+	for _, id := range slices.Sorted(maps.Keys(g.recurring)) {
+		r := g.recurring[id]
+		for !r.Next.After(upTo) {
+			if len(ids) == MaxOccurrencesPerMaterialize {
+				slog.Warn("recurring expense occurrences capped", "group", g.Name, "max", MaxOccurrencesPerMaterialize)
+				return ids
+			}
+			e := g.occurrence(r.Template, r.Interval, r.Next)
+			e.RecurringID = r.ID
+			if err := g.prepareNewExpense(&e); err != nil {
+				slog.Error("recurring expense occurrence failed", "group", g.Name, "recurring_id", r.ID, "due", r.Next, "error", err.Error())
+				break
+			}
+			if err := g.storeExpense(&e); err != nil {
+				slog.Error("recurring expense occurrence failed", "group", g.Name, "recurring_id", r.ID, "due", r.Next, "error", err.Error())
+				break
+			}
+			ids = append(ids, e.ID)
+			r.Next = r.Next.Add(r.Interval)
+		}
+	}
+	return ids
+}
+
+// ListRecurring returns the group's recurring expenses in ID order.
+func (g *Group) ListRecurring() []RecurringExpense {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.recurringList()
+}
+
+// recurringList returns copies of the recurring expenses in ID order.
+// Caller must hold the group lock.
+func (g *Group) recurringList() []RecurringExpense {
+	list := make([]RecurringExpense, 0, len(g.recurring))
+	for _, id := range slices.Sorted(maps.Keys(g.recurring)) {
+		r := *g.recurring[id]
+		r.Template = copyExpense(&r.Template)
+		list = append(list, r)
+	}
+	return list
+}
+
+// occurrence returns the expense a recurring template produces when due.
+// Caller must hold the group lock.
+func (g *Group) occurrence(template Expense, interval time.Duration, due time.Time) Expense {
+	e := copyExpense(&template)
+	e.ID = 0
+	e.CreatedAt = due
+	e.ResolvedShares = nil
+	if e.SplitMethod == "duration" {
+		e.PeriodStart = due
+		e.PeriodEnd = due.Add(interval)
+	}
+	return e
+}
@@ -0,0 +1,133 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RemovePerson removes name from the group, e.g. a member added with a typo. Someone who is
+// settled with everyone goes cleanly, even if they took part in expenses: the expenses they
+// paid are deleted, their share is taken out of the others and the payments they made or
+// received go with them, which leaves everyone else's balances as they were. Outstanding
+// balances with others, and recurring expenses that name them, block the removal and are
+// listed in the error unless force is set, in which case those debts and recurring expenses
+// are dropped the same way. Expenses paid by several people can't be taken apart and have to
+// be edited or deleted first. The person's graph node goes with them.
+func (g *Group) RemovePerson(name string, force bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		return fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(name), g.Name)
+	}
+
+	affected := []*Expense{}
+	shared := []string{}
+	for _, e := range g.sortedExpenses() {
+		_, paid := e.paidShares()[key]
+		_, share := e.ResolvedShares[key]
+		if !paid && !share && !slices.Contains(e.namedKeys(), key) {
+			continue
+		}
+		if len(e.PaidByMap) > 0 && (paid || share) {
+			shared = append(shared, strconv.Itoa(e.ID))
+			continue
+		}
+		affected = append(affected, e)
+	}
+	if len(shared) > 0 {
+		return fmt.Errorf("person(%s) is part of expenses %s paid by several people; edit or delete them first", g.displayName(key), strings.Join(shared, ", "))
+	}
+
+	recurring := []int{}
+	outstanding := []string{}
+	for _, id := range slices.Sorted(maps.Keys(g.recurring)) {
+		if slices.Contains(g.recurring[id].Template.namedKeys(), key) {
+			recurring = append(recurring, id)
+			outstanding = append(outstanding, fmt.Sprintf("recurring expense %d names %s", id, g.displayName(key)))
+		}
+	}
+	for _, other := range slices.Sorted(maps.Keys(g.people)) {
+		if other == key {
+			continue
+		}
+		switch net := g.netOwed(key, other); {
+		case net >= settleThresholdMicroCents:
+			outstanding = append(outstanding, fmt.Sprintf("%s owes %s %s", g.displayName(key), g.displayName(other), formatMicroCentsAsDollars(net)))
+		case net <= -settleThresholdMicroCents:
+			outstanding = append(outstanding, fmt.Sprintf("%s owes %s %s", g.displayName(other), g.displayName(key), formatMicroCentsAsDollars(-net)))
+		}
+	}
+	if len(outstanding) > 0 && !force {
+		return fmt.Errorf("person(%s) has outstanding balances or recurring expenses: %s; settle them or remove with force", g.displayName(key), strings.Join(outstanding, ", "))
+	}
+
+	deleted := []int{}
+	for _, e := range affected {
+		if !dropParticipant(e, key) {
+			delete(g.expenses, e.ID)
+			deleted = append(deleted, e.ID)
+		}
+	}
+	for _, id := range recurring {
+		delete(g.recurring, id)
+	}
+	// every debt and payment edge of theirs goes; the edges between the others are
+	// untouched, as dropParticipant leaves the others' shares as they were
+	dropped := g.graph.removeEdges(func(from string, e *edge) bool {
+		return from == key || e.To == key
+	})
+	delete(g.graph.nodes, key)
+	delete(g.people, key)
+	delete(g.memberBrackets, key)
+	slog.Debug("RemovePerson", "group", g.Name, "person", key, "dropped_edges", dropped, "deleted_expenses", deleted,
+		"dropped_recurring", recurring, "force", force)
+	return nil
+}
+
+// dropParticipant takes the person keyed key out of the single-payer expense e: their share
+// comes off the total and they are dropped from the split. A percentage split becomes a
+// weights split over the remaining percentages, so it still adds up, and a covered percent
+// is rescaled so the same dollars stay covered. It returns false when nothing is left of e,
+// i.e. they paid for it or were the only one with a share besides the covered portion, and
+// e should be deleted.
+func dropParticipant(e *Expense, key string) bool {
+	if normalizeName(e.PaidBy) == key {
+		return false
+	}
+	share := e.ResolvedShares[key]
+	if share >= e.NetMicroCents() {
+		return false
+	}
+	// the covered portion is a percentage of the total; keep the dollars covered the same
+	covered := e.CoveredMicroCents()
+	if covered > 0 && covered >= e.TotalMicroCents-share {
+		return false
+	}
+	e.TotalMicroCents -= share
+	if covered > 0 {
+		e.CoveredPercent = float64(covered) * 100 / float64(e.TotalMicroCents)
+	}
+	delete(e.ResolvedShares, key)
+	delete(e.RemainderMicroCents, key)
+	for _, m := range []map[string]float64{e.SplitPercentages, e.SplitWeights, e.TipSplitWeights} {
+		maps.DeleteFunc(m, func(name string, _ float64) bool { return normalizeName(name) == key })
+	}
+	maps.DeleteFunc(e.SplitHeadcount, func(name string, _ int) bool { return normalizeName(name) == key })
+	maps.DeleteFunc(e.SplitExactMicroCents, func(name string, _ int64) bool { return normalizeName(name) == key })
+	maps.DeleteFunc(e.ParticipantNotes, func(name string, _ string) bool { return normalizeName(name) == key })
+	named := func(name string) bool { return normalizeName(name) == key }
+	e.SettledParticipants = slices.DeleteFunc(e.SettledParticipants, named)
+	e.Excluded = slices.DeleteFunc(e.Excluded, named)
+	if e.SplitMethod == "percentage" {
+		e.SplitMethod = "weights"
+		e.SplitWeights = e.SplitPercentages
+		e.SplitPercentages = nil
+	}
+	return true
+}
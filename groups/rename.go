@@ -0,0 +1,133 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// RenamePerson renames a member everywhere the group refers to them: their debts,
+// payments and income bracket, and every stored expense's payers, split maps, resolved
+// shares, settled and excluded lists and notes, as well as recurring expense templates.
+// Changing only the case of a name is allowed. The group is left untouched if the rename fails.
+func (g *Group) RenamePerson(oldName, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if !validPersonName(newName) {
+		return fmt.Errorf("person name must start with a letter, match %q, and be [1, 32] chars long", personNamePattern.String())
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	oldKey, newKey := normalizeName(oldName), normalizeName(newName)
+	p, exists := g.people[oldKey]
+	if !exists {
+		return fmt.Errorf("person(%s) not found in group(%s)", oldName, g.Name)
+	}
+	if existing, taken := g.people[newKey]; taken && newKey != oldKey {
+		return fmt.Errorf("person(%s) already exists in group(%s)", existing.Name, g.Name)
+	}
+
+	// rebuild the whole state from a renamed snapshot so the graph can't drift from the expenses
+	s := g.snapshot()
+	rename := func(name string) string {
+		if normalizeName(name) == oldKey {
+			return newName
+		}
+		return name
+	}
+	for i := range s.People {
+		s.People[i].Name = rename(s.People[i].Name)
+	}
+	for i := range s.Expenses {
+		renameInExpense(&s.Expenses[i], oldKey, newName)
+	}
+	for i := range s.Payments {
+		s.Payments[i].From = rename(s.Payments[i].From)
+		s.Payments[i].To = rename(s.Payments[i].To)
+	}
+	for i := range s.Transfers {
+		s.Transfers[i].From = rename(s.Transfers[i].From)
+		s.Transfers[i].To = rename(s.Transfers[i].To)
+	}
+	for i := range s.Recurring {
+		renameInExpense(&s.Recurring[i].Template, oldKey, newName)
+	}
+	for i := range s.Archived {
+		renameInExpense(&s.Archived[i], oldKey, newName)
+	}
+	for i := range s.ArchivedPayments {
+		s.ArchivedPayments[i].From = rename(s.ArchivedPayments[i].From)
+		s.ArchivedPayments[i].To = rename(s.ArchivedPayments[i].To)
+	}
+	for i := range s.ArchivedTransfers {
+		s.ArchivedTransfers[i].From = rename(s.ArchivedTransfers[i].From)
+		s.ArchivedTransfers[i].To = rename(s.ArchivedTransfers[i].To)
+	}
+
+	people, expenses, gr, err := buildState(g.Name, s)
+	if err != nil {
+		slog.Error("rename person failed", "group", g.Name, "person", oldName, "error", err.Error())
+		return err
+	}
+	recurring := make(map[int]*RecurringExpense, len(s.Recurring))
+	for _, r := range s.Recurring {
+		recurring[r.ID] = &r
+	}
+
+	g.people = people
+	g.expenses = expenses
+	g.graph = gr
+	g.recurring = recurring
+	g.archived = s.Archived
+	g.archivedPayments = s.ArchivedPayments
+	g.archivedTransfers = s.ArchivedTransfers
+	if bracket, ok := g.memberBrackets[oldKey]; ok {
+		delete(g.memberBrackets, oldKey)
+		g.memberBrackets[newKey] = bracket
+	}
+	slog.Debug("RenamePerson", "group", g.Name, "from", p.Name, "to", newName)
+	return nil
+}
+
+// renameInExpense replaces the person with normalized name oldKey by newName in every
+// name-keyed field of e. e must be a copy; its maps and slices are modified in place.
+func renameInExpense(e *Expense, oldKey, newName string) {
+	newKey := normalizeName(newName)
+	if normalizeName(e.PaidBy) == oldKey {
+		e.PaidBy = newName
+	}
+	e.PaidByMap = renameKey(e.PaidByMap, oldKey, newName)
+	e.SplitPercentages = renameKey(e.SplitPercentages, oldKey, newName)
+	e.SplitWeights = renameKey(e.SplitWeights, oldKey, newName)
+	e.TipSplitWeights = renameKey(e.TipSplitWeights, oldKey, newName)
+	e.SplitHeadcount = renameKey(e.SplitHeadcount, oldKey, newKey)
+	e.SplitExactMicroCents = renameKey(e.SplitExactMicroCents, oldKey, newKey)
+	e.ParticipantNotes = renameKey(e.ParticipantNotes, oldKey, newName)
+	// resolved shares and remainders are keyed by normalized name
+	e.ResolvedShares = renameKey(e.ResolvedShares, oldKey, newKey)
+	e.RemainderMicroCents = renameKey(e.RemainderMicroCents, oldKey, newKey)
+	for i, name := range e.SettledParticipants {
+		if normalizeName(name) == oldKey {
+			e.SettledParticipants[i] = newName
+		}
+	}
+	for i, name := range e.Excluded {
+		if normalizeName(name) == oldKey {
+			e.Excluded[i] = newName
+		}
+	}
+}
+
+// renameKey moves the value stored under the key matching oldKey, compared by normalized
+// name, to newKey.
+func renameKey[V any](m map[string]V, oldKey, newKey string) map[string]V {
+	for k, v := range m {
+		if normalizeName(k) == oldKey {
+			delete(m, k)
+			m[newKey] = v
+			return m
+		}
+	}
+	return m
+}
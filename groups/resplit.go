@@ -0,0 +1,109 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ApplySplitTemplate splits each of expenseIDs again with the same method and params, e.g.
+// "redo these five as a 2:1 weight split". method is equal, percentage or weights; params
+// are the percentages or weights by person and must be empty for equal. Each expense keeps
+// its participants and is checked the way AddExpense would check it. Those that fail are
+// left alone and their errors returned by expense ID; the others are replaced and the debt
+// graph is rebuilt. updated lists the IDs that were re-split, in the order given.
+func (g *Group) ApplySplitTemplate(expenseIDs []int, method string, params map[string]float64) (updated []int, errs map[string]error) {
+	errs = map[string]error{}
+	switch method {
+	case "equal":
+		if len(params) > 0 {
+			errs["template"] = fmt.Errorf("an equal split takes no params, got %d", len(params))
+			return nil, errs
+		}
+	case "percentage", "weights":
+		if len(params) == 0 {
+			errs["template"] = fmt.Errorf("a %s split needs params", method)
+			return nil, errs
+		}
+	default:
+		errs["template"] = fmt.Errorf("split method must be equal, percentage or weights, got %q", method)
+		return nil, errs
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	edits := map[int]Expense{}
+	for _, id := range expenseIDs {
+		key := strconv.Itoa(id)
+		if _, seen := edits[id]; seen {
+			continue
+		}
+		e, exists := g.expenses[id]
+		if !exists {
+			errs[key] = fmt.Errorf("expense(%d) not found in group(%s)", id, g.Name)
+			continue
+		}
+
+		edited := copyExpense(e)
+		edited.SplitMethod = method
+		edited.SplitPercentages = nil
+		edited.SplitWeights = nil
+		edited.SplitHeadcount = nil
+		edited.SplitExactMicroCents = nil
+		edited.PeriodStart = time.Time{}
+		edited.PeriodEnd = time.Time{}
+		switch method {
+		case "percentage":
+			edited.SplitPercentages = maps.Clone(params)
+		case "weights":
+			edited.SplitWeights = maps.Clone(params)
+		case "equal":
+			// keep members who weren't part of the expense out of it
+			for k, p := range g.people {
+				if _, ok := e.ResolvedShares[k]; !ok && !slices.Contains(edited.Excluded, p.Name) {
+					edited.Excluded = append(edited.Excluded, p.Name)
+				}
+			}
+			slices.Sort(edited.Excluded)
+		}
+		if err := validateExpenseFields(&edited); err != nil {
+			errs[key] = err
+			continue
+		}
+		if err := g.resolveExpense(&edited); err != nil {
+			errs[key] = err
+			continue
+		}
+		if err := g.checkAllParticipate(&edited); err != nil {
+			errs[key] = err
+			continue
+		}
+		edits[id] = edited
+		updated = append(updated, id)
+	}
+	if len(edits) == 0 {
+		return nil, errs
+	}
+
+	s := g.snapshot()
+	for i := range s.Expenses {
+		if edited, ok := edits[s.Expenses[i].ID]; ok {
+			s.Expenses[i] = edited
+		}
+	}
+	people, expenses, gr, err := buildState(g.Name, s)
+	if err != nil {
+		slog.Error("bulk re-split failed", "group", g.Name, "error", err.Error())
+		errs["template"] = err
+		return nil, errs
+	}
+	g.people = people
+	g.expenses = expenses
+	g.graph = gr
+	slog.Debug("ApplySplitTemplate", "group", g.Name, "method", method, "updated", len(updated))
+	return updated, errs
+}
@@ -0,0 +1,111 @@
+package groups
+
+import (
+	"math"
+	"sort"
+)
+
+// RoundingFairnessReport returns, per person (display name), the micro cents they
+// absorbed beyond a fair share of the rounding remainders across all expenses: positive
+// means they paid more than they would have had remainders been spread evenly.
+//
+// Equal splits hand leftover micro cents to the alphabetically first participants, so
+// over many expenses the same people pay slightly more. For every expense, the remainder
+// is compared with an even spread over its participants. The totals are rounded to whole
+// micro cents such that the report sums to zero.
+func (g *Group) RoundingFairnessReport() map[string]int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	deviation := make(map[string]float64, len(g.people))
+	for key := range g.people {
+		deviation[key] = 0
+	}
+	for _, e := range g.expenses {
+		if len(e.RemainderMicroCents) == 0 {
+			continue
+		}
+		participants := 0
+		for _, share := range e.ResolvedShares {
+			if share > 0 {
+				participants++
+			}
+		}
+		remainder := int64(0)
+		for _, extra := range e.RemainderMicroCents {
+			remainder += extra
+		}
+		fair := float64(remainder) / float64(participants)
+		for key, share := range e.ResolvedShares {
+			if share > 0 {
+				deviation[key] += float64(e.RemainderMicroCents[key]) - fair
+			}
+		}
+	}
+
+	rounded := roundToZeroSum(deviation)
+	report := make(map[string]int64, len(rounded))
+	for key, v := range rounded {
+		report[g.displayName(key)] = v
+	}
+	return report
+}
+
+// roundToZeroSum rounds values that add up to (about) zero to integers that add up to
+// exactly zero. The rounding error is pushed onto the values that lost the most to it.
+func roundToZeroSum(values map[string]float64) map[string]int64 {
+	keys := make([]string, 0, len(values))
+	out := make(map[string]int64, len(values))
+	sum := int64(0)
+	for key, v := range values {
+		keys = append(keys, key)
+		out[key] = int64(math.Round(v))
+		sum += out[key]
+	}
+	// error is how much rounding added to the value
+	sort.Slice(keys, func(i, j int) bool {
+		ei := float64(out[keys[i]]) - values[keys[i]]
+		ej := float64(out[keys[j]]) - values[keys[j]]
+		if ei != ej {
+			return ei > ej
+		}
+		return keys[i] < keys[j]
+	})
+	for i := 0; sum > 0; i++ {
+		out[keys[i%len(keys)]]--
+		sum--
+	}
+	for i := 0; sum < 0; i++ {
+		out[keys[len(keys)-1-i%len(keys)]]++
+		sum++
+	}
+	return out
+}
+
+// remainders returns how far each share exceeds its floor, i.e. the micro cents it got
+// from rounding. Shares at or below their floor are left out.
+func remainders(shares map[string]int64, floor func(key string) int64) map[string]int64 {
+	out := map[string]int64{}
+	for key, share := range shares {
+		if extra := share - floor(key); extra > 0 {
+			out[key] = extra
+		}
+	}
+	return out
+}
+
+// proportionalFloor returns the rounded-down exact share of total for weights[key]/sum,
+// computed the same way as splitByPercent and splitByWeights.
+func proportionalFloor(total int64, weights map[string]float64, sum float64) func(key string) int64 {
+	return func(key string) int64 {
+		return int64(math.Floor((weights[key] / sum) * float64(total)))
+	}
+}
+
+func sumValues(m map[string]float64) float64 {
+	sum := 0.0
+	for _, v := range m {
+		sum += v
+	}
+	return sum
+}
@@ -0,0 +1,304 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// settleThresholdMicroCents is the smallest transfer worth suggesting (1 cent).
+// Smaller residuals come from micro-cent rounding and are ignored, like getMoneyTobePaid does.
+const settleThresholdMicroCents = 1000
+
+// Settlement is a suggested transfer: From pays To AmountMicroCents.
+// From and To are display names.
+type Settlement struct {
+	From             string `json:"from"`
+	To               string `json:"to"`
+	AmountMicroCents int64  `json:"amount_micro_cents"`
+}
+
+// SettleSubset returns the minimal set of transfers that settles the debts among the
+// given people only. Debts with anyone outside the subset are left untouched.
+func (g *Group) SettleSubset(people []string) ([]Settlement, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	subset := make(map[string]bool, len(people))
+	for _, name := range people {
+		key := normalizeName(name)
+		if _, exists := g.people[key]; !exists {
+			slog.Error("settle subset validation failed, name not in the group", "name", name, "group", g.Name)
+			return nil, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(name), g.Name)
+		}
+		subset[key] = true
+	}
+	if len(subset) < 2 {
+		return nil, fmt.Errorf("at least 2 distinct people are required to settle a subset, got %d", len(subset))
+	}
+
+	balances := g.netBalances(func(key string) bool { return subset[key] })
+	return g.minimalSettlement(balances), nil
+}
+
+// netBalances returns each person's net balance in micro cents over the edges whose
+// both ends satisfy include (nil includes everyone). Positive means others owe them.
+// Caller must hold the group lock.
+func (g *Group) netBalances(include func(key string) bool) map[string]int64 {
+	balances := make(map[string]int64, len(g.graph.nodes))
+	for from, edges := range g.graph.nodes {
+		if include != nil && !include(from) {
+			continue
+		}
+		if _, ok := balances[from]; !ok {
+			balances[from] = 0
+		}
+		for _, edge := range edges {
+			if include != nil && !include(edge.To) {
+				continue
+			}
+			edgeInfo, ok := edgeMetadata(edge)
+			if !ok {
+				continue
+			}
+			balances[from] -= edgeInfo.AmountInMicroCents
+			balances[edge.To] += edgeInfo.AmountInMicroCents
+		}
+	}
+	return balances
+}
+
+// minimalSettlement greedily matches the biggest debtor with the biggest creditor until
+// all balances are settled. Transfers below a cent are dropped.
+// Caller must hold the group lock.
+func (g *Group) minimalSettlement(balances map[string]int64) []Settlement {
+	settlements := []Settlement{}
+	for _, d := range matchDebts(balances) {
+		if d.amount < settleThresholdMicroCents {
+			continue
+		}
+		settlements = append(settlements, Settlement{
+			From:             g.displayName(d.from),
+			To:               g.displayName(d.to),
+			AmountMicroCents: d.amount,
+		})
+	}
+	return settlements
+}
+
+// matchDebts greedily matches the biggest debtor (negative balance) with the biggest
+// creditor (positive balance) until one side runs out. Ties are broken by name so the
+// result is deterministic.
+func matchDebts(balances map[string]int64) []debt {
+	type party struct {
+		key    string
+		amount int64
+	}
+
+	creditors := []party{}
+	debtors := []party{}
+	for key, balance := range balances {
+		switch {
+		case balance > 0:
+			creditors = append(creditors, party{key: key, amount: balance})
+		case balance < 0:
+			debtors = append(debtors, party{key: key, amount: -balance})
+		}
+	}
+	byAmount := func(list []party) func(i, j int) bool {
+		return func(i, j int) bool {
+			if list[i].amount == list[j].amount {
+				return list[i].key < list[j].key
+			}
+			return list[i].amount > list[j].amount
+		}
+	}
+	sort.Slice(creditors, byAmount(creditors))
+	sort.Slice(debtors, byAmount(debtors))
+
+	debts := []debt{}
+	for i, j := 0, 0; i < len(debtors) && j < len(creditors); {
+		amount := min(debtors[i].amount, creditors[j].amount)
+		debts = append(debts, debt{from: debtors[i].key, to: creditors[j].key, amount: amount})
+		debtors[i].amount -= amount
+		creditors[j].amount -= amount
+		if debtors[i].amount == 0 {
+			i++
+		}
+		if creditors[j].amount == 0 {
+			j++
+		}
+	}
+	return debts
+}
+
+// DissolutionPlan returns the complete set of transfers that brings everyone in the group
+// to zero, as when winding the group down at the end of a trip.
+func (g *Group) DissolutionPlan() []Settlement {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.minimalSettlement(g.netBalances(nil))
+}
+
+// SimplifyDebts returns the fewest transfers that settle the group, keyed like
+// GetExpenseDetails ("X to pay Y") with amounts in dollars. Unlike GetExpenseDetails, which
+// nets each pair on its own, it works from each person's overall balance, so a cycle where
+// Alice owes Bob, Bob owes Charlie and Charlie owes Alice cancels out. The payments add up
+// to the total outstanding debt; the map is empty when everyone is even.
+func (g *Group) SimplifyDebts() map[string]float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := map[string]float64{}
+	for _, s := range g.minimalSettlement(g.netBalances(nil)) {
+		result[fmt.Sprintf("%s to pay %s", s.From, s.To)] = microCentsToDollars(s.AmountMicroCents)
+	}
+	return result
+}
+
+// OneRoundSettlement settles the group through a single banker, the biggest creditor
+// (ties go to the name that sorts first): every debtor pays the banker and the banker pays
+// every other creditor, so each person makes or receives exactly one transfer. It trades
+// the fewest transfers of DissolutionPlan for everyone dealing with one person. People
+// within a cent of even are left out. banker is empty when nobody is owed anything.
+func (g *Group) OneRoundSettlement() (banker string, transfers []Settlement) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	balances := g.netBalances(nil)
+	keys := slices.Sorted(maps.Keys(balances))
+	bankerKey := ""
+	for _, key := range keys {
+		if balances[key] >= settleThresholdMicroCents && (bankerKey == "" || balances[key] > balances[bankerKey]) {
+			bankerKey = key
+		}
+	}
+	transfers = []Settlement{}
+	if bankerKey == "" {
+		return "", transfers
+	}
+
+	banker = g.displayName(bankerKey)
+	for _, key := range keys {
+		balance := balances[key]
+		if key == bankerKey || (balance > -settleThresholdMicroCents && balance < settleThresholdMicroCents) {
+			continue
+		}
+		if balance < 0 {
+			transfers = append(transfers, Settlement{From: g.displayName(key), To: banker, AmountMicroCents: -balance})
+		} else {
+			transfers = append(transfers, Settlement{From: banker, To: g.displayName(key), AmountMicroCents: balance})
+		}
+	}
+	return banker, transfers
+}
+
+// SettleUpWithConstraints returns the minimal transfers that settle the group when some
+// people can't send money (e.g. they have no payment app) or can't receive it. Transfers
+// are worked out from net balances, so a person who ends up even never takes part: when A
+// owes B and B owes C the same amount, A pays C directly and B, who can't send, is routed
+// around. A debtor who can't send or a creditor who can't receive makes settling
+// impossible; those people are named in the error. Balances within a cent of even are
+// ignored.
+func (g *Group) SettleUpWithConstraints(cannotSend []string, cannotReceive []string) ([]Settlement, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	toKeys := func(names []string) (map[string]bool, error) {
+		keys := make(map[string]bool, len(names))
+		for _, name := range names {
+			key := normalizeName(name)
+			if _, exists := g.people[key]; !exists {
+				return nil, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(name), g.Name)
+			}
+			keys[key] = true
+		}
+		return keys, nil
+	}
+	noSend, err := toKeys(cannotSend)
+	if err != nil {
+		return nil, err
+	}
+	noReceive, err := toKeys(cannotReceive)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := g.netBalances(nil)
+	var problems []string
+	for _, key := range slices.Sorted(maps.Keys(balances)) {
+		switch balance := balances[key]; {
+		case balance <= -settleThresholdMicroCents && noSend[key]:
+			problems = append(problems, fmt.Sprintf("%s owes %s but can't send money", g.displayName(key), formatMicroCentsAsDollars(-balance)))
+		case balance >= settleThresholdMicroCents && noReceive[key]:
+			problems = append(problems, fmt.Sprintf("%s is owed %s but can't receive money", g.displayName(key), formatMicroCentsAsDollars(balance)))
+		}
+	}
+	if len(problems) > 0 {
+		slog.Error("settlement constraints can't be met", "group", g.Name, "problems", len(problems))
+		return nil, fmt.Errorf("can't settle group(%s) under these constraints: %s", g.Name, strings.Join(problems, "; "))
+	}
+	return g.minimalSettlement(balances), nil
+}
+
+// ExitPlan returns the transfers name must make or receive to end up even, e.g. before
+// leaving a trip early. Only transfers to or from name are included, and they go to the
+// biggest counterparties first, so name deals with as few people as possible. Everyone
+// else's balance moves by what they pay or receive; the rest of the group can settle up
+// among themselves later. The plan is empty when name is already within a cent of even.
+func (g *Group) ExitPlan(name string) ([]Settlement, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		return nil, fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(name), g.Name)
+	}
+
+	balances := g.netBalances(nil)
+	remaining := balances[key]
+	delete(balances, key)
+	plan := []Settlement{}
+	if remaining > -settleThresholdMicroCents && remaining < settleThresholdMicroCents {
+		return plan, nil
+	}
+
+	// a debtor pays the biggest creditors; a creditor is paid by the biggest debtors
+	sign := int64(1)
+	if remaining > 0 {
+		sign = -1
+	}
+	others := []string{}
+	for k, balance := range balances {
+		if balance*sign > 0 {
+			others = append(others, k)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		a, b := balances[others[i]]*sign, balances[others[j]]*sign
+		if a == b {
+			return others[i] < others[j]
+		}
+		return a > b
+	})
+	for _, k := range others {
+		if remaining == 0 {
+			break
+		}
+		amount := min(balances[k]*sign, remaining*-sign)
+		if amount < settleThresholdMicroCents {
+			break
+		}
+		if sign > 0 {
+			plan = append(plan, Settlement{From: g.displayName(key), To: g.displayName(k), AmountMicroCents: amount})
+		} else {
+			plan = append(plan, Settlement{From: g.displayName(k), To: g.displayName(key), AmountMicroCents: amount})
+		}
+		remaining += sign * amount
+	}
+	return plan, nil
+}
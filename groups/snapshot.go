@@ -0,0 +1,288 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// GroupSnapshot is a point-in-time copy of a group's state: its people, expenses,
+// payments and debt transfers. The debt graph isn't stored; it is rebuilt from them.
+type GroupSnapshot struct {
+	Name             string           `json:"name"`
+	CreatedAt        time.Time        `json:"created_at"`
+	People           []PersonSnapshot `json:"people"`
+	Expenses         []Expense        `json:"expenses"`
+	Payments         []Payment        `json:"payments"`
+	Transfers        []LedgerEntry    `json:"transfers,omitempty"`
+	ExpenseIDCounter int              `json:"expense_id_counter"`
+	CoalesceEdges    bool             `json:"coalesce_edges,omitempty"`
+
+	RequireAllParticipate bool `json:"require_all_participate,omitempty"`
+
+	NameCollisionPolicy NameCollisionPolicy `json:"name_collision_policy,omitempty"`
+
+	Recurring          []RecurringExpense `json:"recurring,omitempty"`
+	RecurringIDCounter int                `json:"recurring_id_counter,omitempty"`
+
+	HomeCurrency string `json:"home_currency,omitempty"`
+
+	Archived          []Expense     `json:"archived,omitempty"`
+	ArchivedPayments  []Payment     `json:"archived_payments,omitempty"`
+	ArchivedTransfers []LedgerEntry `json:"archived_transfers,omitempty"`
+
+	// IncomeBrackets is nil when the group uses the default brackets.
+	IncomeBrackets map[string]float64 `json:"income_brackets,omitempty"`
+	MemberBrackets map[string]string  `json:"member_brackets,omitempty"`
+}
+
+// PersonSnapshot is a member as stored in a GroupSnapshot.
+type PersonSnapshot struct {
+	Name    string    `json:"name"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Snapshot returns a deep copy of the group's state.
+func (g *Group) Snapshot() GroupSnapshot {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.snapshot()
+}
+
+// snapshot returns a deep copy of the group's state.
+// Caller must hold the group lock.
+func (g *Group) snapshot() GroupSnapshot {
+	s := GroupSnapshot{
+		Name:             g.Name,
+		CreatedAt:        g.CreatedAt,
+		People:           make([]PersonSnapshot, 0, len(g.people)),
+		Expenses:         make([]Expense, 0, len(g.expenses)),
+		Payments:         g.payments(),
+		Transfers:        g.transfers(),
+		ExpenseIDCounter: g.expenseIdCounter,
+		CoalesceEdges:    g.coalesceEdges,
+
+		RequireAllParticipate: g.requireAllParticipate,
+
+		NameCollisionPolicy: g.nameCollisionPolicy,
+
+		Recurring:          g.recurringList(),
+		RecurringIDCounter: g.recurringIdCounter,
+
+		HomeCurrency: g.homeCurrency,
+
+		ArchivedPayments:  slices.Clone(g.archivedPayments),
+		ArchivedTransfers: slices.Clone(g.archivedTransfers),
+
+		IncomeBrackets: maps.Clone(g.brackets),
+	}
+	for key, bracket := range g.memberBrackets {
+		if s.MemberBrackets == nil {
+			s.MemberBrackets = map[string]string{}
+		}
+		s.MemberBrackets[g.displayName(key)] = bracket
+	}
+	for _, name := range slices.Sorted(maps.Keys(g.people)) {
+		p := g.people[name]
+		s.People = append(s.People, PersonSnapshot{Name: p.Name, AddedAt: p.AddedAt})
+	}
+	for _, e := range g.sortedExpenses() {
+		s.Expenses = append(s.Expenses, copyExpense(e))
+	}
+	for i := range g.archived {
+		s.Archived = append(s.Archived, copyExpense(&g.archived[i]))
+	}
+	return s
+}
+
+// RestoreSnapshot replaces the group's people, expenses and payments with the snapshot's
+// and rebuilds the debt graph from them. The snapshot must belong to this group.
+// The group is left untouched if the snapshot is invalid.
+func (g *Group) RestoreSnapshot(s GroupSnapshot) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if normalizeName(s.Name) != normalizeName(g.Name) {
+		return fmt.Errorf("snapshot belongs to group(%s), not group(%s)", s.Name, g.Name)
+	}
+
+	people, expenses, gr, err := buildState(g.Name, s)
+	if err != nil {
+		slog.Error("restore snapshot failed", "group", g.Name, "error", err.Error())
+		return err
+	}
+	recurring := make(map[int]*RecurringExpense, len(s.Recurring))
+	for _, r := range s.Recurring {
+		if r.ID <= 0 || r.ID > s.RecurringIDCounter {
+			return fmt.Errorf("snapshot recurring expense has invalid ID(%d)", r.ID)
+		}
+		if _, exists := recurring[r.ID]; exists {
+			return fmt.Errorf("snapshot has duplicate recurring expense ID(%d)", r.ID)
+		}
+		if err := validateRecurringInterval(r.Interval); err != nil {
+			return fmt.Errorf("snapshot recurring expense(%d): %w", r.ID, err)
+		}
+		if err := validateExpenseFields(&r.Template); err != nil {
+			return fmt.Errorf("snapshot recurring expense(%d): %w", r.ID, err)
+		}
+		for _, key := range r.Template.namedKeys() {
+			if _, exists := people[key]; !exists {
+				return fmt.Errorf("snapshot recurring expense(%d) person(%s) is not a member", r.ID, key)
+			}
+		}
+		r.Template = copyExpense(&r.Template)
+		recurring[r.ID] = &r
+	}
+	if s.HomeCurrency != "" {
+		if _, err := normalizeCurrency(s.HomeCurrency); err != nil {
+			return fmt.Errorf("snapshot home currency: %w", err)
+		}
+	}
+	brackets, memberBrackets, err := restoreIncomeBrackets(s, people)
+	if err != nil {
+		return err
+	}
+
+	g.people = people
+	g.expenses = expenses
+	g.graph = gr
+	g.expenseIdCounter = s.ExpenseIDCounter
+	g.coalesceEdges = s.CoalesceEdges
+	g.requireAllParticipate = s.RequireAllParticipate
+	g.nameCollisionPolicy = s.NameCollisionPolicy
+	g.recurring = recurring
+	g.recurringIdCounter = s.RecurringIDCounter
+	g.homeCurrency = strings.ToUpper(s.HomeCurrency)
+	g.archived = make([]Expense, 0, len(s.Archived))
+	for i := range s.Archived {
+		g.archived = append(g.archived, copyExpense(&s.Archived[i]))
+	}
+	g.archivedPayments = slices.Clone(s.ArchivedPayments)
+	g.archivedTransfers = slices.Clone(s.ArchivedTransfers)
+	g.brackets = brackets
+	g.memberBrackets = memberBrackets
+	slog.Debug("RestoreSnapshot", "group", g.Name, "people", len(people), "expenses", len(expenses))
+	return nil
+}
+
+// buildState validates a snapshot and builds the people, expenses and graph it describes.
+func buildState(graphName string, s GroupSnapshot) (map[string]*Person, map[int]*Expense, *graph, error) {
+	gr := newGraph(graphName)
+	people := make(map[string]*Person, len(s.People))
+	for _, p := range s.People {
+		name := strings.TrimSpace(p.Name)
+		if !validPersonName(name) {
+			return nil, nil, nil, fmt.Errorf("snapshot person name(%s) must match %q", p.Name, personNamePattern.String())
+		}
+		key := normalizeName(name)
+		if _, exists := people[key]; exists {
+			return nil, nil, nil, fmt.Errorf("snapshot has duplicate person(%s)", name)
+		}
+		if err := gr.addNode(key); err != nil {
+			return nil, nil, nil, err
+		}
+		people[key] = &Person{Name: name, AddedAt: p.AddedAt}
+	}
+
+	expenses := make(map[int]*Expense, len(s.Expenses))
+	maxID := 0
+	for i := range s.Expenses {
+		e := copyExpense(&s.Expenses[i])
+		if e.ID <= 0 {
+			return nil, nil, nil, fmt.Errorf("snapshot expense has invalid ID(%d)", e.ID)
+		}
+		if _, exists := expenses[e.ID]; exists {
+			return nil, nil, nil, fmt.Errorf("snapshot has duplicate expense ID(%d)", e.ID)
+		}
+		for key := range e.paidShares() {
+			if _, exists := people[key]; !exists {
+				return nil, nil, nil, fmt.Errorf("snapshot expense(%d) payer(%s) is not a member", e.ID, key)
+			}
+		}
+		sum := int64(0)
+		for key, share := range e.ResolvedShares {
+			if _, exists := people[key]; !exists {
+				return nil, nil, nil, fmt.Errorf("snapshot expense(%d) participant(%s) is not a member", e.ID, key)
+			}
+			sum += share
+		}
+		if sum != e.NetMicroCents() {
+			return nil, nil, nil, fmt.Errorf("snapshot expense(%d) shares add up to %d, not the total %d", e.ID, sum, e.NetMicroCents())
+		}
+		expenses[e.ID] = &e
+		maxID = max(maxID, e.ID)
+	}
+	// archived expenses keep their IDs, so new ones must not reuse them
+	for _, e := range s.Archived {
+		if _, exists := expenses[e.ID]; exists {
+			return nil, nil, nil, fmt.Errorf("snapshot expense ID(%d) is also used by an archived expense", e.ID)
+		}
+		maxID = max(maxID, e.ID)
+	}
+	if s.ExpenseIDCounter < maxID {
+		return nil, nil, nil, fmt.Errorf("snapshot expense ID counter(%d) is below the highest expense ID(%d)", s.ExpenseIDCounter, maxID)
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(expenses)) {
+		if err := addExpenseEdges(gr, expenses[id], s.CoalesceEdges); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for _, p := range s.Payments {
+		from, to := normalizeName(p.From), normalizeName(p.To)
+		if p.AmountMicroCents <= 0 {
+			return nil, nil, nil, fmt.Errorf("snapshot payment from %s to %s must be positive", p.From, p.To)
+		}
+		metadata := EdgeMetadata{
+			AmountInMicroCents: p.AmountMicroCents,
+			ExpenseID:          PaymentExpenseID,
+			Memo:               p.Memo,
+		}
+		// payment edges point from the receiver to the payer
+		if err := gr.addEdgeAt(to, from, metadata, p.CreatedAt); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for _, t := range s.Transfers {
+		if t.AmountMicroCents <= 0 {
+			return nil, nil, nil, fmt.Errorf("snapshot transfer from %s to %s must be positive", t.From, t.To)
+		}
+		metadata := EdgeMetadata{
+			AmountInMicroCents: t.AmountMicroCents,
+			ExpenseID:          TransferExpenseID,
+			Memo:               t.Memo,
+		}
+		if err := gr.addEdgeAt(normalizeName(t.From), normalizeName(t.To), metadata, t.CreatedAt); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return people, expenses, gr, nil
+}
+
+// copyExpense returns a deep copy of e.
+func copyExpense(e *Expense) Expense {
+	c := *e
+	c.PaidByMap = maps.Clone(e.PaidByMap)
+	c.SplitPercentages = maps.Clone(e.SplitPercentages)
+	c.SplitWeights = maps.Clone(e.SplitWeights)
+	c.TipSplitWeights = maps.Clone(e.TipSplitWeights)
+	c.SplitHeadcount = maps.Clone(e.SplitHeadcount)
+	c.SplitExactMicroCents = maps.Clone(e.SplitExactMicroCents)
+	c.ResolvedShares = maps.Clone(e.ResolvedShares)
+	c.RemainderMicroCents = maps.Clone(e.RemainderMicroCents)
+	c.ParticipantNotes = maps.Clone(e.ParticipantNotes)
+	if e.Excluded != nil {
+		c.Excluded = append([]string(nil), e.Excluded...)
+	}
+	if e.SettledParticipants != nil {
+		c.SettledParticipants = append([]string(nil), e.SettledParticipants...)
+	}
+	if e.Labels != nil {
+		c.Labels = append([]string(nil), e.Labels...)
+	}
+	return c
+}
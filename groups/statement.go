@@ -0,0 +1,105 @@
+package groups
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PersonStatement returns a personal statement for one member, the "here's your bill"
+// document: each expense they had a share of, each expense they paid for, their net balance
+// and the transfers of the group's minimal settlement that involve them.
+func (g *Group) PersonStatement(name string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := normalizeName(name)
+	if _, exists := g.people[key]; !exists {
+		return "", fmt.Errorf("person(%s) not found in group(%s)", name, g.Name)
+	}
+	display := g.displayName(key)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Statement for %s in %s\n", display, g.Name)
+
+	expenses := g.sortedExpenses()
+	b.WriteString("Your shares:\n")
+	shared := 0
+	for _, e := range expenses {
+		share, ok := e.ResolvedShares[key]
+		if !ok || share == 0 {
+			continue
+		}
+		shared++
+		fmt.Fprintf(&b, "  #%d %s, paid by %s: %s", e.ID, e.Description, g.payersText(e), formatMicroCentsAsDollars(share))
+		if slices.ContainsFunc(e.SettledParticipants, func(p string) bool { return normalizeName(p) == key }) {
+			b.WriteString(" (settled on the spot)")
+		}
+		b.WriteString("\n")
+	}
+	if shared == 0 {
+		b.WriteString("  none\n")
+	}
+
+	b.WriteString("You paid:\n")
+	paid := 0
+	for _, e := range expenses {
+		amount := e.paidShares()[key]
+		if amount == 0 {
+			continue
+		}
+		paid++
+		fmt.Fprintf(&b, "  #%d %s: %s\n", e.ID, e.Description, formatMicroCentsAsDollars(amount))
+	}
+	if paid == 0 {
+		b.WriteString("  none\n")
+	}
+
+	balances := g.netBalances(nil)
+	switch net := balances[key]; {
+	case net >= settleThresholdMicroCents:
+		fmt.Fprintf(&b, "Net: you are owed %s\n", formatMicroCentsAsDollars(net))
+	case net <= -settleThresholdMicroCents:
+		fmt.Fprintf(&b, "Net: you owe %s\n", formatMicroCentsAsDollars(-net))
+	default:
+		b.WriteString("Net: you're even\n")
+	}
+
+	b.WriteString("To settle up:")
+	instructions := 0
+	for _, s := range g.minimalSettlement(balances) {
+		switch display {
+		case s.From:
+			fmt.Fprintf(&b, "\n  pay %s %s", s.To, formatMicroCentsAsDollars(s.AmountMicroCents))
+		case s.To:
+			fmt.Fprintf(&b, "\n  receive %s from %s", formatMicroCentsAsDollars(s.AmountMicroCents), s.From)
+		default:
+			continue
+		}
+		instructions++
+	}
+	if instructions == 0 {
+		b.WriteString("\n  nothing to do")
+	}
+	return b.String(), nil
+}
+
+// payersText names who paid for e: the payer alone, or every payer with their amount in
+// name order when several paid, e.g. "Alice $30.00 and Bob $20.00".
+// Caller must hold the group lock.
+func (g *Group) payersText(e *Expense) string {
+	if len(e.PaidByMap) == 0 {
+		return g.displayName(normalizeName(e.PaidBy))
+	}
+	paid := e.paidShares()
+	keys := slices.Sorted(maps.Keys(paid))
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s %s", g.displayName(key), formatMicroCentsAsDollars(paid[key])))
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
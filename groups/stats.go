@@ -0,0 +1,213 @@
+package groups
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// GraphStats describes the shape of a group's internal debt graph.
+// Edges accumulate because AddExpense never merges them, so Edges is usually much
+// larger than DistinctPairs.
+type GraphStats struct {
+	Nodes         int `json:"nodes"`
+	Edges         int `json:"edges"`
+	MaxOutDegree  int `json:"max_out_degree"`
+	DistinctPairs int `json:"distinct_pairs"`
+}
+
+// GraphStats returns diagnostic counts about the group's internal graph.
+func (g *Group) GraphStats() GraphStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	type pair struct {
+		from string
+		to   string
+	}
+
+	stats := GraphStats{Nodes: g.graph.size()}
+	pairs := map[pair]bool{}
+	for from, edges := range g.graph.nodes {
+		stats.Edges += len(edges)
+		stats.MaxOutDegree = max(stats.MaxOutDegree, len(edges))
+		for _, e := range edges {
+			pairs[pair{from: from, to: e.To}] = true
+		}
+	}
+	stats.DistinctPairs = len(pairs)
+	return stats
+}
+
+// CostPerDay returns the average spend in dollars per calendar day, rounded to the cent,
+// from the day of the first expense to the day of the last one, both included. Expenses
+// on a single day count as one day; expenses without a timestamp, e.g. from an old
+// import, are left out. A group without dated expenses costs 0 per day.
+func (g *Group) CostPerDay() (float64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	total := int64(0)
+	var first, last time.Time
+	for _, e := range g.expenses {
+		if e.isGroupCredit() || e.CreatedAt.IsZero() {
+			continue
+		}
+		total += e.NetMicroCents()
+		if first.IsZero() || e.CreatedAt.Before(first) {
+			first = e.CreatedAt
+		}
+		if last.IsZero() || e.CreatedAt.After(last) {
+			last = e.CreatedAt
+		}
+	}
+
+	startOfDay := func(t time.Time) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+	// round to absorb daylight saving shifts
+	if first.IsZero() {
+		return 0, nil
+	}
+	days := math.Round(startOfDay(last).Sub(startOfDay(first)).Hours()/24) + 1
+	return microCentsToDollars(int64(math.Round(float64(total) / days))), nil
+}
+
+// SpendingByPeriod buckets what was spent, net of discounts, by the week or month each
+// expense was added in, e.g. for a roommate group tracking monthly shared costs.
+// granularity is "week", labeled by ISO week ("2024-W09"), or "month" ("2024-03").
+func (g *Group) SpendingByPeriod(granularity string) (map[string]int64, error) {
+	var label func(t time.Time) string
+	switch granularity {
+	case "week":
+		label = func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}
+	case "month":
+		label = func(t time.Time) string {
+			return t.Format("2006-01")
+		}
+	default:
+		return nil, fmt.Errorf("granularity must be week or month, got %q", granularity)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	totals := map[string]int64{}
+	for _, e := range g.expenses {
+		if e.isGroupCredit() {
+			continue
+		}
+		if e.CreatedAt.IsZero() {
+			return nil, fmt.Errorf("expense(%d) in group(%s) has no timestamp", e.ID, g.Name)
+		}
+		totals[label(e.CreatedAt)] += e.NetMicroCents()
+	}
+	return totals, nil
+}
+
+// SpendingInequality returns the Gini coefficient of what each member ended up bearing,
+// summed over their shares of every expense: 0 when everyone bore the same and close to 1
+// when one person bore it all. Members without any share count as bearing nothing. The
+// result is rounded to 4 decimals so rounding remainders of equal splits don't show up.
+func (g *Group) SpendingInequality() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	borne := make(map[string]int64, len(g.people))
+	for key := range g.people {
+		borne[key] = 0
+	}
+	total := int64(0)
+	for _, e := range g.expenses {
+		if e.isGroupCredit() {
+			continue
+		}
+		for key, share := range e.ResolvedShares {
+			borne[key] += share
+			total += share
+		}
+	}
+	if len(borne) == 0 || total == 0 {
+		return 0
+	}
+
+	diffs := 0.0
+	for _, a := range borne {
+		for _, b := range borne {
+			diffs += math.Abs(float64(a - b))
+		}
+	}
+	n := float64(len(borne))
+	gini := diffs / (2 * n * float64(total))
+	return math.Round(gini*10000) / 10000
+}
+
+// SplitFairnessDelta returns, for each member by display name, how much more (positive) or
+// less (negative) they bore in micro cents than under a naive equal split of all spending
+// among every member. It shows how much the chosen split methods moved the costs around.
+// The equal baseline drops the micro cents left over from dividing the total.
+func (g *Group) SplitFairnessDelta() map[string]int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	borne := make(map[string]int64, len(g.people))
+	for key := range g.people {
+		borne[key] = 0
+	}
+	total := int64(0)
+	for _, e := range g.expenses {
+		if e.isGroupCredit() {
+			continue
+		}
+		for key, share := range e.ResolvedShares {
+			borne[key] += share
+			total += share
+		}
+	}
+
+	deltas := make(map[string]int64, len(borne))
+	if len(borne) == 0 {
+		return deltas
+	}
+	equal := total / int64(len(borne))
+	for key, amount := range borne {
+		deltas[g.displayName(key)] = amount - equal
+	}
+	return deltas
+}
+
+// FlagUnevenSplits returns, in ID order, the expenses where some participant's share is
+// more than thresholdRatio times the average share, e.g. a weight of 50 typed instead of
+// 5. Averages are over participants with a positive share. It is a review aid; lopsided
+// splits can be intended.
+func (g *Group) FlagUnevenSplits(thresholdRatio float64) []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	flagged := []int{}
+	for _, e := range g.sortedExpenses() {
+		if e.isGroupCredit() {
+			continue
+		}
+		total, count, biggest := int64(0), 0, int64(0)
+		for _, share := range e.ResolvedShares {
+			if share <= 0 {
+				continue
+			}
+			total += share
+			count++
+			biggest = max(biggest, share)
+		}
+		if count == 0 {
+			continue
+		}
+		if average := float64(total) / float64(count); float64(biggest) > thresholdRatio*average {
+			flagged = append(flagged, e.ID)
+		}
+	}
+	return flagged
+}
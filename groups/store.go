@@ -2,14 +2,20 @@ package groups
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 )
 
+// maxDeletedGroups caps the recycle bin; deleting more groups drops the oldest.
+const maxDeletedGroups = 100
+
 type groupManager struct {
 	store map[string]*Group
-	mu    sync.Mutex
+	// deleted is the recycle bin: groups removed by Delete, oldest first.
+	deleted []*Group
+	mu      sync.Mutex
 }
 
 var groupMgr *groupManager
@@ -80,6 +86,8 @@ func ListGroups() []*Group {
 }
 
 // Delete removes a group by name and reports whether it was deleted.
+// The group is kept in the recycle bin, which holds the last maxDeletedGroups deleted
+// groups; see PeopleInDeletedGroups.
 func Delete(name string) bool {
 	name = strings.TrimSpace(name)
 	if name == "" {
@@ -90,9 +98,84 @@ func Delete(name string) bool {
 	defer groupMgr.mu.Unlock()
 
 	key := normalizeName(name)
-	if _, exists := groupMgr.store[key]; !exists {
+	group, exists := groupMgr.store[key]
+	if !exists {
 		return false
 	}
 	delete(groupMgr.store, key)
+	groupMgr.deleted = append(groupMgr.deleted, group)
+	if extra := len(groupMgr.deleted) - maxDeletedGroups; extra > 0 {
+		groupMgr.deleted = slices.Delete(groupMgr.deleted, 0, extra)
+	}
 	return true
 }
+
+// VerifyAll runs Group.Verify on every group in the store and returns the problems found,
+// by group name. Consistent groups are listed with no problems.
+func VerifyAll() map[string][]error {
+	groupMgr.mu.Lock()
+	defer groupMgr.mu.Unlock()
+
+	problems := make(map[string][]error, len(groupMgr.store))
+	for _, group := range groupMgr.store {
+		problems[group.Name] = group.Verify()
+	}
+	return problems
+}
+
+// ExposureTotalKey is the key of the grand total in PersonTotalExposure. It can't clash
+// with a group name, as those start with a letter.
+const ExposureTotalKey = "(total)"
+
+// PersonTotalExposure returns name's net balance in dollars in every group they are a
+// member of, by group name, plus the sum over those groups under ExposureTotalKey.
+// Positive means they are owed money. Names match the way they do within a group, so
+// "alice" in one group and "Alice" in another are the same person. The map is empty when
+// no group has them.
+func PersonTotalExposure(name string) map[string]float64 {
+	key := normalizeName(name)
+
+	groupMgr.mu.Lock()
+	defer groupMgr.mu.Unlock()
+
+	exposure := map[string]float64{}
+	total := int64(0)
+	found := false
+	for _, group := range groupMgr.store {
+		balance, ok := group.memberBalance(key)
+		if !ok {
+			continue
+		}
+		found = true
+		exposure[group.Name] = microCentsToDollars(balance)
+		total += balance
+	}
+	if found {
+		exposure[ExposureTotalKey] = microCentsToDollars(total)
+	}
+	return exposure
+}
+
+// PeopleInDeletedGroups returns everyone who was a member of a deleted group when it was
+// deleted, in name order. People in several deleted groups are listed once, under the
+// first name they were seen with.
+func PeopleInDeletedGroups() []string {
+	groupMgr.mu.Lock()
+	defer groupMgr.mu.Unlock()
+
+	seen := map[string]bool{}
+	names := []string{}
+	for _, group := range groupMgr.deleted {
+		for _, name := range group.GetPeople() {
+			key := normalizeName(name)
+			if !seen[key] {
+				seen[key] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
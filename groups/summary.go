@@ -0,0 +1,48 @@
+package groups
+
+import (
+	"fmt"
+	"strings"
+)
+
+// compactSummarySettlements caps the settlements listed by CompactSummary.
+const compactSummarySettlements = 3
+
+// CompactSummary returns a terse, token-frugal summary of the group for LLM contexts:
+// one line such as "sf-trip | 4 people | 12 expenses | $840 total | 3 open debts",
+// followed by at most compactSummarySettlements suggested transfers ("Bob->Alice $20"),
+// biggest first. Use GetGroupInfo for the full picture.
+func (g *Group) CompactSummary() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	total := int64(0)
+	for _, e := range g.expenses {
+		if !e.isGroupCredit() {
+			total += e.NetMicroCents()
+		}
+	}
+	settlements := g.minimalSettlement(g.netBalances(nil))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s | %d people | %d expenses | %s total | %d open debts",
+		g.Name, len(g.people), len(g.expenses), compactDollars(total), len(settlements))
+	for i, s := range settlements {
+		if i == compactSummarySettlements {
+			fmt.Fprintf(&b, "\n+%d more", len(settlements)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n%s->%s %s", s.From, s.To, compactDollars(s.AmountMicroCents))
+	}
+	return b.String()
+}
+
+// compactDollars formats micro cents as dollars, dropping the cents when they are zero
+// ("$840", "$12.50").
+func compactDollars(micro int64) string {
+	cents := microCentsToCents(micro)
+	if cents%100 == 0 {
+		return fmt.Sprintf("$%d", cents/100)
+	}
+	return "$" + formatCents(cents)
+}
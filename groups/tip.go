@@ -0,0 +1,46 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// validateTip checks TipMicroCents and TipSplitWeights against the total.
+func validateTip(e *Expense) error {
+	if e.TipMicroCents < 0 || e.TipMicroCents > e.TotalMicroCents {
+		slog.Error("expense tip out of range", "tip_micro_cents", e.TipMicroCents, "total_micro_cents", e.TotalMicroCents)
+		return fmt.Errorf("expense tip(%d) must be between 0 and the total(%d)", e.TipMicroCents, e.TotalMicroCents)
+	}
+	if len(e.TipSplitWeights) > 0 && e.TipMicroCents == 0 {
+		return fmt.Errorf("tip split weights need a tip")
+	}
+	return nil
+}
+
+// resolveTipWeights normalizes the tip split weights of e and checks them against the
+// group's members and the excluded ones.
+// Caller must hold the group lock.
+func (g *Group) resolveTipWeights(e *Expense, excluded map[string]bool) error {
+	if len(e.TipSplitWeights) == 0 {
+		return nil
+	}
+	weights, err := normalizeSplitMap(e.TipSplitWeights)
+	if err != nil {
+		return err
+	}
+	isMember := func(key string) bool {
+		_, exists := g.people[key]
+		return exists
+	}
+	if err := validateSplitMap("weights", weights, isMember); err != nil {
+		slog.Error("expense tip split weights validation failed", "group", g.Name, "error", err.Error())
+		return fmt.Errorf("tip split weights: %w", err)
+	}
+	for key, w := range weights {
+		if excluded[key] && w > 0 {
+			return fmt.Errorf("person(%s) is excluded but has a positive tip weight", g.displayName(key))
+		}
+	}
+	e.TipSplitWeights = weights
+	return nil
+}
@@ -0,0 +1,145 @@
+package groups
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// readOnlyTokenPrefix marks the tokens of ExportReadOnlyToken and their format version.
+const readOnlyTokenPrefix = "esro1."
+
+// maxReadOnlyTokenBytes caps the decompressed snapshot in a read-only token, so a small
+// crafted token can't expand into gigabytes.
+const maxReadOnlyTokenBytes = 16 << 20
+
+// errNoReadOnlyTokenKey is returned while no key has been set with SetReadOnlyTokenKey.
+var errNoReadOnlyTokenKey = errors.New("read-only tokens are disabled: no signing key is set")
+
+// readOnlyTokenKey signs read-only tokens. It is unset until SetReadOnlyTokenKey is called,
+// and instances that share tokens must use the same key.
+var (
+	readOnlyTokenKey   []byte
+	readOnlyTokenKeyMu sync.Mutex
+)
+
+// SetReadOnlyTokenKey replaces the key read-only tokens are signed and checked with.
+// Tokens signed with the old key stop verifying.
+func SetReadOnlyTokenKey(key []byte) error {
+	if len(key) < 16 {
+		return fmt.Errorf("read-only token key must be at least 16 bytes, got %d", len(key))
+	}
+	readOnlyTokenKeyMu.Lock()
+	defer readOnlyTokenKeyMu.Unlock()
+
+	readOnlyTokenKey = bytes.Clone(key)
+	return nil
+}
+
+// signReadOnlyPayload returns the HMAC-SHA256 of payload under readOnlyTokenKey, or
+// errNoReadOnlyTokenKey when no key is set.
+func signReadOnlyPayload(payload []byte) ([]byte, error) {
+	readOnlyTokenKeyMu.Lock()
+	defer readOnlyTokenKeyMu.Unlock()
+
+	if len(readOnlyTokenKey) == 0 {
+		return nil, errNoReadOnlyTokenKey
+	}
+	mac := hmac.New(sha256.New, readOnlyTokenKey)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// ExportReadOnlyToken returns an opaque token holding a compressed snapshot of the group,
+// signed so that any change to it is detected. Another instance with the same key can open
+// it with ImportReadOnlyToken to look at the group without file transfer.
+func (g *Group) ExportReadOnlyToken() (string, error) {
+	s := g.Snapshot()
+	// recurring templates are of no use to a viewer
+	s.Recurring = nil
+	s.RecurringIDCounter = 0
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(s); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	payload := buf.Bytes()
+	sig, err := signReadOnlyPayload(payload)
+	if err != nil {
+		return "", err
+	}
+	token := readOnlyTokenPrefix +
+		base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(sig)
+	slog.Debug("ExportReadOnlyToken", "group", g.Name, "length", len(token))
+	return token, nil
+}
+
+// ImportReadOnlyToken checks the signature of a token from ExportReadOnlyToken and
+// rebuilds the group it holds. The group is detached: it isn't added to the store, so
+// nothing done to it affects this instance's groups or the group it was exported from.
+func ImportReadOnlyToken(token string) (*Group, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(token), readOnlyTokenPrefix)
+	if !ok {
+		return nil, errors.New("not a read-only group token")
+	}
+	encodedPayload, encodedSig, ok := strings.Cut(rest, ".")
+	if !ok {
+		return nil, errors.New("read-only token is malformed")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, errors.New("read-only token is malformed")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, errors.New("read-only token is malformed")
+	}
+	want, err := signReadOnlyPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(sig, want) {
+		slog.Error("read-only token signature mismatch")
+		return nil, errors.New("read-only token signature doesn't match; it was changed or signed with another key")
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("read-only token payload: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(zr, maxReadOnlyTokenBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read-only token payload: %w", err)
+	}
+	if len(data) > maxReadOnlyTokenBytes {
+		slog.Error("read-only token payload too large", "limit", maxReadOnlyTokenBytes)
+		return nil, fmt.Errorf("read-only token payload is larger than %d bytes", maxReadOnlyTokenBytes)
+	}
+	var s GroupSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("read-only token payload: %w", err)
+	}
+	g, err := NewGroup(s.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.RestoreSnapshot(s); err != nil {
+		return nil, err
+	}
+	g.CreatedAt = s.CreatedAt
+	return g, nil
+}
@@ -0,0 +1,74 @@
+package groups
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// TransferExpenseID is the sentinel EdgeMetadata.ExpenseID of edges created by TransferDebt
+// and ForgiveAllDebts.
+const TransferExpenseID = -2
+
+// TransferDebt moves microCents of what debtor owes from over to to ("owe me instead of
+// him"). Three edges keep everyone whole: from->debtor cancels that much of debtor's debt
+// to from, debtor->to records the new debt, and to->from compensates from, who is no
+// longer owed by debtor. Net balances of all three people are unchanged; only who owes
+// whom moves.
+//
+// debtor must owe from at least microCents net.
+func (g *Group) TransferDebt(debtor, from, to string, microCents int64) error {
+	if microCents <= 0 {
+		slog.Error("transfer amount must be positive", "amount_micro_cents", microCents)
+		return fmt.Errorf("transfer amount(%d) must be positive", microCents)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys := make([]string, 0, 3)
+	for _, name := range []string{debtor, from, to} {
+		key := normalizeName(name)
+		if _, exists := g.people[key]; !exists {
+			return fmt.Errorf("person(%s) not found in group(%s)", strings.TrimSpace(name), g.Name)
+		}
+		keys = append(keys, key)
+	}
+	debtorKey, fromKey, toKey := keys[0], keys[1], keys[2]
+	if debtorKey == fromKey || debtorKey == toKey || fromKey == toKey {
+		return fmt.Errorf("debtor, from and to must be three different people")
+	}
+	if owed := g.netOwed(debtorKey, fromKey); owed < microCents {
+		return fmt.Errorf("%s owes %s %d micro cents, cannot transfer %d", g.displayName(debtorKey), g.displayName(fromKey), max(owed, 0), microCents)
+	}
+
+	memo := fmt.Sprintf("debt of %s moved from %s to %s", g.displayName(debtorKey), g.displayName(fromKey), g.displayName(toKey))
+	for _, d := range []debt{
+		{from: fromKey, to: debtorKey, amount: microCents},
+		{from: debtorKey, to: toKey, amount: microCents},
+		{from: toKey, to: fromKey, amount: microCents},
+	} {
+		metadata := EdgeMetadata{
+			AmountInMicroCents: d.amount,
+			ExpenseID:          TransferExpenseID,
+			Memo:               memo,
+		}
+		if err := g.graph.addEdge(d.from, d.to, metadata); err != nil {
+			return err
+		}
+	}
+	slog.Debug("TransferDebt", "group", g.Name, "debtor", debtor, "from", from, "to", to, "amount_micro_cents", microCents)
+	return nil
+}
+
+// transfers returns the edges recorded by TransferDebt as ledger entries, oldest first.
+// Caller must hold the group lock.
+func (g *Group) transfers() []LedgerEntry {
+	entries := []LedgerEntry{}
+	for _, entry := range g.ledger(nil) {
+		if entry.Kind == "transfer" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
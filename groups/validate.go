@@ -0,0 +1,79 @@
+package groups
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ValidateSplitMap checks a split map for the given split method against the group members,
+// exactly like AddExpense does: percentages must be within 0..100 and sum to 100, weights
+// must be non-negative with a positive sum, and every name must be a member.
+// Methods that don't use a split map accept only an empty one.
+func ValidateSplitMap(method string, m map[string]float64, members []string) error {
+	if err := validateSplitMethod(method); err != nil {
+		return err
+	}
+	memberSet := make(map[string]bool, len(members))
+	for _, name := range members {
+		memberSet[normalizeName(name)] = true
+	}
+	normalized, err := normalizeSplitMap(m)
+	if err != nil {
+		return err
+	}
+	return validateSplitMap(method, normalized, func(key string) bool { return memberSet[key] })
+}
+
+// validateSplitMap validates the normalized split map used by method.
+// AddExpense and ValidateSplitMap share it so both report the same problems.
+func validateSplitMap(method string, m map[string]float64, isMember func(key string) bool) error {
+	var field string
+	switch method {
+	case "percentage":
+		field = "split_percentages"
+	case "weights":
+		field = "split_weights"
+	default:
+		if len(m) > 0 {
+			return fmt.Errorf("split method %s does not take a split map", method)
+		}
+		return nil
+	}
+	if len(m) == 0 {
+		return fmt.Errorf("%s is required for %s split", field, method)
+	}
+
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sum := 0.0
+	for _, name := range names {
+		v := m[name]
+		if !isMember(name) {
+			return fmt.Errorf("%s validation failed, name(%s) not in the group", field, name)
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+			return fmt.Errorf("%s validation failed, value for %s must be >= 0, got %v", field, name, v)
+		}
+		if method == "percentage" && v > 100 {
+			return fmt.Errorf("%s validation failed, value for %s must be <= 100, got %v", field, name, v)
+		}
+		sum += v
+	}
+
+	switch method {
+	case "percentage":
+		if math.Abs(sum-100.0) > 0.01 {
+			return fmt.Errorf("split_percentages must sum to 100 (got %.2f)", sum)
+		}
+	case "weights":
+		if sum <= 0 {
+			return fmt.Errorf("sum of split_weights must be > 0 (at least one participant is required)")
+		}
+	}
+	return nil
+}
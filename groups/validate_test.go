@@ -0,0 +1,54 @@
+package groups
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSplitMap(t *testing.T) {
+	members := []string{"Alice", "Bob", "Charlie"}
+	tests := []struct {
+		name    string
+		method  string
+		m       map[string]float64
+		wantErr string
+	}{
+		{name: "valid percentage", method: "percentage", m: map[string]float64{"alice": 50, "Bob": 50}},
+		{name: "valid weights", method: "weights", m: map[string]float64{"Alice": 2, "Bob": 0}},
+		{name: "equal without map", method: "equal"},
+		{name: "unknown method", method: "shares", m: map[string]float64{"Alice": 1},
+			wantErr: "split method must be one of equal|percentage|weights|duration"},
+		{name: "missing percentages", method: "percentage",
+			wantErr: "split_percentages is required for percentage split"},
+		{name: "percentages not summing to 100", method: "percentage", m: map[string]float64{"Alice": 50, "Bob": 40},
+			wantErr: "split_percentages must sum to 100 (got 90.00)"},
+		{name: "percentage above 100", method: "percentage", m: map[string]float64{"Alice": 150, "Bob": -50},
+			wantErr: "split_percentages validation failed, value for alice must be <= 100, got 150"},
+		{name: "non-member percentage", method: "percentage", m: map[string]float64{"Alice": 50, "Mallory": 50},
+			wantErr: "split_percentages validation failed, name(mallory) not in the group"},
+		{name: "negative weight", method: "weights", m: map[string]float64{"Alice": 1, "Bob": -1},
+			wantErr: "split_weights validation failed, value for bob must be >= 0, got -1"},
+		{name: "zero weights", method: "weights", m: map[string]float64{"Alice": 0, "Bob": 0},
+			wantErr: "sum of split_weights must be > 0 (at least one participant is required)"},
+		{name: "non-member weight", method: "weights", m: map[string]float64{"Dave": 1},
+			wantErr: "split_weights validation failed, name(dave) not in the group"},
+		{name: "duplicate after normalization", method: "weights", m: map[string]float64{"Alice": 1, "alice ": 1},
+			wantErr: "duplicate name in split map after normalization"},
+		{name: "map for equal split", method: "equal", m: map[string]float64{"Alice": 1},
+			wantErr: "split method equal does not take a split map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSplitMap(tt.method, tt.m, members)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || (err.Error() != tt.wantErr && !strings.HasPrefix(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package groups
+
+import (
+	"math"
+	"time"
+)
+
+// SettlementVelocity estimates how fast the group pays down its debts, in dollars per day:
+// the total of all recorded payments divided by the days from the first payment to the
+// last (at least one day). It is 0 when no payments were recorded.
+func (g *Group) SettlementVelocity() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	perDay, _ := g.settlementVelocity()
+	return microCentsToDollars(int64(math.Round(perDay)))
+}
+
+// ProjectedSettlement projects when the group will be fully settled if payments keep coming
+// at SettlementVelocity. outstanding is the total still owed, in micro cents. ok is false
+// when there is nothing to project: no payments yet, or nothing outstanding.
+func (g *Group) ProjectedSettlement() (outstanding int64, settledBy time.Time, ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, balance := range g.netBalances(nil) {
+		if balance >= settleThresholdMicroCents {
+			outstanding += balance
+		}
+	}
+	perDay, last := g.settlementVelocity()
+	if outstanding == 0 || perDay == 0 {
+		return outstanding, time.Time{}, false
+	}
+	days := float64(outstanding) / perDay
+	return outstanding, last.Add(time.Duration(days * float64(24*time.Hour))), true
+}
+
+// settlementVelocity returns the micro cents paid per day and when the last payment was
+// made; both are zero without payments.
+// Caller must hold the group lock.
+func (g *Group) settlementVelocity() (perDay float64, last time.Time) {
+	payments := g.payments()
+	if len(payments) == 0 {
+		return 0, time.Time{}
+	}
+	total := int64(0)
+	first := payments[0].CreatedAt
+	for _, p := range payments {
+		total += p.AmountMicroCents
+		if p.CreatedAt.Before(first) {
+			first = p.CreatedAt
+		}
+		if p.CreatedAt.After(last) {
+			last = p.CreatedAt
+		}
+	}
+	days := max(last.Sub(first).Hours()/24, 1)
+	return float64(total) / days, last
+}
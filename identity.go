@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// unknownCaller is recorded when a request carries no caller identity, as over stdio.
+const unknownCaller = "unknown"
+
+// callerIdentity returns the user ID of whoever made the request. It comes from the bearer
+// token info, which the HTTP transports attach to the context and to the request when the
+// server verifies tokens.
+func callerIdentity(ctx context.Context, req *mcp.CallToolRequest) string {
+	if info := auth.TokenInfoFromContext(ctx); info != nil && info.UserID != "" {
+		return info.UserID
+	}
+	if req != nil && req.Extra != nil && req.Extra.TokenInfo != nil && req.Extra.TokenInfo.UserID != "" {
+		return req.Extra.TokenInfo.UserID
+	}
+	return unknownCaller
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type SetIncomeBracketInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Person    string `json:"person,omitempty" jsonschema_description:"person to place in a bracket"`
+	Bracket   string `json:"bracket" jsonschema_description:"income bracket, e.g. low, mid or high; empty takes the person out of income splits"`
+}
+
+type SetIncomeBracketOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func SetIncomeBracket(ctx context.Context, req *mcp.CallToolRequest, input *SetIncomeBracketInput) (*mcp.CallToolResult, *SetIncomeBracketOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to set an income bracket")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Person == "" {
+		return nil, nil, errors.New("person is required")
+	}
+
+	if err := group.SetIncomeBracket(input.Person, input.Bracket); err != nil {
+		return nil, nil, err
+	}
+
+	msg := fmt.Sprintf("%s is in the %s income bracket", input.Person, input.Bracket)
+	if input.Bracket == "" {
+		msg = fmt.Sprintf("%s has no income bracket", input.Person)
+	}
+	output := &SetIncomeBracketOutput{
+		Msg: msg,
+	}
+	return nil, output, nil
+}
+
+type DefineIncomeBracketInput struct {
+	GroupName string  `json:"group_name,omitempty" jsonschema_description:"group to configure"`
+	Bracket   string  `json:"bracket" jsonschema_description:"bracket name, e.g. student"`
+	Weight    float64 `json:"weight" jsonschema_description:"relative weight of the bracket in income splits; must be positive"`
+}
+
+type DefineIncomeBracketOutput struct {
+	Brackets map[string]float64 `json:"brackets" jsonschema_description:"Map bracket->weight of every defined bracket"`
+}
+
+func DefineIncomeBracket(ctx context.Context, req *mcp.CallToolRequest, input *DefineIncomeBracketInput) (*mcp.CallToolResult, *DefineIncomeBracketOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to define an income bracket")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	if err := group.DefineIncomeBracket(input.Bracket, input.Weight); err != nil {
+		return nil, nil, err
+	}
+
+	output := &DefineIncomeBracketOutput{
+		Brackets: group.IncomeBrackets(),
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// LedgerEntryView is a ledger entry as returned by the ledger tools.
+type LedgerEntryView struct {
+	Kind       string `json:"kind" jsonschema_description:"expense (from owes to), payment (from paid to) or transfer (from owes to after a debt transfer)"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Amount     string `json:"amount" jsonschema_description:"amount in dollars"`
+	ExpenseID  int    `json:"expense_id,omitempty" jsonschema_description:"expense that created this entry; omitted for payments and transfers"`
+	ExpenseIDs []int  `json:"expense_ids,omitempty" jsonschema_description:"expenses merged into this entry when edge coalescing is on"`
+	Memo       string `json:"memo,omitempty"`
+	EnteredBy  string `json:"entered_by,omitempty" jsonschema_description:"who added the expense behind this entry"`
+	CreatedAt  string `json:"created_at"`
+	Summary    string `json:"summary" jsonschema_description:"one line description, e.g. Bob paid Alice $20.00 — Venmo on 3/5"`
+}
+
+type LedgerInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group whose ledger to show"`
+}
+
+type LedgerOutput struct {
+	Entries []LedgerEntryView `json:"entries"`
+}
+
+type DebtsBetweenInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the two people"`
+	PersonA   string `json:"person_a,omitempty" jsonschema_description:"first person"`
+	PersonB   string `json:"person_b,omitempty" jsonschema_description:"second person"`
+}
+
+type DebtsBetweenOutput struct {
+	Entries []LedgerEntryView `json:"entries"`
+	Net     string            `json:"net" jsonschema_description:"who owes whom after netting all entries"`
+}
+
+func Ledger(ctx context.Context, req *mcp.CallToolRequest, input *LedgerInput) (*mcp.CallToolResult, *LedgerOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show its ledger")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &LedgerOutput{
+		Entries: toLedgerEntryViews(group.Ledger()),
+	}
+	return nil, output, nil
+}
+
+func DebtsBetween(ctx context.Context, req *mcp.CallToolRequest, input *DebtsBetweenInput) (*mcp.CallToolResult, *DebtsBetweenOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show debts between two people")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.PersonA == "" || input.PersonB == "" {
+		return nil, nil, errors.New("person_a and person_b are required")
+	}
+
+	entries, net, err := group.DebtsBetween(input.PersonA, input.PersonB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary := "all settled"
+	switch {
+	case net >= 1000:
+		summary = fmt.Sprintf("%s to pay %s %s", input.PersonA, input.PersonB, formatMicroCents(net))
+	case net <= -1000:
+		summary = fmt.Sprintf("%s to pay %s %s", input.PersonB, input.PersonA, formatMicroCents(-net))
+	}
+
+	output := &DebtsBetweenOutput{
+		Entries: toLedgerEntryViews(entries),
+		Net:     summary,
+	}
+	return nil, output, nil
+}
+
+type PersonLedgerInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Name      string `json:"name,omitempty" jsonschema_description:"person whose debts to show"`
+}
+
+type PersonLedgerOutput struct {
+	Owes   map[string]float64 `json:"owes" jsonschema_description:"Map person->dollars this person owes them"`
+	OwedBy map[string]float64 `json:"owed_by" jsonschema_description:"Map person->dollars they owe this person"`
+}
+
+func PersonLedger(ctx context.Context, req *mcp.CallToolRequest, input *PersonLedgerInput) (*mcp.CallToolResult, *PersonLedgerOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show a person's debts")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	owes, owedBy, err := group.PersonLedger(input.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &PersonLedgerOutput{
+		Owes:   owes,
+		OwedBy: owedBy,
+	}
+	return nil, output, nil
+}
+
+func toLedgerEntryViews(entries []groups.LedgerEntry) []LedgerEntryView {
+	views := make([]LedgerEntryView, 0, len(entries))
+	for _, e := range entries {
+		view := LedgerEntryView{
+			Kind:      e.Kind,
+			From:      e.From,
+			To:        e.To,
+			Amount:    formatMicroCents(e.AmountMicroCents),
+			Memo:      e.Memo,
+			EnteredBy: e.EnteredBy,
+			CreatedAt: fmt.Sprint(e.CreatedAt),
+		}
+		if e.Kind == "payment" {
+			view.Summary = fmt.Sprintf("%s paid %s %s", e.From, e.To, view.Amount)
+		} else if e.Kind == "transfer" {
+			view.Summary = fmt.Sprintf("%s owes %s %s", e.From, e.To, view.Amount)
+		} else if len(e.ExpenseIDs) > 0 {
+			view.ExpenseIDs = e.ExpenseIDs
+			ids := make([]string, 0, len(e.ExpenseIDs))
+			for _, id := range e.ExpenseIDs {
+				ids = append(ids, fmt.Sprintf("#%d", id))
+			}
+			view.Summary = fmt.Sprintf("%s owes %s %s for expenses %s", e.From, e.To, view.Amount, strings.Join(ids, ", "))
+		} else {
+			view.ExpenseID = e.ExpenseID
+			view.Summary = fmt.Sprintf("%s owes %s %s for expense #%d", e.From, e.To, view.Amount, e.ExpenseID)
+		}
+		if e.Memo != "" {
+			view.Summary += " — " + e.Memo
+		}
+		views = append(views, view)
+	}
+	return views
+}
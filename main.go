@@ -2,15 +2,37 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"expense-splitter/groups"
+	"flag"
+	"io/fs"
 	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func main() {
+	stateFile := flag.String("state-file", "", "load groups from this file on startup and save them to it after every tool call and on shutdown")
+	flag.Parse()
+	if err := setReadOnlyTokenKey(); err != nil {
+		log.Fatal(err)
+	}
+	if *stateFile != "" {
+		loadState(*stateFile)
+	}
+
 	server := mcp.NewServer(&mcp.Implementation{Name: "create_group", Version: "v1.0.0"}, nil)
+	if *stateFile != "" {
+		server.AddReceivingMiddleware(saveStateAfterToolCalls(*stateFile))
+	}
 	mcp.AddTool(server, &mcp.Tool{Name: "create_group", Description: "Create a group"}, CreateGroup)
 	mcp.AddTool(server, &mcp.Tool{Name: "list_groups", Description: "List groups"}, ListGroups)
+	mcp.AddTool(server, &mcp.Tool{Name: "delete_group", Description: "Delete a group"}, DeleteGroup)
 	mcp.AddTool(server, &mcp.Tool{Name: "add_people", Description: "Add people to the group"}, AddPeople)
 	mcp.AddTool(server, &mcp.Tool{Name: "get_group_info", Description: "Get group info or details"}, GetGroupInfo)
 	mcp.AddTool(server, &mcp.Tool{
@@ -19,10 +41,160 @@ func main() {
 		InputSchema: addExpenseInputSchema,
 	},
 		AddExpense)
+	mcp.AddTool(server, &mcp.Tool{Name: "validate_split", Description: "Check a percentage/weights split map before adding an expense"}, ValidateSplit)
+	mcp.AddTool(server, &mcp.Tool{Name: "delete_expense", Description: "Delete an expense and the debts it created"}, DeleteExpense)
+	mcp.AddTool(server, &mcp.Tool{Name: "compact_expense_ids", Description: "Renumber a group's expenses to 1..N after deletions"}, CompactExpenseIDs)
+	mcp.AddTool(server, &mcp.Tool{Name: "settle_subset", Description: "Suggest the minimal transfers that settle debts among a subset of people"}, SettleSubset)
+	mcp.AddTool(server, &mcp.Tool{Name: "ledger", Description: "List every expense share and payment in a group, with memos"}, Ledger)
+	mcp.AddTool(server, &mcp.Tool{Name: "debts_between", Description: "List the ledger entries and net debt between two people"}, DebtsBetween)
+	mcp.AddTool(server, &mcp.Tool{Name: "members_by_balance", Description: "List members from the biggest creditor to the biggest debtor"}, MembersByBalance)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_balances", Description: "Show how much each person, or one person, is up or down overall"}, GetBalances)
+	mcp.AddTool(server, &mcp.Tool{Name: "top_debts", Description: "List the largest outstanding debts between pairs of people"}, TopDebts)
+	mcp.AddTool(server, &mcp.Tool{Name: "contribution_to_equalize", Description: "Suggest how much each person should chip in for a planned shared purchase, given current balances"}, ContributionToEqualize)
+	mcp.AddTool(server, &mcp.Tool{Name: "graph_stats", Description: "Show node/edge counts of a group's internal debt graph (for debugging)"}, GraphStats)
+	mcp.AddTool(server, &mcp.Tool{Name: "set_edge_coalescing", Description: "Merge debts between the same two people into one edge to bound memory growth"}, SetEdgeCoalescing)
+	mcp.AddTool(server, &mcp.Tool{Name: "export_splitwise", Description: "Export a group as Splitwise-compatible JSON"}, ExportSplitwise)
+	mcp.AddTool(server, &mcp.Tool{Name: "export_graphml", Description: "Export a group's debt graph as GraphML (e.g. for Gephi)"}, ExportGraphML)
+	mcp.AddTool(server, &mcp.Tool{Name: "snapshot_group", Description: "Capture a group's people, expenses and payments so they can be restored later"}, SnapshotGroup)
+	mcp.AddTool(server, &mcp.Tool{Name: "restore_snapshot", Description: "Roll a group back to a snapshot taken with snapshot_group"}, RestoreSnapshot)
+	mcp.AddTool(server, &mcp.Tool{Name: "set_name_collision_policy", Description: "Choose what happens when a person is added under a name already in the group"}, SetNameCollisionPolicy)
+	mcp.AddTool(server, &mcp.Tool{Name: "compact_summary", Description: "Summarize a group in a few short lines; cheaper than get_group_info when juggling many groups"}, CompactSummary)
+	mcp.AddTool(server, &mcp.Tool{Name: "add_recurring_expense", Description: "Register an expense that repeats, such as rent or utilities"}, AddRecurringExpense)
+	mcp.AddTool(server, &mcp.Tool{Name: "materialize_recurring", Description: "Add every due occurrence of a group's recurring expenses as real expenses"}, MaterializeRecurring)
+	mcp.AddTool(server, &mcp.Tool{Name: "settlement_chains", Description: "Suggest direct transfers that skip an intermediary (A owes B, B owes C: A pays C)"}, SettlementChains)
+	mcp.AddTool(server, &mcp.Tool{Name: "rounding_fairness", Description: "Report how much extra each person absorbed from rounding remainders across all expenses"}, RoundingFairness)
+	mcp.AddTool(server, &mcp.Tool{Name: "transfer_debt", Description: "Move part of what one person owes from one creditor to another"}, TransferDebt)
+	mcp.AddTool(server, &mcp.Tool{Name: "list_expenses", Description: "List every expense of a group: amount and any discount, who paid, who entered it, description and split"}, ListExpenses)
+	mcp.AddTool(server, &mcp.Tool{Name: "expenses_by_method", Description: "List a group's expenses that use a given split method"}, ExpensesByMethod)
+	mcp.AddTool(server, &mcp.Tool{Name: "cost_per_day", Description: "Average spend per day over the days a group's expenses span"}, CostPerDay)
+	mcp.AddTool(server, &mcp.Tool{Name: "person_ledger", Description: "Show whom one person owes and who owes them"}, PersonLedger)
+	mcp.AddTool(server, &mcp.Tool{Name: "export_all", Description: "Back up every group as one JSON document"}, ExportAll)
+	mcp.AddTool(server, &mcp.Tool{Name: "import_all", Description: "Replace all groups with a backup from export_all"}, ImportAll)
+	mcp.AddTool(server, &mcp.Tool{Name: "set_home_currency", Description: "Set the currency a group reports in"}, SetHomeCurrency)
+	mcp.AddTool(server, &mcp.Tool{Name: "home_currency_report", Description: "Convert every expense and payment into the home currency using a rate table"}, HomeCurrencyReport)
+	mcp.AddTool(server, &mcp.Tool{Name: "expense_impact", Description: "Show how one expense changed each person's net balance"}, ExpenseImpact)
+	mcp.AddTool(server, &mcp.Tool{Name: "expenses_owed_by", Description: "List the expenses a person owes a share of but didn't pay"}, ExpensesOwedBy)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_expense", Description: "Show one expense with each participant's share and notes"}, GetExpense)
+	mcp.AddTool(server, &mcp.Tool{Name: "set_participant_note", Description: "Attach a note about one participant to an expense, e.g. why their share differs"}, SetParticipantNote)
+	mcp.AddTool(server, &mcp.Tool{Name: "spending_inequality", Description: "Rate in one number how evenly a group's costs were shared (Gini coefficient)"}, SpendingInequality)
+	mcp.AddTool(server, &mcp.Tool{Name: "rename_person", Description: "Rename a group member across all expenses, debts and payments"}, RenamePerson)
+	mcp.AddTool(server, &mcp.Tool{Name: "payment_history", Description: "List the payments already made in a group, oldest first"}, PaymentHistory)
+	mcp.AddTool(server, &mcp.Tool{Name: "dissolution_plan", Description: "List every transfer needed to wind a group down, and what each person pays or receives"}, DissolutionPlan)
+	mcp.AddTool(server, &mcp.Tool{Name: "simplify_debts", Description: "Simplify a group's debts to the fewest payments, cancelling out cycles"}, SimplifyDebts)
+	mcp.AddTool(server, &mcp.Tool{Name: "paid_vs_owed", Description: "Show what each member paid and what their shares came to"}, PaidVsOwed)
+	mcp.AddTool(server, &mcp.Tool{Name: "expense_extrema", Description: "Show a group's most and least expensive expenses"}, ExpenseExtrema)
+	mcp.AddTool(server, &mcp.Tool{Name: "close_out_trip", Description: "Settle everyone to zero in one go and archive the group's expenses"}, CloseOutTrip)
+	mcp.AddTool(server, &mcp.Tool{Name: "non_payers", Description: "List members who haven't paid for any expense yet"}, NonPayers)
+	mcp.AddTool(server, &mcp.Tool{Name: "suggest_pool_contributions", Description: "Suggest what each person should put into a shared pool of a fixed amount per person, netted against current balances"}, SuggestPoolContributions)
+	mcp.AddTool(server, &mcp.Tool{Name: "filter_expenses_by_label", Description: "List the expenses of a group that carry a label, such as business or reimbursable"}, FilterExpensesByLabel)
+	mcp.AddTool(server, &mcp.Tool{Name: "preview_forgive_all", Description: "Show the debts that would remain if everything one person owes were written off, without doing it"}, PreviewForgiveAll)
+	mcp.AddTool(server, &mcp.Tool{Name: "forgive_all_debts", Description: "Write off everything one person owes, e.g. when the group covers a kid's expenses"}, ForgiveAllDebts)
+	mcp.AddTool(server, &mcp.Tool{Name: "payer_rotation", Description: "Suggest who should pay each of the next few expenses so balances even out"}, PayerRotation)
+	mcp.AddTool(server, &mcp.Tool{Name: "expenses_by_event", Description: "List a group's expenses grouped by sub-event, e.g. Day 1 dinner or concert"}, ExpensesByEvent)
+	mcp.AddTool(server, &mcp.Tool{Name: "flag_uneven_splits", Description: "Flag expenses where one share is far above the average, to catch data-entry errors"}, FlagUnevenSplits)
+	mcp.AddTool(server, &mcp.Tool{Name: "reimbursement_needed", Description: "How much a person who fronted costs is still owed, and by whom"}, ReimbursementNeeded)
+	mcp.AddTool(server, &mcp.Tool{Name: "add_mileage_expense", Description: "Add a carpool expense split by the miles each person rode"}, AddMileageExpense)
+	mcp.AddTool(server, &mcp.Tool{Name: "gross_debts", Description: "Show what each person owes each other person in both directions, before netting and before payments"}, GrossDebts)
+	mcp.AddTool(server, &mcp.Tool{Name: "banker_settlement", Description: "Settle up through one person, the biggest creditor, with a single transfer per person"}, BankerSettlement)
+	mcp.AddTool(server, &mcp.Tool{Name: "settlement_reminder", Description: "Create an iCalendar (.ics) reminder to settle up by a date, listing who pays whom"}, SettlementReminder)
+	mcp.AddTool(server, &mcp.Tool{Name: "preview_edit_amount", Description: "Show the debts before and after correcting the amount of an expense, without changing it"}, PreviewEditAmount)
+	mcp.AddTool(server, &mcp.Tool{Name: "people_in_deleted_groups", Description: "List everyone who was in a deleted group, to help recover data"}, PeopleInDeletedGroups)
+	mcp.AddTool(server, &mcp.Tool{Name: "leaderboard", Description: "Rank members by how much they fronted net of their own shares, most generous first"}, Leaderboard)
+	mcp.AddTool(server, &mcp.Tool{Name: "set_income_bracket", Description: "Place a member in an income bracket (low, mid, high, ...) for income splits"}, SetIncomeBracket)
+	mcp.AddTool(server, &mcp.Tool{Name: "define_income_bracket", Description: "Add an income bracket or change its weight for income splits"}, DefineIncomeBracket)
+	mcp.AddTool(server, &mcp.Tool{Name: "normalize_percentage_maps", Description: "Round drifted percentages such as 33.33333333 in stored percentage splits and recompute their debts"}, NormalizePercentageMaps)
+	mcp.AddTool(server, &mcp.Tool{Name: "back_charge_person", Description: "Add a person retroactively to past equal-split expenses (\"Dave was actually at dinner\"); previews the share changes unless commit is set"}, BackChargePerson)
+	mcp.AddTool(server, &mcp.Tool{Name: "explain_balance", Description: "Explain a person's balance in plain English: whom they owe and who owes them, for which expenses, and the net"}, ExplainBalance)
+	mcp.AddTool(server, &mcp.Tool{Name: "set_require_all_participate", Description: "Strict mode: reject expenses that leave a member out of the split unless they are explicitly excluded"}, SetRequireAllParticipate)
+	mcp.AddTool(server, &mcp.Tool{Name: "settle_up_converted", Description: "Settle a group in another currency: the fewest transfers, converted from the home currency at the given rate"}, SettleUpConverted)
+	mcp.AddTool(server, &mcp.Tool{Name: "reconcile_statement", Description: "Match expenses against bank statement entries by amount and date, listing matches, expenses not on the statement and charges never logged"}, ReconcileStatement)
+	mcp.AddTool(server, &mcp.Tool{Name: "settle_up_with_constraints", Description: "Minimal settlement when some people can't send or can't receive money; people who end up even are routed around"}, SettleUpWithConstraints)
+	mcp.AddTool(server, &mcp.Tool{Name: "person_statement", Description: "A member's personal statement: their share of each expense, what they paid, their net balance and how they settle up"}, PersonStatement)
+	mcp.AddTool(server, &mcp.Tool{Name: "resolve_name", Description: "Look up a possibly misspelled name: the exact member, or close matches to ask \"did you mean Bob?\""}, ResolveName)
+	mcp.AddTool(server, &mcp.Tool{Name: "spending_by_period", Description: "Total spending per week or month, e.g. monthly shared costs of roommates"}, SpendingByPeriod)
+	mcp.AddTool(server, &mcp.Tool{Name: "settlement_velocity", Description: "How fast a group pays down its debts, from its payment history, and the projected date everything is settled"}, SettlementVelocity)
+	mcp.AddTool(server, &mcp.Tool{Name: "itemize_existing_expense", Description: "Break an expense into items with their own amounts and participants, e.g. $60 food for everyone and $40 drinks for two"}, ItemizeExistingExpense)
+	mcp.AddTool(server, &mcp.Tool{Name: "person_category_share", Description: "Show how much of the spending under one expense label, such as food, was a given person's share"}, PersonCategoryShare)
+	mcp.AddTool(server, &mcp.Tool{Name: "bulk_resplit", Description: "Split several existing expenses again with the same method and percentages or weights, e.g. redo these as a 2:1 split"}, BulkResplit)
+	mcp.AddTool(server, &mcp.Tool{Name: "exit_plan", Description: "Show the transfers one person must make or receive to leave the group even, e.g. before leaving a trip early"}, ExitPlan)
+	mcp.AddTool(server, &mcp.Tool{Name: "split_fairness_delta", Description: "Show how much more or less each person bore than under a plain equal split of all spending"}, SplitFairnessDelta)
+	mcp.AddTool(server, &mcp.Tool{Name: "verify_all", Description: "Check every group for consistency problems, e.g. after a bulk import or a batch of edits"}, VerifyAll)
+	mcp.AddTool(server, &mcp.Tool{Name: "export_read_only_token", Description: "Create a signed token holding a group, to share a trip summary without sending a file"}, ExportReadOnlyToken)
+	mcp.AddTool(server, &mcp.Tool{Name: "view_read_only_token", Description: "Show the group held in a read-only token from export_read_only_token, without adding it to the groups here"}, ViewReadOnlyToken)
+	mcp.AddTool(server, &mcp.Tool{Name: "suggest_buffer", Description: "Suggest how much each person should pre-pay into a shared pot for the rest of a trip, settling current balances along the way"}, SuggestBuffer)
+	mcp.AddTool(server, &mcp.Tool{Name: "merge_duplicate_expenses", Description: "Keep one of several identical expenses that were entered more than once and delete the rest"}, MergeDuplicateExpenses)
+	mcp.AddTool(server, &mcp.Tool{Name: "best_payer_for", Description: "Suggest who should pay an upcoming expense of a given size so the group ends up closest to balanced"}, BestPayerFor)
+	mcp.AddTool(server, &mcp.Tool{Name: "settle_up", Description: "Record that one person paid off (part of) what they owe another and show what is still owed, with an optional memo; overpaying needs allow_overpay, and so do transfers from simplify_debts, dissolution_plan or banker_settlement to someone the payer doesn't owe directly"}, SettleUp)
+	mcp.AddTool(server, &mcp.Tool{Name: "sole_beneficiary_expenses", Description: "List the expenses where one person was the only one with a share, to spot personal charges entered as shared"}, SoleBeneficiaryExpenses)
+	mcp.AddTool(server, &mcp.Tool{Name: "remove_person", Description: "Remove a person, e.g. one added with a typo; expenses they paid are deleted and their shares taken out of the rest. Outstanding balances or recurring expenses naming them need force"}, RemovePerson)
+	mcp.AddTool(server, &mcp.Tool{Name: "apply_group_credit", Description: "Spread a late group-wide credit, such as a refund not tied to one expense, over everyone in proportion to their shares"}, ApplyGroupCredit)
+	mcp.AddTool(server, &mcp.Tool{Name: "person_total_exposure", Description: "Show a person's net balance in every group they are in and overall, to see if they are up or down across all trips"}, PersonTotalExposure)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	log.Printf("Running mcp server...\n")
-	// Run the server over stdin/stdout until the client disconnects
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	// Run the server over stdin/stdout until the client disconnects or we are told to stop
+	err := server.Run(ctx, &mcp.StdioTransport{})
+	if *stateFile != "" {
+		saveState(*stateFile)
+	}
+	if err != nil && ctx.Err() == nil {
 		log.Fatal(err)
 	}
 }
+
+// setReadOnlyTokenKey signs read-only tokens with the key in EXPENSE_SPLITTER_TOKEN_KEY, so
+// instances that share it can open each other's tokens. Without it, a random key is used
+// and tokens only open on this instance until it restarts.
+func setReadOnlyTokenKey() error {
+	if key := os.Getenv("EXPENSE_SPLITTER_TOKEN_KEY"); key != "" {
+		return groups.SetReadOnlyTokenKey([]byte(key))
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	log.Printf("EXPENSE_SPLITTER_TOKEN_KEY is not set; read-only tokens will only open on this instance\n")
+	return groups.SetReadOnlyTokenKey(key)
+}
+
+// loadState loads the groups saved to path. A file that can't be loaded is moved aside
+// to path.bad, so the next save doesn't overwrite it, and the server starts empty.
+func loadState(path string) {
+	err := groups.LoadFromFile(path)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if renameErr := os.Rename(path, path+".bad"); renameErr != nil {
+		log.Fatalf("%v; moving it aside failed: %v", err, renameErr)
+	}
+	log.Printf("%v; moved it to %s.bad and starting without groups\n", err, path)
+}
+
+// stateMu keeps saves of the state file in order.
+var stateMu sync.Mutex
+
+// saveState writes every group to path, logging a failure.
+func saveState(path string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	if err := groups.SaveToFile(path); err != nil {
+		log.Printf("saving state to %s failed: %v\n", path, err)
+	}
+}
+
+// saveStateAfterToolCalls saves the state to path after every tool call, so a crash loses
+// at most the call in flight.
+func saveStateAfterToolCalls(path string) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			result, err := next(ctx, method, req)
+			if method == "tools/call" {
+				saveState(path)
+			}
+			return result, err
+		}
+	}
+}
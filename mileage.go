@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type AddMileageExpenseInput struct {
+	GroupName   string             `json:"group_name,omitempty" jsonschema_description:"group of the carpool"`
+	PaidBy      string             `json:"paid_by,omitempty" jsonschema_description:"person who paid for gas, tolls and so on"`
+	Amount      string             `json:"amount,omitempty" jsonschema_description:"total cost in dollars (e.g. \"60\" or \"60.50\")"`
+	Description string             `json:"description,omitempty" jsonschema_description:"a short description, e.g. gas to the lake"`
+	Miles       map[string]float64 `json:"miles,omitempty" jsonschema_description:"Map person->miles they rode; each pays in proportion. People left out pay nothing"`
+}
+
+type AddMileageExpenseOutput struct {
+	ExpenseID int               `json:"expense_id"`
+	Shares    map[string]string `json:"shares" jsonschema_description:"Map person->share in dollars"`
+}
+
+func AddMileageExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddMileageExpenseInput) (*mcp.CallToolResult, *AddMileageExpenseOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to add a carpool expense")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.PaidBy == "" || input.Amount == "" || input.Description == "" || len(input.Miles) == 0 {
+		return nil, nil, errors.New("paid_by, amount, description and miles are required")
+	}
+
+	total, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := group.AddMileageExpense(input.PaidBy, total, input.Miles, input.Description)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not add the carpool expense: %w", err)
+	}
+	shares, err := group.ExpenseShares(e.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &AddMileageExpenseOutput{
+		ExpenseID: e.ID,
+		Shares:    make(map[string]string, len(shares)),
+	}
+	for name, share := range shares {
+		output.Shares[name] = formatMicroCents(share)
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type PaymentHistoryInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type PaymentView struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Amount    string `json:"amount"`
+	Memo      string `json:"memo,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type PaymentHistoryOutput struct {
+	Payments []PaymentView `json:"payments" jsonschema_description:"payments recorded so far, oldest first"`
+}
+
+type SettleUpInput struct {
+	GroupName    string `json:"group_name,omitempty" jsonschema_description:"group of the two people"`
+	From         string `json:"from,omitempty" jsonschema_description:"person who paid off (part of) their debt"`
+	To           string `json:"to,omitempty" jsonschema_description:"person they paid"`
+	Amount       string `json:"amount,omitempty" jsonschema_description:"amount paid in dollars (e.g. \"40\")"`
+	Memo         string `json:"memo,omitempty" jsonschema_description:"optional note, e.g. \"Venmo on 3/5\""`
+	AllowOverpay bool   `json:"allow_overpay,omitempty" jsonschema_description:"record the payment even if it is more than from owes to; needed for a transfer suggested by simplify_debts, dissolution_plan or banker_settlement when from doesn't owe to directly"`
+}
+
+type SettleUpOutput struct {
+	Msg        string `json:"msg" jsonschema_description:"success message"`
+	NetBalance string `json:"net_balance" jsonschema_description:"what from still owes to after the payment; negative when to now owes from"`
+}
+
+func SettleUp(ctx context.Context, req *mcp.CallToolRequest, input *SettleUpInput) (*mcp.CallToolResult, *SettleUpOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to settle up")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.From == "" || input.To == "" || input.Amount == "" {
+		return nil, nil, errors.New("from, to and amount are required")
+	}
+
+	microCents, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	remaining, err := group.RecordPaymentWithMemo(input.From, input.To, microCents, input.Memo, input.AllowOverpay)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SettleUpOutput{
+		Msg:        fmt.Sprintf("recorded %s paying %s %s", input.From, input.To, formatMicroCents(microCents)),
+		NetBalance: formatMicroCents(remaining),
+	}
+	return nil, output, nil
+}
+
+func PaymentHistory(ctx context.Context, req *mcp.CallToolRequest, input *PaymentHistoryInput) (*mcp.CallToolResult, *PaymentHistoryOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to list its payments")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &PaymentHistoryOutput{
+		Payments: toPaymentViews(group.CompletedPayments()),
+	}
+	return nil, output, nil
+}
+
+func toPaymentViews(payments []groups.Payment) []PaymentView {
+	views := make([]PaymentView, 0, len(payments))
+	for _, p := range payments {
+		views = append(views, PaymentView{
+			From:      p.From,
+			To:        p.To,
+			Amount:    formatMicroCents(p.AmountMicroCents),
+			Memo:      p.Memo,
+			CreatedAt: fmt.Sprint(p.CreatedAt),
+		})
+	}
+	return views
+}
+
+type ApplyGroupCreditInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group that got the credit"`
+	Amount    string `json:"amount,omitempty" jsonschema_description:"credit in dollars (e.g. \"45\"), such as a refund not tied to one expense"`
+}
+
+type ApplyGroupCreditOutput struct {
+	Msg            string             `json:"msg" jsonschema_description:"success message"`
+	ExpenseDetails map[string]float64 `json:"expense_details" jsonschema_description:"who pays whom how much after the credit, in dollars"`
+}
+
+func ApplyGroupCredit(ctx context.Context, req *mcp.CallToolRequest, input *ApplyGroupCreditInput) (*mcp.CallToolResult, *ApplyGroupCreditOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to apply a credit")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Amount == "" {
+		return nil, nil, errors.New("amount is required")
+	}
+
+	microCents, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := group.ApplyGroupCredit(microCents); err != nil {
+		return nil, nil, err
+	}
+
+	output := &ApplyGroupCreditOutput{
+		Msg:            fmt.Sprintf("spread a credit of %s over the group in proportion to each person's shares", formatMicroCents(microCents)),
+		ExpenseDetails: group.GetExpenseDetails(),
+	}
+	return nil, output, nil
+}
+
+type SettlementVelocityInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type SettlementVelocityOutput struct {
+	PerDay      float64 `json:"per_day" jsonschema_description:"average dollars paid back per day, from the first payment to the last"`
+	Outstanding string  `json:"outstanding" jsonschema_description:"dollars still owed in total"`
+	SettledBy   string  `json:"settled_by,omitempty" jsonschema_description:"projected date (YYYY-MM-DD) everything is paid back at this pace"`
+	Summary     string  `json:"summary"`
+}
+
+func SettlementVelocity(ctx context.Context, req *mcp.CallToolRequest, input *SettlementVelocityInput) (*mcp.CallToolResult, *SettlementVelocityOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to measure how fast it settles")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	perDay := group.SettlementVelocity()
+	outstanding, settledBy, ok := group.ProjectedSettlement()
+	output := &SettlementVelocityOutput{
+		PerDay:      perDay,
+		Outstanding: formatMicroCents(outstanding),
+	}
+	switch {
+	case outstanding == 0:
+		output.Summary = "everyone is settled up"
+	case !ok:
+		output.Summary = fmt.Sprintf("%s is owed and no payments were recorded yet, so there is no pace to project from", output.Outstanding)
+	default:
+		output.SettledBy = settledBy.Format(time.DateOnly)
+		output.Summary = fmt.Sprintf("paying back about $%.2f/day, the remaining %s would be settled by %s", perDay, output.Outstanding, output.SettledBy)
+	}
+	return nil, output, nil
+}
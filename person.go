@@ -15,7 +15,9 @@ type AddPeopleInput struct {
 }
 
 type AddPeopleOutput struct {
-	Msg string `json:"msg" jsonschema_description:"success message"`
+	Msg   string   `json:"msg" jsonschema_description:"success message"`
+	Added []string `json:"added" jsonschema_description:"names the people were added under; differs from the input when a duplicate name was auto-suffixed"`
+	Notes []string `json:"notes,omitempty" jsonschema_description:"things to know about the new members, e.g. expenses recorded before they joined that they are not part of"`
 }
 
 func parseNames(value any) ([]string, error) {
@@ -40,11 +42,9 @@ func parseNames(value any) ([]string, error) {
 func AddPeople(ctx context.Context, req *mcp.CallToolRequest, input *AddPeopleInput) (*mcp.CallToolResult, *AddPeopleOutput, error) {
 	groupName := input.GroupName
 	names := input.Names
+	budget := &elicitationBudget{}
 	if len(names) == 0 || groupName == "" {
-		// Get the session so we can talk back to the client.
-		ss, _ := req.GetSession().(*mcp.ServerSession)
-
-		er, err := ss.Elicit(ctx, &mcp.ElicitParams{
+		er, err := budget.elicit(ctx, req, &mcp.ElicitParams{
 			Mode:    "form",
 			Message: "I need group name and person name(s)",
 			RequestedSchema: map[string]any{
@@ -98,15 +98,140 @@ func AddPeople(ctx context.Context, req *mcp.CallToolRequest, input *AddPeopleIn
 	if !exists {
 		return nil, nil, fmt.Errorf("group(%s) not found; create it with CreateGroup", groupName)
 	}
+	added := make([]string, 0, len(names))
+	var notes []string
 	for _, name := range names {
-		if err := group.AddPerson(name); err != nil {
+		leftOut := group.WouldAffectExistingExpenses(name)
+		p, err := group.AddMember(name)
+		if err != nil {
 			return nil, nil, err
 		}
+		added = append(added, p.Name)
+		if leftOut {
+			notes = append(notes, fmt.Sprintf("note: %s will not be included in the %d expenses already recorded.", p.Name, group.ExpenseCount()))
+		}
 	}
 
 	output := &AddPeopleOutput{
-		Msg: "success",
+		Msg:   "success",
+		Added: added,
+		Notes: notes,
 	}
 
 	return nil, output, nil
 }
+
+type SetNameCollisionPolicyInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to configure"`
+	Policy    string `json:"policy" jsonschema_description:"reject duplicate names (default), auto_suffix them as \"Alex (2)\", or require a disambiguator such as \"Alex (work)\""`
+}
+
+type SetNameCollisionPolicyOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func SetNameCollisionPolicy(ctx context.Context, req *mcp.CallToolRequest, input *SetNameCollisionPolicyInput) (*mcp.CallToolResult, *SetNameCollisionPolicyOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to configure its name collision policy")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	if err := group.SetNameCollisionPolicy(groups.NameCollisionPolicy(input.Policy)); err != nil {
+		return nil, nil, err
+	}
+
+	output := &SetNameCollisionPolicyOutput{
+		Msg: fmt.Sprintf("name collision policy set to %s", input.Policy),
+	}
+	return nil, output, nil
+}
+
+type RenamePersonInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Name      string `json:"name" jsonschema_description:"current name of the person"`
+	NewName   string `json:"new_name" jsonschema_description:"name to rename them to"`
+}
+
+type RenamePersonOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func RenamePerson(ctx context.Context, req *mcp.CallToolRequest, input *RenamePersonInput) (*mcp.CallToolResult, *RenamePersonOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to rename a person")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Name == "" || input.NewName == "" {
+		return nil, nil, errors.New("name and new_name are required")
+	}
+
+	if err := group.RenamePerson(input.Name, input.NewName); err != nil {
+		return nil, nil, err
+	}
+
+	output := &RenamePersonOutput{
+		Msg: fmt.Sprintf("renamed %s to %s in every expense, debt and payment", input.Name, input.NewName),
+	}
+	return nil, output, nil
+}
+
+type RemovePersonInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Name      string `json:"name" jsonschema_description:"person to remove, e.g. one added with a typo"`
+	Force     bool   `json:"force,omitempty" jsonschema_description:"remove them even with outstanding balances, dropping their debts, payments and shares and the recurring expenses that name them"`
+}
+
+type RemovePersonOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func RemovePerson(ctx context.Context, req *mcp.CallToolRequest, input *RemovePersonInput) (*mcp.CallToolResult, *RemovePersonOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to remove a person")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	if err := group.RemovePerson(input.Name, input.Force); err != nil {
+		return nil, nil, err
+	}
+
+	output := &RemovePersonOutput{
+		Msg: fmt.Sprintf("removed %s from group %s", input.Name, group.Name),
+	}
+	return nil, output, nil
+}
+
+type ResolveNameInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Name      string `json:"name,omitempty" jsonschema_description:"name as typed, possibly misspelled"`
+}
+
+type ResolveNameOutput struct {
+	Resolved   string   `json:"resolved,omitempty" jsonschema_description:"the member's name when it matches exactly, ignoring case"`
+	Candidates []string `json:"candidates,omitempty" jsonschema_description:"close matches, closest first, when there is no exact match"`
+	Msg        string   `json:"msg"`
+}
+
+func ResolveName(ctx context.Context, req *mcp.CallToolRequest, input *ResolveNameInput) (*mcp.CallToolResult, *ResolveNameOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to look up a person")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	resolved, candidates, err := group.ResolveName(input.Name)
+	output := &ResolveNameOutput{
+		Resolved:   resolved,
+		Candidates: candidates,
+		Msg:        fmt.Sprintf("%s is a member", resolved),
+	}
+	if err != nil {
+		output.Msg = err.Error()
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"expense-splitter/groups"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestAddPeopleNotesEarlierExpenses(t *testing.T) {
+	group, err := groups.Create("late-joiner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { groups.Delete(group.Name) })
+
+	ctx := context.Background()
+	req := &mcp.CallToolRequest{}
+	_, out, err := AddPeople(ctx, req, &AddPeopleInput{GroupName: group.Name, Names: []string{"Alice", "Bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Notes) != 0 {
+		t.Fatalf("expected no notes before any expense, got %v", out.Notes)
+	}
+
+	e := &groups.Expense{PaidBy: "Alice", TotalMicroCents: 20 * 100 * 1000, Description: "dinner", SplitMethod: "equal"}
+	if err := group.AddExpense(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, out, err = AddPeople(ctx, req, &AddPeopleInput{GroupName: group.Name, Names: []string{"Charlie"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "note: Charlie will not be included in the 1 expenses already recorded."
+	if len(out.Notes) != 1 || out.Notes[0] != want {
+		t.Fatalf("expected note %q, got %v", want, out.Notes)
+	}
+}
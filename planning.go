@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"sort"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ContributionView is a suggested amount for one person.
+type ContributionView struct {
+	Name   string `json:"name"`
+	Amount string `json:"amount" jsonschema_description:"amount in dollars"`
+}
+
+type ContributionToEqualizeInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group planning the purchase"`
+	Amount    string `json:"amount,omitempty" jsonschema_description:"planned shared cost in dollars (e.g. \"80\" or \"80.50\")"`
+}
+
+type ContributionToEqualizeOutput struct {
+	Contributions []ContributionView `json:"contributions" jsonschema_description:"how much each person should chip in; those who overpaid so far chip in less"`
+}
+
+func ContributionToEqualize(ctx context.Context, req *mcp.CallToolRequest, input *ContributionToEqualizeInput) (*mcp.CallToolResult, *ContributionToEqualizeOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to plan contributions")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Amount == "" {
+		return nil, nil, errors.New("amount is required")
+	}
+	amount, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ContributionToEqualizeOutput{
+		Contributions: toContributionViews(group.ContributionToEqualize(amount)),
+	}
+	return nil, output, nil
+}
+
+type SuggestPoolContributionsInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group pre-funding a shared pool"`
+	Amount    string `json:"amount,omitempty" jsonschema_description:"what each person is meant to put in, in dollars (e.g. \"50\")"`
+}
+
+type SuggestPoolContributionsOutput struct {
+	Contributions []ContributionView `json:"contributions" jsonschema_description:"how much each person should put into the pool; those who are owed put in less, those who owe put in more"`
+}
+
+func SuggestPoolContributions(ctx context.Context, req *mcp.CallToolRequest, input *SuggestPoolContributionsInput) (*mcp.CallToolResult, *SuggestPoolContributionsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to plan the pool")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Amount == "" {
+		return nil, nil, errors.New("amount is required")
+	}
+	amount, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SuggestPoolContributionsOutput{
+		Contributions: toContributionViews(group.SuggestPoolContributions(amount)),
+	}
+	return nil, output, nil
+}
+
+type SuggestBufferInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group planning the rest of its trip"`
+	Amount    string `json:"amount,omitempty" jsonschema_description:"anticipated remaining group cost in dollars (e.g. \"600\")"`
+}
+
+type SuggestBufferOutput struct {
+	Contributions []ContributionView `json:"contributions" jsonschema_description:"how much each person should pre-pay into the shared pot; a negative amount is paid out of the pot to someone who is owed more than their share"`
+}
+
+func SuggestBuffer(ctx context.Context, req *mcp.CallToolRequest, input *SuggestBufferInput) (*mcp.CallToolResult, *SuggestBufferOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to suggest a buffer")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Amount == "" {
+		return nil, nil, errors.New("amount is required")
+	}
+	amount, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SuggestBufferOutput{
+		Contributions: toContributionViews(group.SuggestBuffer(amount)),
+	}
+	return nil, output, nil
+}
+
+type BestPayerForInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group planning the expense"`
+	Amount    string `json:"amount,omitempty" jsonschema_description:"size of the upcoming expense in dollars (e.g. \"90\"), to be split equally"`
+}
+
+type BestPayerForOutput struct {
+	Payer string `json:"payer" jsonschema_description:"who should pay to leave the group closest to balanced"`
+}
+
+func BestPayerFor(ctx context.Context, req *mcp.CallToolRequest, input *BestPayerForInput) (*mcp.CallToolResult, *BestPayerForOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to suggest a payer")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Amount == "" {
+		return nil, nil, errors.New("amount is required")
+	}
+	amount, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payer := group.BestPayerFor(amount)
+	if payer == "" {
+		return nil, nil, errors.New("the group has no members to suggest a payer from")
+	}
+	output := &BestPayerForOutput{
+		Payer: payer,
+	}
+	return nil, output, nil
+}
+
+type PayerRotationInput struct {
+	GroupName        string `json:"group_name,omitempty" jsonschema_description:"group planning its next expenses"`
+	UpcomingExpenses int    `json:"upcoming_expenses,omitempty" jsonschema_description:"how many upcoming expenses to plan; defaults to the number of members"`
+}
+
+type PayerRotationOutput struct {
+	Rotation []string `json:"rotation" jsonschema_description:"who should pay each upcoming expense, in order; the biggest debtors come first"`
+}
+
+func PayerRotation(ctx context.Context, req *mcp.CallToolRequest, input *PayerRotationInput) (*mcp.CallToolResult, *PayerRotationOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to plan who pays next")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.UpcomingExpenses < 0 {
+		return nil, nil, errors.New("upcoming_expenses must not be negative")
+	}
+	n := input.UpcomingExpenses
+	if n == 0 {
+		n = len(group.GetPeople())
+	}
+
+	output := &PayerRotationOutput{
+		Rotation: group.PayerRotation(n),
+	}
+	return nil, output, nil
+}
+
+func toContributionViews(amounts map[string]int64) []ContributionView {
+	views := make([]ContributionView, 0, len(amounts))
+	for name, amount := range amounts {
+		views = append(views, ContributionView{Name: name, Amount: formatMicroCents(amount)})
+	}
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].Name < views[j].Name
+	})
+	return views
+}
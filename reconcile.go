@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// StatementEntryInput is one charge of a bank or card statement as parsed by the caller.
+type StatementEntryInput struct {
+	Date        string `json:"date" jsonschema_description:"date of the charge (YYYY-MM-DD)"`
+	Amount      string `json:"amount" jsonschema_description:"amount charged in dollars (e.g. \"42.10\")"`
+	Description string `json:"description,omitempty" jsonschema_description:"description on the statement"`
+}
+
+type ReconcileStatementInput struct {
+	GroupName string                `json:"group_name,omitempty" jsonschema_description:"group whose expenses to check"`
+	Entries   []StatementEntryInput `json:"entries" jsonschema_description:"the statement's charges"`
+}
+
+// StatementMatchView is a statement entry matched to an expense.
+type StatementMatchView struct {
+	Entry              StatementEntryInput `json:"entry"`
+	ExpenseID          int                 `json:"expense_id"`
+	ExpenseDescription string              `json:"expense_description"`
+}
+
+type ReconcileStatementOutput struct {
+	Matched           []StatementMatchView  `json:"matched" jsonschema_description:"statement entries with the expense they match"`
+	UnmatchedExpenses []groups.ExpenseView  `json:"unmatched_expenses" jsonschema_description:"expenses not found on the statement"`
+	UnmatchedEntries  []StatementEntryInput `json:"unmatched_entries" jsonschema_description:"charges on the statement that were never logged"`
+}
+
+func ReconcileStatement(ctx context.Context, req *mcp.CallToolRequest, input *ReconcileStatementInput) (*mcp.CallToolResult, *ReconcileStatementOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to reconcile a statement")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if len(input.Entries) == 0 {
+		return nil, nil, errors.New("entries are required")
+	}
+
+	entries := make([]groups.StatementEntry, 0, len(input.Entries))
+	for i, in := range input.Entries {
+		date, err := time.ParseInLocation(time.DateOnly, in.Date, time.Local)
+		if err != nil {
+			return nil, nil, fmt.Errorf("entry %d: date must be YYYY-MM-DD: %w", i+1, err)
+		}
+		amount, err := groups.ParseDollars(in.Amount)
+		if err != nil {
+			return nil, nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		entries = append(entries, groups.StatementEntry{Date: date, AmountMicroCents: amount, Description: in.Description})
+	}
+
+	report, err := group.Reconcile(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	toInput := func(e groups.StatementEntry) StatementEntryInput {
+		return StatementEntryInput{
+			Date:        e.Date.Format(time.DateOnly),
+			Amount:      formatMicroCents(e.AmountMicroCents),
+			Description: e.Description,
+		}
+	}
+	output := &ReconcileStatementOutput{
+		Matched:          make([]StatementMatchView, 0, len(report.Matched)),
+		UnmatchedEntries: make([]StatementEntryInput, 0, len(report.UnmatchedEntries)),
+	}
+	for _, m := range report.Matched {
+		view := StatementMatchView{Entry: toInput(m.Entry), ExpenseID: m.ExpenseID}
+		if e, err := group.GetExpense(m.ExpenseID); err == nil {
+			view.ExpenseDescription = e.Description
+		}
+		output.Matched = append(output.Matched, view)
+	}
+	unmatched := make([]groups.Expense, 0, len(report.UnmatchedExpenses))
+	for _, id := range report.UnmatchedExpenses {
+		if e, err := group.GetExpense(id); err == nil {
+			unmatched = append(unmatched, e)
+		}
+	}
+	output.UnmatchedExpenses = group.ExpenseViews(unmatched)
+	for _, e := range report.UnmatchedEntries {
+		output.UnmatchedEntries = append(output.UnmatchedEntries, toInput(e))
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"expense-splitter/groups"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type AddRecurringExpenseInput struct {
+	GroupName        string             `json:"group_name,omitempty" jsonschema_description:"group where the recurring expense belongs"`
+	Amount           string             `json:"amount" jsonschema_description:"amount in dollars of every occurrence (e.g. \"1200\", \"85.50\")"`
+	PaidBy           string             `json:"paid_by" jsonschema_description:"the person who pays every occurrence"`
+	Description      string             `json:"description" jsonschema_description:"e.g. rent, internet"`
+	SplitMethod      string             `json:"split_method" jsonschema_description:"equal, percentage, weights or duration"`
+	SplitPercentages map[string]float64 `json:"split_percentages,omitempty" jsonschema_description:"Map person->percentage when split_method is percentage"`
+	SplitWeights     map[string]float64 `json:"split_weights,omitempty" jsonschema_description:"Map person->weight when split_method is weights"`
+	IntervalDays     int                `json:"interval_days" jsonschema_description:"days between occurrences, e.g. 30 for monthly; at most 366"`
+	StartDate        string             `json:"start_date,omitempty" jsonschema_description:"date (YYYY-MM-DD) of the first occurrence; defaults to today"`
+}
+
+type AddRecurringExpenseOutput struct {
+	RecurringID int    `json:"recurring_id"`
+	Next        string `json:"next" jsonschema_description:"date the first occurrence is due"`
+}
+
+type MaterializeRecurringInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group whose recurring expenses to materialize"`
+	UpTo      string `json:"up_to,omitempty" jsonschema_description:"add occurrences due on or before this date (YYYY-MM-DD); defaults to now"`
+}
+
+type MaterializeRecurringOutput struct {
+	ExpenseIDs []int  `json:"expense_ids" jsonschema_description:"IDs of the expenses added"`
+	More       bool   `json:"more,omitempty" jsonschema_description:"true when the per-call cap was reached and more occurrences may still be due"`
+	Msg        string `json:"msg,omitempty"`
+}
+
+func AddRecurringExpense(ctx context.Context, req *mcp.CallToolRequest, input *AddRecurringExpenseInput) (*mcp.CallToolResult, *AddRecurringExpenseOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to add a recurring expense")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	// bound interval_days before it is turned into a duration, which could overflow
+	if input.IntervalDays <= 0 || input.IntervalDays > groups.MaxRecurringIntervalDays {
+		return nil, nil, fmt.Errorf("interval_days must be between 1 and %d", groups.MaxRecurringIntervalDays)
+	}
+
+	totalMicroCents, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := time.Now()
+	if input.StartDate != "" {
+		if start, err = time.ParseInLocation(time.DateOnly, input.StartDate, time.Local); err != nil {
+			return nil, nil, fmt.Errorf("invalid start_date %q, expected YYYY-MM-DD", input.StartDate)
+		}
+	}
+
+	template := groups.Expense{
+		TotalMicroCents:  totalMicroCents,
+		PaidBy:           input.PaidBy,
+		Description:      input.Description,
+		SplitMethod:      input.SplitMethod,
+		SplitPercentages: input.SplitPercentages,
+		SplitWeights:     input.SplitWeights,
+		CreatedAt:        start,
+	}
+	id, err := group.AddRecurring(template, time.Duration(input.IntervalDays)*24*time.Hour)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &AddRecurringExpenseOutput{
+		RecurringID: id,
+		Next:        start.Format(time.DateOnly),
+	}
+	return nil, output, nil
+}
+
+func MaterializeRecurring(ctx context.Context, req *mcp.CallToolRequest, input *MaterializeRecurringInput) (*mcp.CallToolResult, *MaterializeRecurringOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to materialize its recurring expenses")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	upTo := time.Now()
+	if input.UpTo != "" {
+		date, err := time.ParseInLocation(time.DateOnly, input.UpTo, time.Local)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid up_to %q, expected YYYY-MM-DD", input.UpTo)
+		}
+		// include occurrences due at any time that day
+		upTo = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	ids := group.MaterializeRecurring(upTo)
+	output := &MaterializeRecurringOutput{
+		ExpenseIDs: ids,
+	}
+	if len(ids) == groups.MaxOccurrencesPerMaterialize {
+		output.More = true
+		output.Msg = fmt.Sprintf("added the first %d occurrences; call materialize_recurring again for the rest", len(ids))
+	}
+	return nil, output, nil
+}
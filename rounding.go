@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RoundingFairnessView is a person's cumulative rounding bias as returned by rounding_fairness.
+type RoundingFairnessView struct {
+	Name            string `json:"name"`
+	ExtraMicroCents int64  `json:"extra_micro_cents" jsonschema_description:"micro cents (1/1000 of a cent) absorbed beyond a fair share of rounding remainders; negative means less"`
+}
+
+type RoundingFairnessInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type RoundingFairnessOutput struct {
+	People []RoundingFairnessView `json:"people" jsonschema_description:"people ordered from most to least extra absorbed; sums to zero"`
+}
+
+func RoundingFairness(ctx context.Context, req *mcp.CallToolRequest, input *RoundingFairnessInput) (*mcp.CallToolResult, *RoundingFairnessOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to report its rounding fairness")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	report := group.RoundingFairnessReport()
+	people := make([]RoundingFairnessView, 0, len(report))
+	for name, extra := range report {
+		people = append(people, RoundingFairnessView{Name: name, ExtraMicroCents: extra})
+	}
+	sort.Slice(people, func(i, j int) bool {
+		if people[i].ExtraMicroCents != people[j].ExtraMicroCents {
+			return people[i].ExtraMicroCents > people[j].ExtraMicroCents
+		}
+		return people[i].Name < people[j].Name
+	})
+
+	output := &RoundingFairnessOutput{
+		People: people,
+	}
+	return nil, output, nil
+}
@@ -1,5 +1,7 @@
 package main
 
+import "expense-splitter/groups"
+
 var addExpenseInputSchema = map[string]any{
 	"type":                 "object",
 	"additionalProperties": false,
@@ -11,12 +13,59 @@ var addExpenseInputSchema = map[string]any{
 		"amount": map[string]any{
 			"type":        "string",
 			"description": "Total amount in dollars (e.g. \"208\" or \"208.50\")",
-			"pattern":     `^\d+(\.\d{1,2})?$`,
+			"pattern":     groups.AmountPattern,
+		},
+		"discount": map[string]any{
+			"type":        "string",
+			"description": "Coupon or discount in dollars taken off the amount; spread over participants in proportion to their shares",
+			"pattern":     groups.AmountPattern,
+		},
+		"currency": map[string]any{
+			"type":        "string",
+			"description": "ISO 4217 code the amount is in, e.g. EUR; defaults to the group's home currency",
+		},
+		"payer_personal": map[string]any{
+			"type":        "string",
+			"description": "Dollars of the amount that were for the payer alone (e.g. their own item); only the rest is split",
+			"pattern":     groups.AmountPattern,
+		},
+		"covered_percent": map[string]any{
+			"type":             "number",
+			"minimum":          0,
+			"exclusiveMaximum": 100,
+			"description":      "Percent of the amount borne by someone outside the group (e.g. 80 when the company covers 80%); creates no debt, only the rest is split",
+		},
+		"covered_by": map[string]any{
+			"type":        "string",
+			"maxLength":   32,
+			"description": "Who covers covered_percent, e.g. Company; need not be a member",
+		},
+		"tip": map[string]any{
+			"type":        "string",
+			"description": "Dollars of the amount that were tip; split with the bill unless tip_split_weights is given",
+			"pattern":     groups.AmountPattern,
+		},
+		"tip_split_weights": map[string]any{
+			"type": "object",
+			"additionalProperties": map[string]any{
+				"type":    "number",
+				"minimum": 0,
+			},
+			"description": "Map of person -> weight for splitting the tip alone (e.g. by how much service mattered to them); the rest of the bill splits by split_method",
 		},
 		"paid_by": map[string]any{
 			"type":        "string",
 			"description": "person who paid for the expense (must be a member of the group)",
 		},
+		"paid_by_map": map[string]any{
+			"type":          "object",
+			"minProperties": 1,
+			"additionalProperties": map[string]any{
+				"type":    "number",
+				"minimum": 0,
+			},
+			"description": "Map of person -> dollars paid, when several people paid for the expense. Must add up to amount. Use instead of paid_by.",
+		},
 		"description": map[string]any{
 			"type":        "string",
 			"description": "a short description about the expense",
@@ -25,7 +74,7 @@ var addExpenseInputSchema = map[string]any{
 		},
 		"split_method": map[string]any{
 			"type":        "string",
-			"enum":        []any{"equal", "percentage", "weights"},
+			"enum":        []any{"equal", "percentage", "weights", "duration", "headcount", "income", "exact"},
 			"default":     "equal",
 			"description": "How to split. If omitted, defaults to 'equal'.",
 		},
@@ -48,8 +97,71 @@ var addExpenseInputSchema = map[string]any{
 			},
 			"description": "Map of person->weight. Weight 0 excludes the person from this expense. At least one weight must be > 0.",
 		},
+		"split_headcount": map[string]any{
+			"type":          "object",
+			"minProperties": 1,
+			"additionalProperties": map[string]any{
+				"type":    "integer",
+				"minimum": 1,
+			},
+			"description": "Map of person->number of people they stand for, e.g. 3 for someone with two kids. Used only when split_method='headcount'.",
+		},
+		"split_exact": map[string]any{
+			"type":          "object",
+			"minProperties": 1,
+			"additionalProperties": map[string]any{
+				"type":    "string",
+				"pattern": groups.AmountPattern,
+			},
+			"description": "Map of person->dollars they owe (e.g. \"12.50\"), for receipts with known per-person amounts. Must add up to amount. Used only when split_method='exact'.",
+		},
+		"period_start": map[string]any{
+			"type":        "string",
+			"format":      "date",
+			"description": "Start date (YYYY-MM-DD) of the period covered. Used only when split_method='duration'.",
+		},
+		"period_end": map[string]any{
+			"type":        "string",
+			"format":      "date",
+			"description": "End date (YYYY-MM-DD) of the period covered. Used only when split_method='duration'.",
+		},
+		"settled_participants": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"uniqueItems": true,
+			"description": "Participants who already paid their share to the payer on the spot. No debt is recorded for them.",
+		},
+		"excluded": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"uniqueItems": true,
+			"description": "Members who take no part in the expense. Honored by every split method; percentage and weights maps must not give them a positive share.",
+		},
+		"labels": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string", "pattern": "^[a-z][a-z0-9_-]{0,31}$"},
+			"maxItems":    10,
+			"uniqueItems": true,
+			"description": "Tags such as recurring, business or reimbursable; see filter_expenses_by_label.",
+		},
+		"event": map[string]any{
+			"type":        "string",
+			"maxLength":   50,
+			"description": "Sub-event of the trip the expense belongs to, e.g. \"Day 1 dinner\" or \"concert\"; see expenses_by_event.",
+		},
+		"participant_notes": map[string]any{
+			"type":                 "object",
+			"additionalProperties": map[string]any{"type": "string", "maxLength": 140},
+			"description":          "Map participant->short note explaining their share, e.g. \"had the steak\". Does not affect the split.",
+		},
+	},
+	"required": []any{"group_name", "amount", "description"},
+
+	// exactly one of paid_by or paid_by_map
+	"oneOf": []any{
+		map[string]any{"required": []any{"paid_by"}},
+		map[string]any{"required": []any{"paid_by_map"}},
 	},
-	"required": []any{"group_name", "amount", "paid_by", "description"},
 
 	// percentage => require split_percentages, forbid split_weights
 	"allOf": []any{
@@ -77,6 +189,57 @@ var addExpenseInputSchema = map[string]any{
 				"not":      map[string]any{"required": []any{"split_percentages"}},
 			},
 		},
+		map[string]any{
+			"if": map[string]any{
+				"properties": map[string]any{
+					"split_method": map[string]any{"const": "duration"},
+				},
+				"required": []any{"split_method"},
+			},
+			"then": map[string]any{
+				"required": []any{"period_start", "period_end"},
+				"not": map[string]any{
+					"anyOf": []any{
+						map[string]any{"required": []any{"split_percentages"}},
+						map[string]any{"required": []any{"split_weights"}},
+					},
+				},
+			},
+		},
+		map[string]any{
+			"if": map[string]any{
+				"properties": map[string]any{
+					"split_method": map[string]any{"const": "headcount"},
+				},
+				"required": []any{"split_method"},
+			},
+			"then": map[string]any{
+				"required": []any{"split_headcount"},
+				"not": map[string]any{
+					"anyOf": []any{
+						map[string]any{"required": []any{"split_percentages"}},
+						map[string]any{"required": []any{"split_weights"}},
+					},
+				},
+			},
+		},
+		map[string]any{
+			"if": map[string]any{
+				"properties": map[string]any{
+					"split_method": map[string]any{"const": "exact"},
+				},
+				"required": []any{"split_method"},
+			},
+			"then": map[string]any{
+				"required": []any{"split_exact"},
+				"not": map[string]any{
+					"anyOf": []any{
+						map[string]any{"required": []any{"split_percentages"}},
+						map[string]any{"required": []any{"split_weights"}},
+					},
+				},
+			},
+		},
 		map[string]any{
 			"if": map[string]any{
 				"properties": map[string]any{
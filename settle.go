@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SettlementView is a suggested transfer as returned by the settlement tools.
+type SettlementView struct {
+	From             string `json:"from" jsonschema_description:"person who pays"`
+	To               string `json:"to" jsonschema_description:"person who receives"`
+	Amount           string `json:"amount" jsonschema_description:"amount in dollars, e.g. $12.50"`
+	AmountMicroCents int64  `json:"amount_micro_cents" jsonschema_description:"amount in micro cents (1 dollar = 100000 micro cents)"`
+}
+
+type SettleSubsetInput struct {
+	GroupName string   `json:"group_name,omitempty" jsonschema_description:"group to settle"`
+	Names     []string `json:"names,omitempty" jsonschema_description:"the people to settle among (at least 2); debts with anyone else are left untouched"`
+}
+
+type SettleSubsetOutput struct {
+	Settlements []SettlementView `json:"settlements"`
+}
+
+func SettleSubset(ctx context.Context, req *mcp.CallToolRequest, input *SettleSubsetInput) (*mcp.CallToolResult, *SettleSubsetOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to settle debts among its people")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if len(input.Names) < 2 {
+		return nil, nil, errors.New("names are required; provide at least 2 people to settle among")
+	}
+
+	settlements, err := group.SettleSubset(input.Names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SettleSubsetOutput{
+		Settlements: toSettlementViews(settlements),
+	}
+	return nil, output, nil
+}
+
+func toSettlementViews(settlements []groups.Settlement) []SettlementView {
+	views := make([]SettlementView, 0, len(settlements))
+	for _, s := range settlements {
+		views = append(views, SettlementView{
+			From:             s.From,
+			To:               s.To,
+			Amount:           formatMicroCents(s.AmountMicroCents),
+			AmountMicroCents: s.AmountMicroCents,
+		})
+	}
+	return views
+}
+
+type SettleUpWithConstraintsInput struct {
+	GroupName     string   `json:"group_name,omitempty" jsonschema_description:"group to settle"`
+	CannotSend    []string `json:"cannot_send,omitempty" jsonschema_description:"people who can't send money, e.g. without a payment app"`
+	CannotReceive []string `json:"cannot_receive,omitempty" jsonschema_description:"people who can't receive money"`
+}
+
+type SettleUpWithConstraintsOutput struct {
+	Transfers []SettlementView `json:"transfers" jsonschema_description:"the fewest transfers that settle everything without anyone sending or receiving against their constraint"`
+}
+
+func SettleUpWithConstraints(ctx context.Context, req *mcp.CallToolRequest, input *SettleUpWithConstraintsInput) (*mcp.CallToolResult, *SettleUpWithConstraintsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to settle up with constraints")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	settlements, err := group.SettleUpWithConstraints(input.CannotSend, input.CannotReceive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SettleUpWithConstraintsOutput{
+		Transfers: toSettlementViews(settlements),
+	}
+	return nil, output, nil
+}
+
+type ExitPlanInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group the person is leaving"`
+	Name      string `json:"name" jsonschema_description:"person who wants to leave the group settled"`
+}
+
+type ExitPlanOutput struct {
+	Transfers []SettlementView `json:"transfers" jsonschema_description:"the transfers the person must make or receive to be even, fewest first; empty if already even"`
+}
+
+func ExitPlan(ctx context.Context, req *mcp.CallToolRequest, input *ExitPlanInput) (*mcp.CallToolResult, *ExitPlanOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to plan someone's exit")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, nil, errors.New("name is required")
+	}
+
+	settlements, err := group.ExitPlan(input.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ExitPlanOutput{
+		Transfers: toSettlementViews(settlements),
+	}
+	return nil, output, nil
+}
+
+type DissolutionPlanInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to wind down"`
+}
+
+type DissolutionPlanOutput struct {
+	Transfers []SettlementView `json:"transfers" jsonschema_description:"every transfer needed to bring everyone to zero"`
+	Summary   []string         `json:"summary" jsonschema_description:"one line per person: what they will pay or receive in total"`
+}
+
+func DissolutionPlan(ctx context.Context, req *mcp.CallToolRequest, input *DissolutionPlanInput) (*mcp.CallToolResult, *DissolutionPlanOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to plan winding it down")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	settlements := group.DissolutionPlan()
+	net := map[string]int64{}
+	for _, s := range settlements {
+		net[s.From] -= s.AmountMicroCents
+		net[s.To] += s.AmountMicroCents
+	}
+	output := &DissolutionPlanOutput{
+		Transfers: toSettlementViews(settlements),
+		Summary:   []string{},
+	}
+	for _, name := range group.GetPeople() {
+		switch amount := net[name]; {
+		case amount > 0:
+			output.Summary = append(output.Summary, fmt.Sprintf("%s will receive %s net", name, formatMicroCents(amount)))
+		case amount < 0:
+			output.Summary = append(output.Summary, fmt.Sprintf("%s will pay %s net", name, formatMicroCents(-amount)))
+		default:
+			output.Summary = append(output.Summary, fmt.Sprintf("%s is already even", name))
+		}
+	}
+	return nil, output, nil
+}
+
+type SimplifyDebtsInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group whose debts to simplify"`
+}
+
+type SimplifyDebtsOutput struct {
+	Payments map[string]float64 `json:"payments" jsonschema_description:"Map 'X to pay Y'->dollars: the fewest payments that settle everyone's overall balance; empty when everyone is even"`
+	Count    int                `json:"count" jsonschema_description:"number of payments"`
+}
+
+func SimplifyDebts(ctx context.Context, req *mcp.CallToolRequest, input *SimplifyDebtsInput) (*mcp.CallToolResult, *SimplifyDebtsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to simplify its debts")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	payments := group.SimplifyDebts()
+	output := &SimplifyDebtsOutput{
+		Payments: payments,
+		Count:    len(payments),
+	}
+	return nil, output, nil
+}
+
+type CloseOutTripInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to settle and archive"`
+}
+
+type CloseOutTripOutput struct {
+	Payments []PaymentView        `json:"payments" jsonschema_description:"payments recorded to bring everyone to zero"`
+	Archived []groups.ExpenseView `json:"archived" jsonschema_description:"expenses moved to the archive"`
+	Msg      string               `json:"msg"`
+}
+
+func CloseOutTrip(ctx context.Context, req *mcp.CallToolRequest, input *CloseOutTripInput) (*mcp.CallToolResult, *CloseOutTripOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to settle and archive it")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	payments, archived, err := group.SettleAllAndArchive()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &CloseOutTripOutput{
+		Payments: toPaymentViews(payments),
+		Archived: group.ExpenseViews(archived),
+		Msg:      fmt.Sprintf("recorded %d payments and archived %d expenses; %s starts over at zero", len(payments), len(archived), group.Name),
+	}
+	return nil, output, nil
+}
+
+type BankerSettlementInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to settle"`
+}
+
+type BankerSettlementOutput struct {
+	Banker    string           `json:"banker,omitempty" jsonschema_description:"person everyone settles with: the biggest creditor"`
+	Transfers []SettlementView `json:"transfers" jsonschema_description:"one transfer per person: debtors pay the banker, the banker pays the other creditors"`
+	Summary   string           `json:"summary"`
+}
+
+func BankerSettlement(ctx context.Context, req *mcp.CallToolRequest, input *BankerSettlementInput) (*mcp.CallToolResult, *BankerSettlementOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to plan a banker settlement")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	banker, transfers := group.OneRoundSettlement()
+	summary := "everyone is already even"
+	if banker != "" {
+		summary = fmt.Sprintf("everyone settles with %s in one transfer each", banker)
+	}
+	output := &BankerSettlementOutput{
+		Banker:    banker,
+		Transfers: toSettlementViews(transfers),
+		Summary:   summary,
+	}
+	return nil, output, nil
+}
+
+type SettlementReminderInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to remind"`
+	DueDate   string `json:"due_date,omitempty" jsonschema_description:"date (YYYY-MM-DD) to settle up by"`
+}
+
+type SettlementReminderOutput struct {
+	ICS string `json:"ics" jsonschema_description:"iCalendar file with the reminder; save it as a .ics file and open it in a calendar app"`
+}
+
+func SettlementReminder(ctx context.Context, req *mcp.CallToolRequest, input *SettlementReminderInput) (*mcp.CallToolResult, *SettlementReminderOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to create a settle-up reminder")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.DueDate == "" {
+		return nil, nil, errors.New("due_date is required")
+	}
+	due, err := time.ParseInLocation(time.DateOnly, input.DueDate, time.Local)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid due_date %q, expected YYYY-MM-DD", input.DueDate)
+	}
+
+	ics, err := group.SettlementReminderICS(due)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SettlementReminderOutput{
+		ICS: ics,
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type SnapshotGroupInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to snapshot"`
+}
+
+type SnapshotGroupOutput struct {
+	SnapshotJSON string `json:"snapshot_json" jsonschema_description:"group state (people, expenses, payments) to pass to restore_snapshot later"`
+}
+
+type RestoreSnapshotInput struct {
+	GroupName    string `json:"group_name,omitempty" jsonschema_description:"group to roll back"`
+	SnapshotJSON string `json:"snapshot_json,omitempty" jsonschema_description:"snapshot_json returned by snapshot_group for this group"`
+}
+
+type RestoreSnapshotOutput struct {
+	People   int `json:"people" jsonschema_description:"number of people after the restore"`
+	Expenses int `json:"expenses" jsonschema_description:"number of expenses after the restore"`
+	Payments int `json:"payments" jsonschema_description:"number of payments after the restore"`
+}
+
+func SnapshotGroup(ctx context.Context, req *mcp.CallToolRequest, input *SnapshotGroupInput) (*mcp.CallToolResult, *SnapshotGroupOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to snapshot it")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	data, err := json.Marshal(group.Snapshot())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SnapshotGroupOutput{
+		SnapshotJSON: string(data),
+	}
+	return nil, output, nil
+}
+
+func RestoreSnapshot(ctx context.Context, req *mcp.CallToolRequest, input *RestoreSnapshotInput) (*mcp.CallToolResult, *RestoreSnapshotOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to restore a snapshot")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.SnapshotJSON == "" {
+		return nil, nil, errors.New("snapshot_json is required")
+	}
+
+	var snapshot groups.GroupSnapshot
+	if err := json.Unmarshal([]byte(input.SnapshotJSON), &snapshot); err != nil {
+		return nil, nil, fmt.Errorf("invalid snapshot_json: %w", err)
+	}
+	if err := group.RestoreSnapshot(snapshot); err != nil {
+		return nil, nil, err
+	}
+
+	output := &RestoreSnapshotOutput{
+		People:   len(snapshot.People),
+		Expenses: len(snapshot.Expenses),
+		Payments: len(snapshot.Payments),
+	}
+	return nil, output, nil
+}
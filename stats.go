@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"expense-splitter/groups"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type GraphStatsInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type GraphStatsOutput struct {
+	groups.GraphStats
+}
+
+func GraphStats(ctx context.Context, req *mcp.CallToolRequest, input *GraphStatsInput) (*mcp.CallToolResult, *GraphStatsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to show its graph stats")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &GraphStatsOutput{
+		GraphStats: group.GraphStats(),
+	}
+	return nil, output, nil
+}
+
+type SetEdgeCoalescingInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to configure"`
+	Enabled   bool   `json:"enabled" jsonschema_description:"merge all debts from one person to another into a single edge for expenses added from now on"`
+}
+
+type SetEdgeCoalescingOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func SetEdgeCoalescing(ctx context.Context, req *mcp.CallToolRequest, input *SetEdgeCoalescingInput) (*mcp.CallToolResult, *SetEdgeCoalescingOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to configure edge coalescing")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	group.SetEdgeCoalescing(input.Enabled)
+
+	msg := "edge coalescing disabled"
+	if input.Enabled {
+		msg = "edge coalescing enabled for new expenses"
+	}
+	output := &SetEdgeCoalescingOutput{
+		Msg: msg,
+	}
+	return nil, output, nil
+}
+
+type SetRequireAllParticipateInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to configure"`
+	Enabled   bool   `json:"enabled" jsonschema_description:"reject new expenses that leave a member without a share unless they are listed in excluded"`
+}
+
+type SetRequireAllParticipateOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func SetRequireAllParticipate(ctx context.Context, req *mcp.CallToolRequest, input *SetRequireAllParticipateInput) (*mcp.CallToolResult, *SetRequireAllParticipateOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to configure the participation policy")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	group.SetRequireAllParticipate(input.Enabled)
+
+	msg := "members may be left out of expenses"
+	if input.Enabled {
+		msg = "every member must take part in new expenses unless excluded"
+	}
+	output := &SetRequireAllParticipateOutput{
+		Msg: msg,
+	}
+	return nil, output, nil
+}
+
+type CostPerDayInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type CostPerDayOutput struct {
+	CostPerDay float64 `json:"cost_per_day" jsonschema_description:"average dollars spent per day between the first and last expense, both days included"`
+	Summary    string  `json:"summary"`
+}
+
+func CostPerDay(ctx context.Context, req *mcp.CallToolRequest, input *CostPerDayInput) (*mcp.CallToolResult, *CostPerDayOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to compute its cost per day")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	perDay, err := group.CostPerDay()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary := fmt.Sprintf("about $%.2f/day", perDay)
+	if perDay == 0 {
+		summary = "no expenses yet"
+	}
+	output := &CostPerDayOutput{
+		CostPerDay: perDay,
+		Summary:    summary,
+	}
+	return nil, output, nil
+}
+
+type SpendingByPeriodInput struct {
+	GroupName   string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+	Granularity string `json:"granularity,omitempty" jsonschema_description:"week or month" jsonschema_enum:"week,month"`
+}
+
+// PeriodSpending is what a group spent in one week or month.
+type PeriodSpending struct {
+	Period string `json:"period" jsonschema_description:"e.g. 2024-03 or 2024-W09"`
+	Total  string `json:"total" jsonschema_description:"dollars spent"`
+}
+
+type SpendingByPeriodOutput struct {
+	Periods []PeriodSpending `json:"periods" jsonschema_description:"periods with expenses, oldest first"`
+}
+
+func SpendingByPeriod(ctx context.Context, req *mcp.CallToolRequest, input *SpendingByPeriodInput) (*mcp.CallToolResult, *SpendingByPeriodOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to total its spending by period")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	granularity := input.Granularity
+	if granularity == "" {
+		granularity = "month"
+	}
+
+	totals, err := group.SpendingByPeriod(granularity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &SpendingByPeriodOutput{
+		Periods: make([]PeriodSpending, 0, len(totals)),
+	}
+	for _, period := range slices.Sorted(maps.Keys(totals)) {
+		output.Periods = append(output.Periods, PeriodSpending{
+			Period: period,
+			Total:  formatMicroCents(totals[period]),
+		})
+	}
+	return nil, output, nil
+}
+
+type SpendingInequalityInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type SpendingInequalityOutput struct {
+	Gini    float64 `json:"gini" jsonschema_description:"Gini coefficient of what each member bore: 0 is perfectly even, close to 1 is one person bearing everything"`
+	Summary string  `json:"summary"`
+}
+
+func SpendingInequality(ctx context.Context, req *mcp.CallToolRequest, input *SpendingInequalityInput) (*mcp.CallToolResult, *SpendingInequalityOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to measure how evenly it split costs")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	gini := group.SpendingInequality()
+	summary := "everyone bore the same"
+	switch {
+	case gini >= 0.4:
+		summary = "very uneven: a few people bore most of the costs"
+	case gini >= 0.15:
+		summary = "somewhat uneven"
+	case gini > 0:
+		summary = "fairly even"
+	}
+	output := &SpendingInequalityOutput{
+		Gini:    gini,
+		Summary: summary,
+	}
+	return nil, output, nil
+}
+
+type SplitFairnessDeltaInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to inspect"`
+}
+
+type SplitFairnessDeltaOutput struct {
+	Deltas  map[string]string `json:"deltas" jsonschema_description:"per person, how much more (+) or less (-) they bore than under an equal split of all spending"`
+	Summary []string          `json:"summary" jsonschema_description:"one line per person, by name"`
+}
+
+func SplitFairnessDelta(ctx context.Context, req *mcp.CallToolRequest, input *SplitFairnessDeltaInput) (*mcp.CallToolResult, *SplitFairnessDeltaOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to compare its splits with an equal split")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	deltas := group.SplitFairnessDelta()
+	output := &SplitFairnessDeltaOutput{
+		Deltas:  make(map[string]string, len(deltas)),
+		Summary: []string{},
+	}
+	for _, name := range slices.Sorted(maps.Keys(deltas)) {
+		delta := deltas[name]
+		switch {
+		case delta > 0:
+			output.Deltas[name] = "+" + formatMicroCents(delta)
+			output.Summary = append(output.Summary, fmt.Sprintf("%s bore %s more than an equal split", name, formatMicroCents(delta)))
+		case delta < 0:
+			output.Deltas[name] = "-" + formatMicroCents(-delta)
+			output.Summary = append(output.Summary, fmt.Sprintf("%s bore %s less than an equal split", name, formatMicroCents(-delta)))
+		default:
+			output.Deltas[name] = formatMicroCents(0)
+			output.Summary = append(output.Summary, fmt.Sprintf("%s bore the same as an equal split", name))
+		}
+	}
+	return nil, output, nil
+}
+
+type FlagUnevenSplitsInput struct {
+	GroupName      string  `json:"group_name,omitempty" jsonschema_description:"group to review"`
+	ThresholdRatio float64 `json:"threshold_ratio,omitempty" jsonschema_description:"flag expenses where someone's share is more than this many times the average share; defaults to 3"`
+}
+
+type FlagUnevenSplitsOutput struct {
+	Expenses []groups.ExpenseView `json:"expenses" jsonschema_description:"expenses with a suspiciously large share, by ID; worth a second look for typos"`
+}
+
+func FlagUnevenSplits(ctx context.Context, req *mcp.CallToolRequest, input *FlagUnevenSplitsInput) (*mcp.CallToolResult, *FlagUnevenSplitsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to review its splits")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	ratio := input.ThresholdRatio
+	if ratio == 0 {
+		ratio = 3
+	}
+	if ratio <= 1 {
+		return nil, nil, fmt.Errorf("threshold_ratio must be greater than 1, got %v", ratio)
+	}
+
+	expenses := []groups.Expense{}
+	for _, id := range group.FlagUnevenSplits(ratio) {
+		e, err := group.GetExpense(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		expenses = append(expenses, e)
+	}
+
+	output := &FlagUnevenSplitsOutput{
+		Expenses: group.ExpenseViews(expenses),
+	}
+	return nil, output, nil
+}
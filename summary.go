@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type CompactSummaryInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to summarize"`
+}
+
+type CompactSummaryOutput struct {
+	Summary string `json:"summary" jsonschema_description:"one line overview followed by the top suggested transfers"`
+}
+
+func CompactSummary(ctx context.Context, req *mcp.CallToolRequest, input *CompactSummaryInput) (*mcp.CallToolResult, *CompactSummaryOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to summarize it")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &CompactSummaryOutput{
+		Summary: group.CompactSummary(),
+	}
+	return nil, output, nil
+}
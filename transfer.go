@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expense-splitter/groups"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type TransferDebtInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the three people"`
+	Debtor    string `json:"debtor,omitempty" jsonschema_description:"person who owes the debt"`
+	From      string `json:"from,omitempty" jsonschema_description:"person the debtor owes now"`
+	To        string `json:"to,omitempty" jsonschema_description:"person the debtor will owe instead"`
+	Amount    string `json:"amount,omitempty" jsonschema_description:"amount in dollars to move (e.g. \"20\", \"20.50\")"`
+}
+
+type TransferDebtOutput struct {
+	Msg string `json:"msg" jsonschema_description:"success message"`
+}
+
+func TransferDebt(ctx context.Context, req *mcp.CallToolRequest, input *TransferDebtInput) (*mcp.CallToolResult, *TransferDebtOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to transfer a debt")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Debtor == "" || input.From == "" || input.To == "" || input.Amount == "" {
+		return nil, nil, errors.New("debtor, from, to and amount are required")
+	}
+
+	microCents, err := groups.ParseDollars(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := group.TransferDebt(input.Debtor, input.From, input.To, microCents); err != nil {
+		return nil, nil, err
+	}
+
+	output := &TransferDebtOutput{
+		Msg: fmt.Sprintf("%s now owes %s %s instead of %s; %s owes %s the same amount", input.Debtor, input.To,
+			formatMicroCents(microCents), input.From, input.To, input.From),
+	}
+	return nil, output, nil
+}
+
+type ForgiveAllInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group of the person"`
+	Person    string `json:"person,omitempty" jsonschema_description:"person whose debts are written off"`
+}
+
+type PreviewForgiveAllOutput struct {
+	ExpenseDetails map[string]float64 `json:"expense_details" jsonschema_description:"pairwise debts in dollars that would remain, like get_group_info's expense_details"`
+}
+
+type ForgiveAllDebtsOutput struct {
+	Msg            string             `json:"msg" jsonschema_description:"success message"`
+	ExpenseDetails map[string]float64 `json:"expense_details" jsonschema_description:"pairwise debts in dollars that remain"`
+}
+
+func PreviewForgiveAll(ctx context.Context, req *mcp.CallToolRequest, input *ForgiveAllInput) (*mcp.CallToolResult, *PreviewForgiveAllOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to preview forgiving debts")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Person == "" {
+		return nil, nil, errors.New("person is required")
+	}
+
+	details, err := group.PreviewForgiveAll(input.Person)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &PreviewForgiveAllOutput{
+		ExpenseDetails: details,
+	}
+	return nil, output, nil
+}
+
+func ForgiveAllDebts(ctx context.Context, req *mcp.CallToolRequest, input *ForgiveAllInput) (*mcp.CallToolResult, *ForgiveAllDebtsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to forgive debts")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+	if input.Person == "" {
+		return nil, nil, errors.New("person is required")
+	}
+
+	total, err := group.ForgiveAllDebts(input.Person)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := &ForgiveAllDebtsOutput{
+		Msg:            fmt.Sprintf("wrote off %s that %s owed", formatMicroCents(total), input.Person),
+		ExpenseDetails: group.GetExpenseDetails(),
+	}
+	return nil, output, nil
+}
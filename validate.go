@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"expense-splitter/groups"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type ValidateSplitInput struct {
+	GroupName   string             `json:"group_name,omitempty" jsonschema_description:"group the expense would be added to"`
+	SplitMethod string             `json:"split_method" jsonschema_description:"percentage or weights"`
+	SplitMap    map[string]float64 `json:"split_map,omitempty" jsonschema_description:"Map person->percentage (must sum to 100) or person->weight (non-negative, positive sum)"`
+}
+
+type ValidateSplitOutput struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty" jsonschema_description:"why the split map would be rejected by add_expense"`
+}
+
+func ValidateSplit(ctx context.Context, req *mcp.CallToolRequest, input *ValidateSplitInput) (*mcp.CallToolResult, *ValidateSplitOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to validate a split against its members")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	output := &ValidateSplitOutput{Valid: true}
+	if err := groups.ValidateSplitMap(input.SplitMethod, input.SplitMap, group.GetPeople()); err != nil {
+		output.Valid = false
+		output.Error = err.Error()
+	}
+	return nil, output, nil
+}
+
+type NormalizePercentageMapsInput struct {
+	GroupName string `json:"group_name,omitempty" jsonschema_description:"group to clean up"`
+}
+
+type NormalizePercentageMapsOutput struct {
+	Adjusted int    `json:"adjusted" jsonschema_description:"how many expenses had their percentages rounded and were split again"`
+	Msg      string `json:"msg"`
+}
+
+func NormalizePercentageMaps(ctx context.Context, req *mcp.CallToolRequest, input *NormalizePercentageMapsInput) (*mcp.CallToolResult, *NormalizePercentageMapsOutput, error) {
+	group, res, err := lookupGroup(ctx, req, input.GroupName, "I need group name to clean up its percentage splits")
+	if res != nil || err != nil {
+		return res, nil, err
+	}
+
+	n := group.NormalizePercentageMaps()
+	msg := "all percentage splits were already clean"
+	if n > 0 {
+		msg = fmt.Sprintf("rounded the percentages of %d expenses and recomputed their debts", n)
+	}
+	output := &NormalizePercentageMapsOutput{
+		Adjusted: n,
+		Msg:      msg,
+	}
+	return nil, output, nil
+}
+
+type VerifyAllInput struct{}
+
+type VerifyAllOutput struct {
+	Problems map[string][]string `json:"problems" jsonschema_description:"consistency problems by group name; empty when everything checks out"`
+	Checked  int                 `json:"checked" jsonschema_description:"how many groups were checked"`
+}
+
+func VerifyAll(ctx context.Context, req *mcp.CallToolRequest, input *VerifyAllInput) (*mcp.CallToolResult, *VerifyAllOutput, error) {
+	results := groups.VerifyAll()
+	output := &VerifyAllOutput{
+		Problems: map[string][]string{},
+		Checked:  len(results),
+	}
+	for name, errs := range results {
+		for _, err := range errs {
+			output.Problems[name] = append(output.Problems[name], err.Error())
+		}
+	}
+	return nil, output, nil
+}